@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/auth"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesupdater "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_updater"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/health"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/licenses"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/osv"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/sbom"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/versions"
+)
+
+// runUpdateCommand runs the dependencies update once, without starting the API,
+// and prints its result as JSON.
+func runUpdateCommand(args []string, db *database.SQLiteDB, updater *dependenciesupdater.Updater) error {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print the planned changes without writing them to the database")
+	classes := fs.String("class", "", "comma separated update classes to apply, e.g. minor,patch")
+	fs.Parse(args)
+
+	var filter []string
+	if *classes != "" {
+		var err error
+		if filter, err = versions.ParseClasses([]string{*classes}); err != nil {
+			return err
+		}
+	}
+
+	if err := db.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create db tables due to an error: %w", err)
+	}
+
+	plan, err := updater.PlanUpdates()
+	if err != nil {
+		return err
+	}
+	if filter != nil {
+		plan = dependenciesupdater.FilterByClass(plan, filter)
+	}
+
+	var result any = plan
+	if !*dryRun {
+		result, err = updater.ApplyUpdates(plan)
+		if err != nil {
+			return err
+		}
+	}
+
+	return printJSON(result)
+}
+
+// runOsvImportCommand imports an OSV dump and matches it against the stored dependencies.
+func runOsvImportCommand(args []string, db *database.SQLiteDB) error {
+	fs := flag.NewFlagSet("osv-import", flag.ExitOnError)
+	dumpPath := fs.String("path", "", "path to an OSV zip file or a directory of OSV JSON files")
+	fs.Parse(args)
+
+	if *dumpPath == "" {
+		return fmt.Errorf("-path is required")
+	}
+
+	if err := db.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create db tables due to an error: %w", err)
+	}
+
+	vulnerabilities, err := osv.ReadDump(*dumpPath)
+	if err != nil {
+		return err
+	}
+	if err := db.ImportOsvVulnerabilities(vulnerabilities); err != nil {
+		return err
+	}
+
+	matches, err := db.MatchOsvVulnerabilities()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d OSV vulnerabilities, %d match stored dependencies\n", len(vulnerabilities), matches)
+	return nil
+}
+
+// runLicenseCheckCommand evaluates licenses of the stored dependencies against the policy,
+// prints the report and fails if any license is denied.
+func runLicenseCheckCommand(db *database.SQLiteDB, policy licenses.Policy) error {
+	if err := db.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create db tables due to an error: %w", err)
+	}
+
+	dependencies, err := db.GetAllDependencies()
+	if err != nil {
+		return err
+	}
+
+	report := policy.EvaluateAll(dependencies)
+	if err := printJSON(report); err != nil {
+		return err
+	}
+	if !report.Passed {
+		return fmt.Errorf("%d dependencies violate the license policy", len(report.Violations))
+	}
+	return nil
+}
+
+// runHealthCheckCommand evaluates health rules against the stored dependencies,
+// prints the verdicts and fails if the gate does not pass.
+func runHealthCheckCommand(db *database.SQLiteDB, policy health.Policy) error {
+	if err := db.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create db tables due to an error: %w", err)
+	}
+
+	report, err := evaluateHealth(db, policy)
+	if err != nil {
+		return err
+	}
+	if err := printJSON(report); err != nil {
+		return err
+	}
+	if !report.Passed {
+		return fmt.Errorf("%d dependencies failed the health gate", report.Failed)
+	}
+	return nil
+}
+
+// runSbomCommand exports the stored dependency graph as a CycloneDX or SPDX SBOM.
+func runSbomCommand(args []string, db *database.SQLiteDB) error {
+	fs := flag.NewFlagSet("sbom", flag.ExitOnError)
+	standard := fs.String("standard", sbom.StandardCycloneDX, "SBOM standard, cyclonedx or spdx")
+	format := fs.String("format", "", "output format, json (default) or xml for CycloneDX, json (default) or tag-value for SPDX")
+	output := fs.String("output", "", "file to write the SBOM to, stdout if empty")
+	fs.Parse(args)
+
+	if _, err := sbom.Validate(*standard, *format); err != nil {
+		return err
+	}
+
+	if err := db.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create db tables due to an error: %w", err)
+	}
+
+	graph, err := db.GetDependencyGraph()
+	if err != nil {
+		return err
+	}
+	dependencies, err := db.GetAllDependencies()
+	if err != nil {
+		return err
+	}
+	projectKeyIDs, err := db.GetProjectKeyIDsByName()
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return sbom.Export(os.Stdout, *standard, *format, graph, dependencies, projectKeyIDs)
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := sbom.Export(f, *standard, *format, graph, dependencies, projectKeyIDs); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runKeysCommand issues, revokes and lists API keys. An issued key is printed once, only its hash
+// is stored.
+func runKeysCommand(args []string, db *database.SQLiteDB) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected a subcommand: issue, revoke or list")
+	}
+	if err := db.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create db tables due to an error: %w", err)
+	}
+
+	switch args[0] {
+	case "issue":
+		fs := flag.NewFlagSet("keys issue", flag.ExitOnError)
+		name := fs.String("name", "", "name of the key, e.g. of its holder, used as the author of overrides")
+		roleName := fs.String("role", string(auth.RoleReader), "role of the key, reader, editor or admin")
+		fs.Parse(args[1:])
+
+		if *name == "" {
+			return fmt.Errorf("-name is required")
+		}
+		role, err := auth.ParseRole(*roleName)
+		if err != nil {
+			return err
+		}
+		key, hash, err := auth.NewKey()
+		if err != nil {
+			return err
+		}
+		stored, err := db.AddAPIKey(*name, string(role), hash)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "issued %s key %d for %s, it is shown only once:\n", stored.Role, stored.ID, stored.Name)
+		fmt.Println(key)
+		return nil
+	case "revoke":
+		fs := flag.NewFlagSet("keys revoke", flag.ExitOnError)
+		id := fs.Int("id", 0, "id of the key, as listed by keys list")
+		fs.Parse(args[1:])
+
+		if *id == 0 {
+			return fmt.Errorf("-id is required")
+		}
+		if err := db.RevokeAPIKey(*id); err != nil {
+			return err
+		}
+		fmt.Printf("revoked key %d\n", *id)
+		return nil
+	case "list":
+		keys, err := db.GetAPIKeys()
+		if err != nil {
+			return err
+		}
+		return printJSON(keys)
+	default:
+		return fmt.Errorf("unknown keys subcommand: %s, expected issue, revoke or list", args[0])
+	}
+}
+
+func evaluateHealth(db *database.SQLiteDB, policy health.Policy) (health.Report, error) {
+	dependencies, err := db.GetAllDependencies()
+	if err != nil {
+		return health.Report{}, err
+	}
+	vulnerable, err := db.GetVulnerableDependencies()
+	if err != nil {
+		return health.Report{}, err
+	}
+	return policy.Evaluate(dependencies, health.AdvisoriesByID(vulnerable)), nil
+}
+
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -1,21 +1,44 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"path"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/alerts"
 	"github.com/wojcikp/deps-dev-assignment/backend/internal/api"
 	"github.com/wojcikp/deps-dev-assignment/backend/internal/app"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/auth"
 	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
 	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
 	dependenciesupdater "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_updater"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/gomod"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/health"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/licenses"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/manifests"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/sbom"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/webhooks"
 )
 
 const repositoryApiUrl = "https://api.deps.dev/v3/systems/GO/packages/github.com%2Fcli%2Fcli/versions/v1.14.0:dependencies"
 
 func main() {
+	scorecardMaxAgeDays := flag.Int("scorecard-max-age-days", 7, "re-fetch dependency details when the Scorecard is older than this many days, 0 disables")
+	alertRules := flag.String("alert-rules", "", "path to a JSON file with Scorecard alert rules, built-in rules are used if empty")
+	licensePolicyPath := flag.String("license-policy", "", "path to a JSON or YAML license policy, built-in policy is used if empty")
+	healthPolicyPath := flag.String("health-policy", "", "path to a JSON file with dependency health rules, built-in rules are used if empty")
+	webhooksConfig := flag.String("webhooks-config", "", "path to a JSON file with webhooks notified about dependency changes")
+	sbomPath := flag.String("sbom", "", "path to a CycloneDX or SPDX document used as the dependency graph instead of resolving it with deps.dev")
+	goModPath := flag.String("gomod", "", "path to a go.mod file, with go.sum next to it, used as the dependency graph instead of resolving it with deps.dev")
+	goListPath := flag.String("go-list", "", "path to a file with the output of go list -m -json all used as the dependency graph instead of resolving it with deps.dev")
+	manifestPath := flag.String("manifest", "", "path to a package-lock.json, requirements.txt, Cargo.lock or pom.xml used as the dependency graph instead of resolving it with deps.dev")
+	protectReads := flag.Bool("protect-reads", false, "require an API key for reading as well, by default only writes need one")
+	flag.Parse()
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		log.Fatal(err)
@@ -26,10 +49,111 @@ func main() {
 		log.Fatal("failed to establish database connection, exiting...")
 	}
 
+	webhooksCfg, err := webhooks.LoadConfig(*webhooksConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	notifier := webhooks.NewDispatcher(webhooksCfg, db)
+
+	alertsCfg, err := alerts.LoadConfig(*alertRules)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	licensePolicy, err := licenses.LoadPolicy(*licensePolicyPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	healthPolicy, err := health.LoadPolicy(*healthPolicyPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	dependenciesLoader := dependenciesloader.NewDependenciesLoader(repositoryApiUrl)
-	dependenciesUpdater := dependenciesupdater.NewDependenciesUpdater(dependenciesLoader, db)
-	api := api.NewApi(db, dependenciesUpdater)
+	source, err := sourceOf(*sbomPath, *goModPath, *goListPath, *manifestPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if source != nil {
+		dependenciesLoader.SetSource(source)
+	}
+	dependenciesUpdater := dependenciesupdater.NewDependenciesUpdater(
+		dependenciesLoader,
+		db,
+		time.Duration(*scorecardMaxAgeDays)*24*time.Hour,
+		notifier,
+		alerts.NewEvaluator(alertsCfg),
+	)
+
+	switch flag.Arg(0) {
+	case "update":
+		err := runUpdateCommand(flag.Args()[1:], db, dependenciesUpdater)
+		notifier.Wait()
+		if err != nil {
+			log.Fatalf("update failed due to an error: %v", err)
+		}
+		return
+	case "osv-import":
+		if err := runOsvImportCommand(flag.Args()[1:], db); err != nil {
+			log.Fatalf("osv import failed due to an error: %v", err)
+		}
+		return
+	case "license-check":
+		if err := runLicenseCheckCommand(db, licensePolicy); err != nil {
+			log.Fatalf("license check failed: %v", err)
+		}
+		return
+	case "health-check":
+		if err := runHealthCheckCommand(db, healthPolicy); err != nil {
+			log.Fatalf("health check failed: %v", err)
+		}
+		return
+	case "sbom":
+		if err := runSbomCommand(flag.Args()[1:], db); err != nil {
+			log.Fatalf("sbom export failed due to an error: %v", err)
+		}
+		return
+	case "keys":
+		if err := runKeysCommand(flag.Args()[1:], db); err != nil {
+			log.Fatalf("keys command failed due to an error: %v", err)
+		}
+		return
+	case "":
+	default:
+		log.Fatalf("unknown command: %s", flag.Arg(0))
+	}
+
+	api := api.NewApi(db, dependenciesUpdater, notifier, licensePolicy, healthPolicy, auth.NewAuthenticator(db, *protectReads))
 	app := app.NewApp(dependenciesLoader, db, api)
 
 	app.Run()
 }
+
+// sourceOf returns the source of the dependency graph given by flags, nil means deps.dev.
+func sourceOf(sbomPath, goModPath, goListPath, manifestPath string) (dependenciesloader.Source, error) {
+	var sources []dependenciesloader.Source
+	if sbomPath != "" {
+		sources = append(sources, sbom.FileSource(sbomPath))
+	}
+	if goModPath != "" {
+		sources = append(sources, gomod.ModSource(goModPath))
+	}
+	if goListPath != "" {
+		sources = append(sources, gomod.ListSource(goListPath))
+	}
+	if manifestPath != "" {
+		source, err := manifests.NewFileSource(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	if len(sources) > 1 {
+		return nil, fmt.Errorf("only one of -sbom, -gomod, -go-list and -manifest can be used")
+	}
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	return sources[0], nil
+}
@@ -0,0 +1,155 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+const (
+	// RuleBelow fires when the score falls below Threshold.
+	RuleBelow = "below"
+	// RuleDrop fires when the score drops by more than Points since the previous Scorecard.
+	RuleDrop = "drop"
+	// RuleZero fires when a check falls to 0.
+	RuleZero = "zero"
+)
+
+// Rule is evaluated against the overallScore, or against the check named Check if it is set.
+type Rule struct {
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Check     string  `json:"check"`
+	Threshold float64 `json:"threshold"`
+	Points    float64 `json:"points"`
+}
+
+type Config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// DefaultConfig is used when no alert rules file is given.
+var DefaultConfig = Config{
+	Rules: []Rule{
+		{Name: "overall-score-below-5", Type: RuleBelow, Threshold: 5},
+		{Name: "overall-score-drop", Type: RuleDrop, Points: 1},
+		{Name: "maintained-zero", Type: RuleZero, Check: "Maintained"},
+		{Name: "vulnerabilities-zero", Type: RuleZero, Check: "Vulnerabilities"},
+		{Name: "code-review-zero", Type: RuleZero, Check: "Code-Review"},
+	},
+}
+
+// LoadConfig reads alert rules from a JSON file. An empty path means DefaultConfig.
+func LoadConfig(path string) (Config, error) {
+	if path == "" {
+		return DefaultConfig, nil
+	}
+
+	var config Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read alert rules: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse alert rules: %w", err)
+	}
+
+	for _, rule := range config.Rules {
+		switch rule.Type {
+		case RuleBelow, RuleDrop:
+		case RuleZero:
+			if rule.Check == "" {
+				return config, fmt.Errorf("alert rule %s: check is required for %s rules", rule.Name, RuleZero)
+			}
+		default:
+			return config, fmt.Errorf("alert rule %s: unknown type: %s", rule.Name, rule.Type)
+		}
+	}
+
+	return config, nil
+}
+
+type Evaluator struct {
+	rules []Rule
+}
+
+func NewEvaluator(config Config) *Evaluator {
+	return &Evaluator{config.Rules}
+}
+
+// Evaluate compares a newly fetched Scorecard with the previous one and returns alerts of the rules
+// that fire. Rules fire only on the transition, e.g. a score staying below a threshold alerts once.
+// Without a previous Scorecard only RuleBelow and RuleZero rules are checked.
+func (e *Evaluator) Evaluate(projectKeyID string, previous *dependenciesloader.Scorecard, current dependenciesloader.Scorecard) []database.Alert {
+	alerts := []database.Alert{}
+	if e == nil {
+		return alerts
+	}
+
+	for _, rule := range e.rules {
+		score, ok := scoreOf(rule.Check, &current)
+		if !ok {
+			continue
+		}
+		previousScore, hasPrevious := scoreOf(rule.Check, previous)
+
+		alert := database.Alert{
+			ProjectKeyID:  projectKeyID,
+			Rule:          rule.Name,
+			Check:         rule.Check,
+			PreviousScore: previousScore,
+			Score:         score,
+		}
+
+		switch rule.Type {
+		case RuleBelow:
+			if score >= rule.Threshold || (hasPrevious && previousScore < rule.Threshold) {
+				continue
+			}
+			alert.Message = fmt.Sprintf("%s of %s fell below %g: %g", subject(rule), projectKeyID, rule.Threshold, score)
+		case RuleDrop:
+			if !hasPrevious || previousScore-score <= rule.Points {
+				continue
+			}
+			alert.Message = fmt.Sprintf("%s of %s dropped by more than %g: %g -> %g", subject(rule), projectKeyID, rule.Points, previousScore, score)
+		case RuleZero:
+			if score != 0 || (hasPrevious && previousScore == 0) {
+				continue
+			}
+			alert.Message = fmt.Sprintf("%s of %s fell to 0", subject(rule), projectKeyID)
+		default:
+			continue
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts
+}
+
+// scoreOf returns the overallScore for an empty check name. Checks scored -1 are not applicable
+// to the project and are treated as missing.
+func scoreOf(check string, scorecard *dependenciesloader.Scorecard) (float64, bool) {
+	if scorecard == nil {
+		return 0, false
+	}
+	if check == "" {
+		return scorecard.OverallScore, true
+	}
+	for _, c := range scorecard.Checks {
+		if c.Name == check && c.Score >= 0 {
+			return float64(c.Score), true
+		}
+	}
+	return 0, false
+}
+
+func subject(rule Rule) string {
+	if rule.Check == "" {
+		return "overallScore"
+	}
+	return rule.Check + " check"
+}
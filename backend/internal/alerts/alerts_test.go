@@ -0,0 +1,51 @@
+package alerts
+
+import (
+	"testing"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+func scorecard(overallScore float64, checks map[string]int) *dependenciesloader.Scorecard {
+	s := &dependenciesloader.Scorecard{OverallScore: overallScore}
+	for name, score := range checks {
+		s.Checks = append(s.Checks, dependenciesloader.Check{Name: name, Score: score})
+	}
+	return s
+}
+
+func TestEvaluate(t *testing.T) {
+	evaluator := NewEvaluator(Config{Rules: []Rule{
+		{Name: "below", Type: RuleBelow, Threshold: 5},
+		{Name: "drop", Type: RuleDrop, Points: 1},
+		{Name: "maintained", Type: RuleZero, Check: "Maintained"},
+		{Name: "code-review-below", Type: RuleBelow, Check: "Code-Review", Threshold: 3},
+	}})
+
+	tests := []struct {
+		name     string
+		previous *dependenciesloader.Scorecard
+		current  *dependenciesloader.Scorecard
+		want     []string
+	}{
+		{"no change", scorecard(6, map[string]int{"Maintained": 5}), scorecard(6, map[string]int{"Maintained": 5}), nil},
+		{"fell below", scorecard(5.5, nil), scorecard(4.9, nil), []string{"below"}},
+		{"stays below", scorecard(4, nil), scorecard(3.5, nil), nil},
+		{"big drop", scorecard(8, nil), scorecard(6.5, nil), []string{"drop"}},
+		{"check fell to zero", scorecard(7, map[string]int{"Maintained": 3}), scorecard(7, map[string]int{"Maintained": 0}), []string{"maintained"}},
+		{"check not applicable", scorecard(7, map[string]int{"Code-Review": 8}), scorecard(7, map[string]int{"Code-Review": -1}), nil},
+		{"new dependency", nil, scorecard(4, map[string]int{"Maintained": 0, "Code-Review": 2}), []string{"below", "maintained", "code-review-below"}},
+	}
+
+	for _, tt := range tests {
+		got := evaluator.Evaluate("github.com/cli/cli", tt.previous, *tt.current)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: unexpected alerts, want: %v, got: %+v", tt.name, tt.want, got)
+		}
+		for i, alert := range got {
+			if alert.Rule != tt.want[i] {
+				t.Fatalf("%s: unexpected alert, want: %s, got: %s", tt.name, tt.want[i], alert.Rule)
+			}
+		}
+	}
+}
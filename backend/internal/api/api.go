@@ -1,128 +1,372 @@
 package api
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/auth"
 	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
 	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
 	dependenciesupdater "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_updater"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/health"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/licenses"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/sbom"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/versions"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/webhooks"
 )
 
+const maxSbomSize = 32 << 20
+
 type Api struct {
-	db      *database.SQLiteDB
-	updater *dependenciesupdater.Updater
+	db            *database.SQLiteDB
+	updater       *dependenciesupdater.Updater
+	notifier      *webhooks.Dispatcher
+	licensePolicy licenses.Policy
+	healthPolicy  health.Policy
+	// auth authorizes requests by their API keys, nil allows every request.
+	auth *auth.Authenticator
 }
 
-func NewApi(db *database.SQLiteDB, updater *dependenciesupdater.Updater) *Api {
-	return &Api{db, updater}
+func NewApi(
+	db *database.SQLiteDB,
+	updater *dependenciesupdater.Updater,
+	notifier *webhooks.Dispatcher,
+	licensePolicy licenses.Policy,
+	healthPolicy health.Policy,
+	authenticator *auth.Authenticator,
+) *Api {
+	return &Api{db, updater, notifier, licensePolicy, healthPolicy, authenticator}
 }
 
 func (a *Api) addDependency(w http.ResponseWriter, r *http.Request) {
-	var dependency dependenciesloader.DependencyDetails
-	if err := json.NewDecoder(r.Body).Decode(&dependency); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var request dependencyRequest
+	if err := decodeStrict(r.Body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err, detailsOf(err)...)
 		return
 	}
-	if err := a.db.AddNewDependencyDetails(dependency); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	stored, err := a.addProject(request.DependencyDetails)
+	if err != nil {
+		writeError(w, statusOf(err), err)
 		return
 	}
-	w.WriteHeader(http.StatusCreated)
-	res := fmt.Sprintf("created: %v", dependency)
-	json.NewEncoder(w).Encode(res)
+	w.Header().Set("Location", projectURL(stored.ProjectKey.ID))
+	w.Header().Set("ETag", database.ETag(*stored))
+	writeJSON(w, http.StatusCreated, stored)
 }
 
 func (a *Api) updateDependency(w http.ResponseWriter, r *http.Request) {
-	var dependency dependenciesloader.DependencyDetails
-	if err := json.NewDecoder(r.Body).Decode(&dependency); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var request dependencyRequest
+	if err := decodeStrict(r.Body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err, detailsOf(err)...)
 		return
 	}
-	if err := a.db.UpdateDependencyDetails(dependency); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	// in /api/v1 the project is given by the path, the body may leave it out
+	id := ""
+	if _, ok := mux.Vars(r)["id"]; ok {
+		id = param(r, "id")
+	}
+	stored, err := a.updateProject(request.DependencyDetails, id)
+	if err != nil {
+		writeError(w, statusOf(err), err)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
-	res := fmt.Sprintf("updated with details dependency: %v", dependency)
-	json.NewEncoder(w).Encode(res)
+	w.Header().Set("ETag", database.ETag(*stored))
+	writeJSON(w, http.StatusOK, stored)
+}
+
+// addProject validates and stores a new dependency sent to the REST or gRPC API and notifies webhooks
+// about it. Invalid fields are returned as an invalidFieldsError.
+func (a *Api) addProject(dependency dependenciesloader.DependencyDetails) (*dependenciesloader.DependencyDetails, error) {
+	if err := a.resolvePurl(&dependency); err != nil {
+		return nil, &invalidFieldsError{err, []ErrorDetail{{Field: "purl", Message: err.Error()}}}
+	}
+	if details := validateDependency(dependency); len(details) > 0 {
+		return nil, &invalidFieldsError{fmt.Errorf("%w: dependency has %d invalid fields", database.ErrInvalidInput, len(details)), details}
+	}
+	if err := a.db.AddNewDependencyDetails(dependency); err != nil {
+		return nil, err
+	}
+	stored, err := a.db.GetDependencyDetailsByID(dependency.ProjectKey.ID)
+	if err != nil {
+		return nil, err
+	}
+	a.notifier.Notify(
+		webhooks.Event{Type: webhooks.EventDependencyAdded, Dependency: stored.ProjectKey.ID, Purl: stored.Purl, License: stored.License},
+		webhooks.Event{Type: webhooks.EventScoreBelowThreshold, Dependency: stored.ProjectKey.ID, Purl: stored.Purl, OverallScore: &stored.Scorecard.OverallScore},
+	)
+	return stored, nil
+}
+
+// updateProject validates and replaces the details of a stored dependency. A non-empty id, an ID or
+// package URL, names the project to update, the project key of the dependency may be left out then.
+func (a *Api) updateProject(dependency dependenciesloader.DependencyDetails, id string) (*dependenciesloader.DependencyDetails, error) {
+	if err := a.resolvePurl(&dependency); err != nil {
+		return nil, &invalidFieldsError{err, []ErrorDetail{{Field: "purl", Message: err.Error()}}}
+	}
+	if id != "" {
+		id, err := a.resolveID(id)
+		if err != nil {
+			return nil, err
+		}
+		if dependency.ProjectKey.ID == "" {
+			dependency.ProjectKey.ID = id
+		} else if dependency.ProjectKey.ID != id {
+			err := fmt.Errorf("%w: projectKey %s does not match the project %s of the path", database.ErrInvalidInput, dependency.ProjectKey.ID, id)
+			return nil, &invalidFieldsError{err, []ErrorDetail{{Field: "projectKey.id", Message: "must match the project of the path"}}}
+		}
+	}
+	if details := validateDependency(dependency); len(details) > 0 {
+		return nil, &invalidFieldsError{fmt.Errorf("%w: dependency has %d invalid fields", database.ErrInvalidInput, len(details)), details}
+	}
+	if err := a.db.UpdateDependencyDetails(dependency); err != nil {
+		return nil, err
+	}
+	return a.db.GetDependencyDetailsByID(dependency.ProjectKey.ID)
 }
 
 func (a *Api) getDependencyByID(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id, err := a.resolveID(param(r, "id"))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
 	dependency, err := a.db.GetDependencyDetailsByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, statusOf(err), err)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(dependency)
+	w.Header().Set("ETag", database.ETag(*dependency))
+	writeJSON(w, http.StatusOK, dependency)
 }
 
 func (a *Api) deleteDependency(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
-	err := a.db.DeleteDependencyWithDetails(id)
+	id, err := a.resolveID(param(r, "id"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, statusOf(err), err)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
-	res := fmt.Sprintf("removed with details dependency: %s", id)
-	json.NewEncoder(w).Encode(res)
+	if err := a.db.DeleteDependencyWithDetails(id); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (a *Api) getDependencyByScore(w http.ResponseWriter, r *http.Request) {
-	scoreParam := mux.Vars(r)["score"]
+	scoreParam := param(r, "score")
 	score, err := strconv.ParseFloat(scoreParam, 64)
 	if err != nil {
-		http.Error(w, "Invalid score", http.StatusInternalServerError)
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid score: %s", scoreParam))
 		return
 	}
 	results, err := a.db.GetDependenciesByOverallScore(score)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, statusOf(err), err)
 		return
 	}
-	json.NewEncoder(w).Encode(results)
+	writeJSON(w, http.StatusOK, results)
 }
 
 func (a *Api) getAllDependencies(w http.ResponseWriter, r *http.Request) {
 	results, err := a.db.GetAllDependencies()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, statusOf(err), err)
 		return
 	}
-	json.NewEncoder(w).Encode(results)
+	writeJSON(w, http.StatusOK, results)
 }
 
 func (a *Api) updateAllDependencies(w http.ResponseWriter, r *http.Request) {
-	updatedDependencies, err := a.updater.UpdateDependencies()
+	dryRun := false
+	if dryRunParam := r.URL.Query().Get("dryRun"); dryRunParam != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(dryRunParam)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid dryRun: %s", dryRunParam))
+			return
+		}
+	}
+
+	classes, err := versions.ParseClasses(r.URL.Query()["class"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	plan, updatedDependencies, err := a.updater.Refresh(dryRun, classes)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if dryRun {
+		writeJSON(w, http.StatusOK, plan)
+		return
+	}
+	writeJSON(w, http.StatusOK, updatedDependencies)
+}
+
+func (a *Api) getDependencyAdvisories(w http.ResponseWriter, r *http.Request) {
+	id, err := a.resolveID(param(r, "id"))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	advisories, err := a.db.GetAdvisoriesByID(id)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, advisories)
+}
+
+func (a *Api) getVulnerableDependencies(w http.ResponseWriter, r *http.Request) {
+	results, err := a.db.GetVulnerableDependencies()
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (a *Api) getLicensePolicyReport(w http.ResponseWriter, r *http.Request) {
+	dependencies, err := a.db.GetAllDependencies()
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, a.licensePolicy.EvaluateAll(dependencies))
+}
+
+func (a *Api) getHealthPolicyReport(w http.ResponseWriter, r *http.Request) {
+	dependencies, err := a.db.GetAllDependencies()
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	vulnerable, err := a.db.GetVulnerableDependencies()
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, a.healthPolicy.Evaluate(dependencies, health.AdvisoriesByID(vulnerable)))
+}
+
+func (a *Api) getSbom(w http.ResponseWriter, r *http.Request) {
+	standard := mux.Vars(r)["standard"]
+	format, err := sbom.Validate(standard, r.URL.Query().Get("format"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	graph, err := a.db.GetDependencyGraph()
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	dependencies, err := a.db.GetAllDependencies()
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	projectKeyIDs, err := a.db.GetProjectKeyIDsByName()
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := sbom.Export(&buf, standard, format, graph, dependencies, projectKeyIDs); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	w.Header().Set("Content-Type", sbom.ContentType(standard, format))
+	w.Write(buf.Bytes())
+}
+
+func (a *Api) importSbom(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSbomSize))
+	if err != nil {
+		status := statusOf(err)
+		if status == http.StatusInternalServerError {
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, fmt.Errorf("failed to read request body: %w", err))
+		return
+	}
+	dependencies, err := sbom.Import(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	updatedDependencies, err := a.updater.ImportDependencies(dependencies)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updatedDependencies)
+}
+
+func (a *Api) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", limitParam))
+			return
+		}
+	}
+	deliveries, err := a.db.GetWebhookDeliveries(limit)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+func (a *Api) getAlerts(w http.ResponseWriter, r *http.Request) {
+	unacknowledgedOnly := false
+	if param := r.URL.Query().Get("unacknowledged"); param != "" {
+		var err error
+		unacknowledgedOnly, err = strconv.ParseBool(param)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid unacknowledged: %s", param))
+			return
+		}
+	}
+	results, err := a.db.GetAlerts(unacknowledgedOnly)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (a *Api) acknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["alertId"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid alert id: %s", mux.Vars(r)["alertId"]))
+		return
+	}
+	if err := a.db.AcknowledgeAlert(id); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	alert, err := a.db.GetAlert(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, statusOf(err), err)
 		return
 	}
-	json.NewEncoder(w).Encode(updatedDependencies)
+	writeJSON(w, http.StatusOK, alert)
 }
 
 func (a *Api) Run() {
-	r := mux.NewRouter()
-	h := handlers.CORS(
-		handlers.AllowedOrigins([]string{"http://localhost:8080"}),
-		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE"}),
-		handlers.AllowedHeaders([]string{"Content-Type", "application/json"}),
-	)(r)
-
-	r.HandleFunc("/dependency", a.getDependencyByID).Methods("GET")
-	r.HandleFunc("/dependency/score/{score}", a.getDependencyByScore).Methods("GET")
-	r.HandleFunc("/dependency/all", a.getAllDependencies).Methods("GET")
-	r.HandleFunc("/dependency/update", a.updateAllDependencies).Methods("GET")
-	r.HandleFunc("/dependency", a.addDependency).Methods("POST")
-	r.HandleFunc("/dependency", a.updateDependency).Methods("PUT")
-	r.HandleFunc("/dependency", a.deleteDependency).Methods("DELETE")
-
-	http.ListenAndServe(":3000", h)
+	go a.runGRPC()
+	http.ListenAndServe(":3000", a.handler())
 }
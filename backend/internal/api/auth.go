@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/auth"
+)
+
+// authorize lets requests whose key has the role through to next, an empty role lets requests without
+// a key through as well. Keys are sent as Authorization: Bearer <key> or X-API-Key: <key>.
+func (a *Api) authorize(role auth.Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, err := a.auth.Authorize(auth.KeyOf(r.Header.Get("Authorization"), r.Header.Get("X-API-Key")), role)
+		if err != nil {
+			status := statusOf(err)
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="deps dev app"`)
+			}
+			writeError(w, status, err)
+			return
+		}
+		if key != nil {
+			r = r.WithContext(auth.WithKey(r.Context(), key))
+		}
+		next(w, r)
+	}
+}
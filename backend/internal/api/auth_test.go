@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/api/depsv1"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/auth"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAuthorization(t *testing.T) {
+	db, keys := getAuthTestDatabase(t)
+	handler := (&Api{db: db, auth: auth.NewAuthenticator(db, false)}).handler()
+
+	do := func(method, target, key, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			request.Header.Set("Authorization", "Bearer "+key)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+	expect := func(response *httptest.ResponseRecorder, status int) {
+		t.Helper()
+		if response.Code != status {
+			t.Fatalf("want status %d, got %d: %s", status, response.Code, response.Body)
+		}
+	}
+
+	const project = "/api/v1/projects/github.com%2Fcli%2Fcli"
+	body := `{"projectKey": {"id": "github.com/cli/cli"}, "license": "MIT", "scorecard": {"date": "2024-01-01T00:00:00Z"}}`
+
+	response := do("POST", "/api/v1/projects", "", body)
+	expect(response, http.StatusUnauthorized)
+	if !strings.HasPrefix(response.Header().Get("WWW-Authenticate"), "Bearer") || !strings.Contains(response.Body.String(), `"code":"unauthenticated"`) {
+		t.Fatalf("unexpected response without a key: %v %s", response.Header(), response.Body)
+	}
+	expect(do("POST", "/api/v1/projects", "dda_unknown", body), http.StatusUnauthorized)
+	response = do("POST", "/api/v1/projects", keys[auth.RoleReader], body)
+	expect(response, http.StatusForbidden)
+	if !strings.Contains(response.Body.String(), `"code":"forbidden"`) {
+		t.Fatalf("unexpected response of a reader: %s", response.Body)
+	}
+	expect(do("POST", "/api/v1/projects", keys[auth.RoleEditor], body), http.StatusCreated)
+
+	// reads are open, but a given key must be valid
+	expect(do("GET", project, "", ""), http.StatusOK)
+	expect(do("POST", "/graphql", "", `{"query": "{ project(id: \"github.com/cli/cli\") { license } }"}`), http.StatusOK)
+	expect(do("GET", project, "dda_unknown", ""), http.StatusUnauthorized)
+
+	// the author of an override is the name of the key, also when the body names another one
+	for _, body := range []string{`{"value": "Apache-2.0", "reason": "checked"}`, `{"value": "Apache-2.0", "reason": "checked", "author": "admin"}`} {
+		response = do("PUT", project+"/overrides/license", keys[auth.RoleEditor], body)
+		expect(response, http.StatusOK)
+		if !strings.Contains(response.Body.String(), `"author":"editor"`) {
+			t.Fatalf("want the override authored by the key, got %s", response.Body)
+		}
+	}
+
+	expect(do("POST", "/api/v1/refresh?dryRun=true", keys[auth.RoleEditor], ""), http.StatusForbidden)
+	expect(do("GET", "/dependency/update?dryRun=true", keys[auth.RoleEditor], ""), http.StatusForbidden)
+	expect(do("DELETE", project, keys[auth.RoleEditor], ""), http.StatusForbidden)
+
+	request := httptest.NewRequest("DELETE", project, nil)
+	request.Header.Set("X-API-Key", keys[auth.RoleAdmin])
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	expect(recorder, http.StatusNoContent)
+
+	protected := (&Api{db: db, auth: auth.NewAuthenticator(db, true)}).handler()
+	recorder = httptest.NewRecorder()
+	protected.ServeHTTP(recorder, httptest.NewRequest("GET", "/api/v1/projects", nil))
+	expect(recorder, http.StatusUnauthorized)
+}
+
+func TestGRPCAuthorization(t *testing.T) {
+	db, keys := getAuthTestDatabase(t)
+	conn := dialGRPC(t, &Api{db: db, auth: auth.NewAuthenticator(db, false)})
+	client := depsv1.NewDependencyServiceClient(conn)
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+key)
+	}
+
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal("health should be open without a key:", err)
+	}
+
+	dependency := &depsv1.Dependency{ProjectKey: &depsv1.ProjectKey{Id: "github.com/cli/cli"}, Scorecard: &depsv1.Scorecard{Date: "2024-01-01T00:00:00Z"}}
+	_, err := client.AddDependency(context.Background(), &depsv1.AddDependencyRequest{Dependency: dependency})
+	expectCode(t, err, codes.Unauthenticated)
+	_, err = client.AddDependency(withKey(keys[auth.RoleReader]), &depsv1.AddDependencyRequest{Dependency: dependency})
+	expectCode(t, err, codes.PermissionDenied)
+	if _, err := client.AddDependency(withKey(keys[auth.RoleEditor]), &depsv1.AddDependencyRequest{Dependency: dependency}); err != nil {
+		t.Fatal("failed to add dependency with an editor key:", err)
+	}
+
+	if _, err := client.GetDependency(context.Background(), &depsv1.GetDependencyRequest{Id: "github.com/cli/cli"}); err != nil {
+		t.Fatal("reads should be open without a key:", err)
+	}
+
+	stream, err := client.RefreshDependencies(withKey(keys[auth.RoleEditor]), &depsv1.RefreshDependenciesRequest{DryRun: true})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	expectCode(t, err, codes.PermissionDenied)
+
+	_, err = client.DeleteDependency(withKey(keys[auth.RoleEditor]), &depsv1.DeleteDependencyRequest{Id: "github.com/cli/cli"})
+	expectCode(t, err, codes.PermissionDenied)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", keys[auth.RoleAdmin])
+	if _, err := client.DeleteDependency(ctx, &depsv1.DeleteDependencyRequest{Id: "github.com/cli/cli"}); err != nil {
+		t.Fatal("failed to delete dependency with an admin key:", err)
+	}
+}
+
+func TestOpenAPISecurity(t *testing.T) {
+	db, _ := getAuthTestDatabase(t)
+	spec := openAPIDocument(t, &Api{db: db, auth: auth.NewAuthenticator(db, false)})
+	paths := spec["paths"].(map[string]any)
+
+	if _, ok := spec["components"].(map[string]any)["securitySchemes"]; !ok {
+		t.Fatal("security schemes are missing")
+	}
+	for _, op := range []struct {
+		path, method string
+		secured      bool
+	}{
+		{"/api/v1/projects/{id}", "delete", true},
+		{"/api/v1/refresh", "post", true},
+		{"/api/v1/projects/{id}", "get", false},
+	} {
+		doc := paths[op.path].(map[string]any)[op.method].(map[string]any)
+		if _, secured := doc["security"]; secured != op.secured {
+			t.Errorf("%s %s: want secured %v, got %v", op.method, op.path, op.secured, secured)
+		}
+	}
+}
+
+// getAuthTestDatabase returns a database with a key of every role, keys are mapped by the role and
+// named after it.
+func getAuthTestDatabase(t *testing.T) (*database.SQLiteDB, map[auth.Role]string) {
+	t.Helper()
+	db, err := database.NewSQLiteDB(path.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	t.Cleanup(func() { db.CloseDbConnection() })
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	keys := map[auth.Role]string{}
+	for _, role := range auth.Roles {
+		key, hash, err := auth.NewKey()
+		if err != nil {
+			t.Fatal("failed to generate key:", err)
+		}
+		if _, err := db.AddAPIKey(string(role), string(role), hash); err != nil {
+			t.Fatal("failed to add key:", err)
+		}
+		keys[role] = key
+	}
+	return db, keys
+}
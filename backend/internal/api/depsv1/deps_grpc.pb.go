@@ -0,0 +1,341 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: deps/v1/deps.proto
+
+package depsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DependencyService_GetDependency_FullMethodName       = "/deps.v1.DependencyService/GetDependency"
+	DependencyService_ListDependencies_FullMethodName    = "/deps.v1.DependencyService/ListDependencies"
+	DependencyService_AddDependency_FullMethodName       = "/deps.v1.DependencyService/AddDependency"
+	DependencyService_UpdateDependency_FullMethodName    = "/deps.v1.DependencyService/UpdateDependency"
+	DependencyService_DeleteDependency_FullMethodName    = "/deps.v1.DependencyService/DeleteDependency"
+	DependencyService_RefreshDependencies_FullMethodName = "/deps.v1.DependencyService/RefreshDependencies"
+)
+
+// DependencyServiceClient is the client API for DependencyService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// DependencyService serves the operations of the REST API on dependencies over gRPC. Errors of the
+// database are returned with the codes NOT_FOUND, ALREADY_EXISTS, INVALID_ARGUMENT and FAILED_PRECONDITION,
+// invalid fields are listed in a google.rpc.BadRequest detail.
+//
+// Calls are authorized by an API key sent in authorization metadata as a bearer token or in x-api-key
+// metadata. Adding and updating need an editor key, deleting and refreshing an admin key, calls without
+// a valid key fail with UNAUTHENTICATED and calls with a key of a role which isn't allowed with PERMISSION_DENIED.
+type DependencyServiceClient interface {
+	// GetDependency returns a dependency by its ID or package URL.
+	GetDependency(ctx context.Context, in *GetDependencyRequest, opts ...grpc.CallOption) (*Dependency, error)
+	// ListDependencies returns dependencies matching every given filter, ordered by ID.
+	ListDependencies(ctx context.Context, in *ListDependenciesRequest, opts ...grpc.CallOption) (*ListDependenciesResponse, error)
+	AddDependency(ctx context.Context, in *AddDependencyRequest, opts ...grpc.CallOption) (*Dependency, error)
+	// UpdateDependency replaces the details of a stored dependency.
+	UpdateDependency(ctx context.Context, in *UpdateDependencyRequest, opts ...grpc.CallOption) (*Dependency, error)
+	DeleteDependency(ctx context.Context, in *DeleteDependencyRequest, opts ...grpc.CallOption) (*DeleteDependencyResponse, error)
+	// RefreshDependencies updates dependencies from deps.dev, streaming the progress of planning and
+	// applying the updates and the result last.
+	RefreshDependencies(ctx context.Context, in *RefreshDependenciesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RefreshDependenciesResponse], error)
+}
+
+type dependencyServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDependencyServiceClient(cc grpc.ClientConnInterface) DependencyServiceClient {
+	return &dependencyServiceClient{cc}
+}
+
+func (c *dependencyServiceClient) GetDependency(ctx context.Context, in *GetDependencyRequest, opts ...grpc.CallOption) (*Dependency, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Dependency)
+	err := c.cc.Invoke(ctx, DependencyService_GetDependency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dependencyServiceClient) ListDependencies(ctx context.Context, in *ListDependenciesRequest, opts ...grpc.CallOption) (*ListDependenciesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDependenciesResponse)
+	err := c.cc.Invoke(ctx, DependencyService_ListDependencies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dependencyServiceClient) AddDependency(ctx context.Context, in *AddDependencyRequest, opts ...grpc.CallOption) (*Dependency, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Dependency)
+	err := c.cc.Invoke(ctx, DependencyService_AddDependency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dependencyServiceClient) UpdateDependency(ctx context.Context, in *UpdateDependencyRequest, opts ...grpc.CallOption) (*Dependency, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Dependency)
+	err := c.cc.Invoke(ctx, DependencyService_UpdateDependency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dependencyServiceClient) DeleteDependency(ctx context.Context, in *DeleteDependencyRequest, opts ...grpc.CallOption) (*DeleteDependencyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteDependencyResponse)
+	err := c.cc.Invoke(ctx, DependencyService_DeleteDependency_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dependencyServiceClient) RefreshDependencies(ctx context.Context, in *RefreshDependenciesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RefreshDependenciesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DependencyService_ServiceDesc.Streams[0], DependencyService_RefreshDependencies_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RefreshDependenciesRequest, RefreshDependenciesResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DependencyService_RefreshDependenciesClient = grpc.ServerStreamingClient[RefreshDependenciesResponse]
+
+// DependencyServiceServer is the server API for DependencyService service.
+// All implementations must embed UnimplementedDependencyServiceServer
+// for forward compatibility.
+//
+// DependencyService serves the operations of the REST API on dependencies over gRPC. Errors of the
+// database are returned with the codes NOT_FOUND, ALREADY_EXISTS, INVALID_ARGUMENT and FAILED_PRECONDITION,
+// invalid fields are listed in a google.rpc.BadRequest detail.
+//
+// Calls are authorized by an API key sent in authorization metadata as a bearer token or in x-api-key
+// metadata. Adding and updating need an editor key, deleting and refreshing an admin key, calls without
+// a valid key fail with UNAUTHENTICATED and calls with a key of a role which isn't allowed with PERMISSION_DENIED.
+type DependencyServiceServer interface {
+	// GetDependency returns a dependency by its ID or package URL.
+	GetDependency(context.Context, *GetDependencyRequest) (*Dependency, error)
+	// ListDependencies returns dependencies matching every given filter, ordered by ID.
+	ListDependencies(context.Context, *ListDependenciesRequest) (*ListDependenciesResponse, error)
+	AddDependency(context.Context, *AddDependencyRequest) (*Dependency, error)
+	// UpdateDependency replaces the details of a stored dependency.
+	UpdateDependency(context.Context, *UpdateDependencyRequest) (*Dependency, error)
+	DeleteDependency(context.Context, *DeleteDependencyRequest) (*DeleteDependencyResponse, error)
+	// RefreshDependencies updates dependencies from deps.dev, streaming the progress of planning and
+	// applying the updates and the result last.
+	RefreshDependencies(*RefreshDependenciesRequest, grpc.ServerStreamingServer[RefreshDependenciesResponse]) error
+	mustEmbedUnimplementedDependencyServiceServer()
+}
+
+// UnimplementedDependencyServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDependencyServiceServer struct{}
+
+func (UnimplementedDependencyServiceServer) GetDependency(context.Context, *GetDependencyRequest) (*Dependency, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDependency not implemented")
+}
+func (UnimplementedDependencyServiceServer) ListDependencies(context.Context, *ListDependenciesRequest) (*ListDependenciesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDependencies not implemented")
+}
+func (UnimplementedDependencyServiceServer) AddDependency(context.Context, *AddDependencyRequest) (*Dependency, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddDependency not implemented")
+}
+func (UnimplementedDependencyServiceServer) UpdateDependency(context.Context, *UpdateDependencyRequest) (*Dependency, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateDependency not implemented")
+}
+func (UnimplementedDependencyServiceServer) DeleteDependency(context.Context, *DeleteDependencyRequest) (*DeleteDependencyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDependency not implemented")
+}
+func (UnimplementedDependencyServiceServer) RefreshDependencies(*RefreshDependenciesRequest, grpc.ServerStreamingServer[RefreshDependenciesResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method RefreshDependencies not implemented")
+}
+func (UnimplementedDependencyServiceServer) mustEmbedUnimplementedDependencyServiceServer() {}
+func (UnimplementedDependencyServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeDependencyServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DependencyServiceServer will
+// result in compilation errors.
+type UnsafeDependencyServiceServer interface {
+	mustEmbedUnimplementedDependencyServiceServer()
+}
+
+func RegisterDependencyServiceServer(s grpc.ServiceRegistrar, srv DependencyServiceServer) {
+	// If the following call pancis, it indicates UnimplementedDependencyServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DependencyService_ServiceDesc, srv)
+}
+
+func _DependencyService_GetDependency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDependencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DependencyServiceServer).GetDependency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DependencyService_GetDependency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DependencyServiceServer).GetDependency(ctx, req.(*GetDependencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DependencyService_ListDependencies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDependenciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DependencyServiceServer).ListDependencies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DependencyService_ListDependencies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DependencyServiceServer).ListDependencies(ctx, req.(*ListDependenciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DependencyService_AddDependency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddDependencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DependencyServiceServer).AddDependency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DependencyService_AddDependency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DependencyServiceServer).AddDependency(ctx, req.(*AddDependencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DependencyService_UpdateDependency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateDependencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DependencyServiceServer).UpdateDependency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DependencyService_UpdateDependency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DependencyServiceServer).UpdateDependency(ctx, req.(*UpdateDependencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DependencyService_DeleteDependency_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteDependencyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DependencyServiceServer).DeleteDependency(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DependencyService_DeleteDependency_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DependencyServiceServer).DeleteDependency(ctx, req.(*DeleteDependencyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DependencyService_RefreshDependencies_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RefreshDependenciesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DependencyServiceServer).RefreshDependencies(m, &grpc.GenericServerStream[RefreshDependenciesRequest, RefreshDependenciesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DependencyService_RefreshDependenciesServer = grpc.ServerStreamingServer[RefreshDependenciesResponse]
+
+// DependencyService_ServiceDesc is the grpc.ServiceDesc for DependencyService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DependencyService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "deps.v1.DependencyService",
+	HandlerType: (*DependencyServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetDependency",
+			Handler:    _DependencyService_GetDependency_Handler,
+		},
+		{
+			MethodName: "ListDependencies",
+			Handler:    _DependencyService_ListDependencies_Handler,
+		},
+		{
+			MethodName: "AddDependency",
+			Handler:    _DependencyService_AddDependency_Handler,
+		},
+		{
+			MethodName: "UpdateDependency",
+			Handler:    _DependencyService_UpdateDependency_Handler,
+		},
+		{
+			MethodName: "DeleteDependency",
+			Handler:    _DependencyService_DeleteDependency_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RefreshDependencies",
+			Handler:       _DependencyService_RefreshDependencies_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "deps/v1/deps.proto",
+}
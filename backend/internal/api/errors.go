@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/auth"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+)
+
+// ErrorResponse is the body of every failed request.
+type ErrorResponse struct {
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Details []ErrorDetail `json:"details"`
+}
+
+// ErrorDetail describes a problem with a single field of the request.
+type ErrorDetail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+var errorCodes = map[int]string{
+	http.StatusBadRequest:            "invalid_input",
+	http.StatusUnauthorized:          "unauthenticated",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusPreconditionFailed:    "precondition_failed",
+	http.StatusRequestEntityTooLarge: "payload_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusInternalServerError:   "internal",
+}
+
+// statusOf maps errors of the database to HTTP statuses, unknown errors are internal.
+func statusOf(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, database.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, database.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, database.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, auth.ErrForbidden):
+		return http.StatusForbidden
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// invalidFieldsError is an ErrInvalidInput with the invalid fields listed in details of the response.
+type invalidFieldsError struct {
+	err     error
+	details []ErrorDetail
+}
+
+func (e *invalidFieldsError) Error() string { return e.err.Error() }
+
+func (e *invalidFieldsError) Unwrap() error { return e.err }
+
+func writeError(w http.ResponseWriter, status int, err error, details ...ErrorDetail) {
+	if status == http.StatusInternalServerError {
+		log.Printf("request failed due to an error: %v", err)
+	}
+	code, ok := errorCodes[status]
+	if !ok {
+		code = "error"
+	}
+	var fieldsErr *invalidFieldsError
+	if details == nil && errors.As(err, &fieldsErr) {
+		details = fieldsErr.details
+	}
+	if details == nil {
+		details = []ErrorDetail{}
+	}
+	writeJSON(w, status, ErrorResponse{Code: code, Message: err.Error(), Details: details})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,414 @@
+package api
+
+//go:generate sh -c "cd ../.. && buf generate"
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/api/depsv1"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/auth"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	dependenciesupdater "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_updater"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/versions"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	grpcAddress         = ":3001"
+	defaultGRPCPageSize = 20
+	maxGRPCPageSize     = 100
+)
+
+// grpcRoles lists the roles needed for methods of the dependency service, like the roles of the
+// corresponding routes. Health and reflection are open to every request.
+var grpcRoles = map[string]auth.Role{
+	depsv1.DependencyService_GetDependency_FullMethodName:       auth.RoleReader,
+	depsv1.DependencyService_ListDependencies_FullMethodName:    auth.RoleReader,
+	depsv1.DependencyService_AddDependency_FullMethodName:       auth.RoleEditor,
+	depsv1.DependencyService_UpdateDependency_FullMethodName:    auth.RoleEditor,
+	depsv1.DependencyService_DeleteDependency_FullMethodName:    auth.RoleAdmin,
+	depsv1.DependencyService_RefreshDependencies_FullMethodName: auth.RoleAdmin,
+}
+
+// dependencyService implements the gRPC API of proto/deps/v1/deps.proto with the methods shared with
+// the REST API.
+type dependencyService struct {
+	depsv1.UnimplementedDependencyServiceServer
+	api *Api
+}
+
+// grpcServer returns a server of the dependency service along with the standard health service and
+// server reflection, e.g. for grpcurl.
+func (a *Api) grpcServer(opts ...grpc.ServerOption) *grpc.Server {
+	if a.auth != nil {
+		opts = append(opts, grpc.ChainUnaryInterceptor(a.authorizeUnary), grpc.ChainStreamInterceptor(a.authorizeStream))
+	}
+	server := grpc.NewServer(opts...)
+	depsv1.RegisterDependencyServiceServer(server, &dependencyService{api: a})
+
+	healthServer := grpchealth.NewServer()
+	healthServer.SetServingStatus(depsv1.DependencyService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server
+}
+
+func (a *Api) runGRPC() {
+	listener, err := net.Listen("tcp", grpcAddress)
+	if err != nil {
+		log.Printf("failed to listen for gRPC requests due to an error: %v", err)
+		return
+	}
+	if err := a.grpcServer().Serve(listener); err != nil {
+		log.Printf("gRPC server stopped due to an error: %v", err)
+	}
+}
+
+func (a *Api) authorizeUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authorizeGRPC(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *Api) authorizeStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorizeGRPC(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authorizedStream{stream, ctx})
+}
+
+// authorizeGRPC authorizes a call by the key in its authorization, as a bearer token, or x-api-key
+// metadata and returns the context of the call with the key.
+func (a *Api) authorizeGRPC(ctx context.Context, method string) (context.Context, error) {
+	role, ok := grpcRoles[method]
+	if !ok {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	key, err := a.auth.Authorize(auth.KeyOf(firstOf(md.Get("authorization")), firstOf(md.Get("x-api-key"))), a.auth.Required(role))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if key != nil {
+		ctx = auth.WithKey(ctx, key)
+	}
+	return ctx, nil
+}
+
+// authorizedStream is a stream whose context has the key the call was authorized with.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context { return s.ctx }
+
+func firstOf(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (s *dependencyService) GetDependency(ctx context.Context, req *depsv1.GetDependencyRequest) (*depsv1.Dependency, error) {
+	id, err := s.api.resolveID(req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	dependency, err := s.api.db.GetDependencyDetailsByID(id)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return dependencyToProto(dependency), nil
+}
+
+func (s *dependencyService) ListDependencies(ctx context.Context, req *depsv1.ListDependenciesRequest) (*depsv1.ListDependenciesResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, grpcError(&invalidFieldsError{
+			fmt.Errorf("%w: invalid page_size: %d", database.ErrInvalidInput, pageSize),
+			[]ErrorDetail{{Field: "page_size", Message: "must not be negative"}},
+		})
+	case pageSize == 0:
+		pageSize = defaultGRPCPageSize
+	case pageSize > maxGRPCPageSize:
+		pageSize = maxGRPCPageSize
+	}
+
+	dependencies, err := s.api.db.FindDependencies(database.DependencyFilter{
+		Search:   req.GetSearch(),
+		License:  req.GetLicense(),
+		MinScore: req.MinScore,
+		MaxScore: req.MaxScore,
+		// nil when the optional field isn't set, like the scores
+		Vulnerable: req.Vulnerable,
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	start := 0
+	if req.GetPageToken() != "" {
+		after, err := base64.RawURLEncoding.DecodeString(req.GetPageToken())
+		if err != nil {
+			return nil, grpcError(&invalidFieldsError{
+				fmt.Errorf("%w: invalid page_token: %s", database.ErrInvalidInput, req.GetPageToken()),
+				[]ErrorDetail{{Field: "page_token", Message: "must be the next_page_token of a previous page"}},
+			})
+		}
+		// dependencies are ordered by ID, the page starts after the last ID of the previous one
+		for start < len(dependencies) && dependencies[start].ProjectKey.ID <= string(after) {
+			start++
+		}
+	}
+	end := min(start+pageSize, len(dependencies))
+
+	response := &depsv1.ListDependenciesResponse{TotalSize: int32(len(dependencies))}
+	for _, dependency := range dependencies[start:end] {
+		response.Dependencies = append(response.Dependencies, dependencyToProto(dependency))
+	}
+	if end < len(dependencies) {
+		response.NextPageToken = base64.RawURLEncoding.EncodeToString([]byte(dependencies[end-1].ProjectKey.ID))
+	}
+	return response, nil
+}
+
+func (s *dependencyService) AddDependency(ctx context.Context, req *depsv1.AddDependencyRequest) (*depsv1.Dependency, error) {
+	stored, err := s.api.addProject(dependencyFromProto(req.GetDependency()))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return dependencyToProto(stored), nil
+}
+
+func (s *dependencyService) UpdateDependency(ctx context.Context, req *depsv1.UpdateDependencyRequest) (*depsv1.Dependency, error) {
+	stored, err := s.api.updateProject(dependencyFromProto(req.GetDependency()), "")
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return dependencyToProto(stored), nil
+}
+
+func (s *dependencyService) DeleteDependency(ctx context.Context, req *depsv1.DeleteDependencyRequest) (*depsv1.DeleteDependencyResponse, error) {
+	id, err := s.api.resolveID(req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := s.api.db.DeleteDependencyWithDetails(id); err != nil {
+		return nil, grpcError(err)
+	}
+	return &depsv1.DeleteDependencyResponse{}, nil
+}
+
+// RefreshDependencies streams the progress of the refresh and its result. A client which goes away
+// doesn't cancel the refresh, like a REST request wouldn't.
+func (s *dependencyService) RefreshDependencies(req *depsv1.RefreshDependenciesRequest, stream depsv1.DependencyService_RefreshDependenciesServer) error {
+	classes, err := versions.ParseClasses(req.GetClasses())
+	if err != nil {
+		return grpcError(&invalidFieldsError{
+			fmt.Errorf("%w: %v", database.ErrInvalidInput, err),
+			[]ErrorDetail{{Field: "classes", Message: err.Error()}},
+		})
+	}
+
+	var sendErr error
+	updater := s.api.updater.WithProgress(func(stage, name string, done, total int) {
+		if sendErr != nil {
+			return
+		}
+		sendErr = stream.Send(&depsv1.RefreshDependenciesResponse{Event: &depsv1.RefreshDependenciesResponse_Progress{
+			Progress: &depsv1.Progress{Stage: stageToProto(stage), Name: name, Done: int32(done), Total: int32(total)},
+		}})
+	})
+	plan, updatedDependencies, err := updater.Refresh(req.GetDryRun(), classes)
+	if err != nil {
+		return grpcError(err)
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	result := &depsv1.RefreshResult{Updated: updatedDependencies}
+	for _, change := range plan {
+		result.Planned = append(result.Planned, plannedChangeToProto(change))
+	}
+	return stream.Send(&depsv1.RefreshDependenciesResponse{Event: &depsv1.RefreshDependenciesResponse_Result{Result: result}})
+}
+
+// grpcError maps errors of the database and auth to gRPC statuses like statusOf does to HTTP ones. Invalid fields
+// of an invalidFieldsError are listed in a BadRequest detail.
+func grpcError(err error) error {
+	var code codes.Code
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, database.ErrConflict):
+		code = codes.AlreadyExists
+	case errors.Is(err, database.ErrInvalidInput):
+		code = codes.InvalidArgument
+	case errors.Is(err, database.ErrPreconditionFailed):
+		code = codes.FailedPrecondition
+	case errors.Is(err, auth.ErrUnauthenticated):
+		code = codes.Unauthenticated
+	case errors.Is(err, auth.ErrForbidden):
+		code = codes.PermissionDenied
+	default:
+		log.Printf("request failed due to an error: %v", err)
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	st := status.New(code, err.Error())
+	var fieldsErr *invalidFieldsError
+	if errors.As(err, &fieldsErr) {
+		badRequest := &errdetails.BadRequest{}
+		for _, detail := range fieldsErr.details {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       detail.Field,
+				Description: detail.Message,
+			})
+		}
+		if withDetails, err := st.WithDetails(badRequest); err == nil {
+			st = withDetails
+		}
+	}
+	return st.Err()
+}
+
+func stageToProto(stage string) depsv1.Progress_Stage {
+	switch stage {
+	case dependenciesupdater.StagePlan:
+		return depsv1.Progress_STAGE_PLAN
+	case dependenciesupdater.StageApply:
+		return depsv1.Progress_STAGE_APPLY
+	default:
+		return depsv1.Progress_STAGE_UNSPECIFIED
+	}
+}
+
+func dependencyToProto(d *dependenciesloader.DependencyDetails) *depsv1.Dependency {
+	dependency := &depsv1.Dependency{
+		ProjectKey:      &depsv1.ProjectKey{Id: d.ProjectKey.ID},
+		Purl:            d.Purl,
+		OpenIssuesCount: int32(d.OpenIssuesCount),
+		StarsCount:      int32(d.StarsCount),
+		ForksCount:      int32(d.ForksCount),
+		License:         d.License,
+		Description:     d.Description,
+		Homepage:        d.Homepage,
+		Scorecard: &depsv1.Scorecard{
+			Date:         d.Scorecard.Date,
+			Repository:   &depsv1.Repository{Name: d.Scorecard.Repository.Name, Commit: d.Scorecard.Repository.Commit},
+			Scorecard:    &depsv1.ScorecardInfo{Version: d.Scorecard.Scorecard.Version, Commit: d.Scorecard.Scorecard.Commit},
+			OverallScore: d.Scorecard.OverallScore,
+			Metadata:     d.Scorecard.Metadata,
+		},
+	}
+	for _, check := range d.Scorecard.Checks {
+		dependency.Scorecard.Checks = append(dependency.Scorecard.Checks, &depsv1.Check{
+			Name:          check.Name,
+			Documentation: &depsv1.Documentation{ShortDescription: check.Documentation.ShortDescription, Url: check.Documentation.URL},
+			Score:         int32(check.Score),
+			Reason:        check.Reason,
+			Details:       check.Details,
+		})
+	}
+	for _, override := range d.Overrides {
+		dependency.Overrides = append(dependency.Overrides, &depsv1.FieldOverride{
+			Field:     override.Field,
+			Value:     string(override.Value),
+			Upstream:  string(override.Upstream),
+			Reason:    override.Reason,
+			Author:    override.Author,
+			CreatedAt: override.CreatedAt,
+		})
+	}
+	return dependency
+}
+
+// dependencyFromProto returns the details of a dependency sent to the gRPC API, overrides are output
+// only and ignored.
+func dependencyFromProto(d *depsv1.Dependency) dependenciesloader.DependencyDetails {
+	scorecard := d.GetScorecard()
+	details := dependenciesloader.DependencyDetails{
+		ProjectKey:      dependenciesloader.ProjectKey{ID: d.GetProjectKey().GetId()},
+		Purl:            d.GetPurl(),
+		OpenIssuesCount: int(d.GetOpenIssuesCount()),
+		StarsCount:      int(d.GetStarsCount()),
+		ForksCount:      int(d.GetForksCount()),
+		License:         d.GetLicense(),
+		Description:     d.GetDescription(),
+		Homepage:        d.GetHomepage(),
+		Scorecard: dependenciesloader.Scorecard{
+			Date:         scorecard.GetDate(),
+			Repository:   dependenciesloader.Repository{Name: scorecard.GetRepository().GetName(), Commit: scorecard.GetRepository().GetCommit()},
+			Scorecard:    dependenciesloader.ScorecardInfo{Version: scorecard.GetScorecard().GetVersion(), Commit: scorecard.GetScorecard().GetCommit()},
+			Checks:       []dependenciesloader.Check{},
+			OverallScore: scorecard.GetOverallScore(),
+			Metadata:     scorecard.GetMetadata(),
+		},
+	}
+	for _, check := range scorecard.GetChecks() {
+		details.Scorecard.Checks = append(details.Scorecard.Checks, dependenciesloader.Check{
+			Name: check.GetName(),
+			Documentation: dependenciesloader.Documentation{
+				ShortDescription: check.GetDocumentation().GetShortDescription(),
+				URL:              check.GetDocumentation().GetUrl(),
+			},
+			Score:   int(check.GetScore()),
+			Reason:  check.GetReason(),
+			Details: check.GetDetails(),
+		})
+	}
+	return details
+}
+
+func plannedChangeToProto(change dependenciesupdater.PlannedChange) *depsv1.PlannedChange {
+	planned := &depsv1.PlannedChange{
+		Name:                change.Name,
+		Purl:                change.Purl,
+		Reasons:             change.Reasons,
+		CurrentVersion:      change.CurrentVersion,
+		NewVersion:          change.NewVersion,
+		Class:               change.Class,
+		CurrentOverallScore: change.CurrentOverallScore,
+		NewOverallScore:     change.NewOverallScore,
+		CurrentLicense:      change.CurrentLicense,
+		NewLicense:          change.NewLicense,
+	}
+	for _, check := range change.Checks {
+		planned.Checks = append(planned.Checks, &depsv1.CheckChange{
+			Name:         check.Name,
+			Change:       check.Change,
+			CurrentScore: int32(check.CurrentScore),
+			NewScore:     int32(check.NewScore),
+		})
+	}
+	for _, alert := range change.Alerts {
+		planned.Alerts = append(planned.Alerts, &depsv1.Alert{
+			Rule:          alert.Rule,
+			Check:         alert.Check,
+			PreviousScore: alert.PreviousScore,
+			Score:         alert.Score,
+			Message:       alert.Message,
+		})
+	}
+	return planned
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/api/depsv1"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	dependenciesupdater "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_updater"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGRPC(t *testing.T) {
+	db, err := database.NewSQLiteDB(path.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	defer db.CloseDbConnection()
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+	loader := dependenciesloader.NewDependenciesLoader("")
+	loader.SetSource(dependenciesloader.StaticSource{})
+	a := &Api{db: db, updater: dependenciesupdater.NewDependenciesUpdater(loader, db, 0, nil, nil)}
+
+	conn := dialGRPC(t, a)
+	client := depsv1.NewDependencyServiceClient(conn)
+	ctx := context.Background()
+
+	health, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: "deps.v1.DependencyService"})
+	if err != nil || health.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("want service serving, got %v, %v", health, err)
+	}
+
+	for _, purl := range []string{"pkg:golang/github.com/cli/cli", "pkg:golang/github.com/briandowns/spinner", "pkg:golang/github.com/mattn/go-isatty"} {
+		dependency := &depsv1.Dependency{
+			Purl:    purl,
+			License: "MIT",
+			Scorecard: &depsv1.Scorecard{
+				Date:         "2024-01-01T00:00:00Z",
+				OverallScore: 7.1,
+				Checks:       []*depsv1.Check{{Name: "Maintained", Score: 10, Documentation: &depsv1.Documentation{ShortDescription: "d", Url: "https://example.com"}}},
+			},
+		}
+		if _, err := client.AddDependency(ctx, &depsv1.AddDependencyRequest{Dependency: dependency}); err != nil {
+			t.Fatal("failed to add dependency:", err)
+		}
+	}
+
+	_, err = client.AddDependency(ctx, &depsv1.AddDependencyRequest{Dependency: &depsv1.Dependency{
+		ProjectKey: &depsv1.ProjectKey{Id: "github.com/cli/cli"},
+		Scorecard:  &depsv1.Scorecard{OverallScore: 11},
+	}})
+	expectCode(t, err, codes.InvalidArgument)
+	var fields []string
+	for _, detail := range status.Convert(err).Details() {
+		for _, violation := range detail.(*errdetails.BadRequest).FieldViolations {
+			fields = append(fields, violation.Field)
+		}
+	}
+	if len(fields) == 0 {
+		t.Fatalf("want invalid fields in the details of %v", err)
+	}
+
+	dependency, err := client.GetDependency(ctx, &depsv1.GetDependencyRequest{Id: "pkg:golang/github.com/cli/cli"})
+	if err != nil {
+		t.Fatal("failed to get dependency:", err)
+	}
+	if dependency.ProjectKey.Id != "github.com/cli/cli" || dependency.Scorecard.Checks[0].Name != "Maintained" {
+		t.Fatalf("unexpected dependency: %v", dependency)
+	}
+
+	dependency.License = "Apache-2.0"
+	dependency.Purl = ""
+	if updated, err := client.UpdateDependency(ctx, &depsv1.UpdateDependencyRequest{Dependency: dependency}); err != nil || updated.License != "Apache-2.0" {
+		t.Fatalf("want license updated, got %v, %v", updated, err)
+	}
+
+	var ids []string
+	request := &depsv1.ListDependenciesRequest{Search: "github.com", PageSize: 2}
+	for {
+		response, err := client.ListDependencies(ctx, request)
+		if err != nil {
+			t.Fatal("failed to list dependencies:", err)
+		}
+		if response.TotalSize != 3 {
+			t.Fatalf("want total size 3, got %d", response.TotalSize)
+		}
+		for _, dependency := range response.Dependencies {
+			ids = append(ids, dependency.ProjectKey.Id)
+		}
+		if response.NextPageToken == "" {
+			break
+		}
+		request.PageToken = response.NextPageToken
+	}
+	want := []string{"github.com/briandowns/spinner", "github.com/cli/cli", "github.com/mattn/go-isatty"}
+	if diff := cmp.Diff(want, ids); diff != "" {
+		t.Fatalf("unexpected pages (-want +got):\n%s", diff)
+	}
+	response, err := client.ListDependencies(ctx, &depsv1.ListDependenciesRequest{License: "apache-2.0"})
+	if err != nil || len(response.Dependencies) != 1 {
+		t.Fatalf("want a single Apache-2.0 dependency, got %v, %v", response, err)
+	}
+
+	stream, err := client.RefreshDependencies(ctx, &depsv1.RefreshDependenciesRequest{DryRun: true})
+	if err != nil {
+		t.Fatal("failed to refresh dependencies:", err)
+	}
+	if events := receiveAll(t, stream); len(events) != 1 || events[0].GetResult() == nil {
+		t.Fatalf("want only the result of an empty refresh, got %v", events)
+	}
+	stream, err = client.RefreshDependencies(ctx, &depsv1.RefreshDependenciesRequest{Classes: []string{"huge"}})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	expectCode(t, err, codes.InvalidArgument)
+
+	if _, err := client.DeleteDependency(ctx, &depsv1.DeleteDependencyRequest{Id: "github.com/cli/cli"}); err != nil {
+		t.Fatal("failed to delete dependency:", err)
+	}
+	_, err = client.GetDependency(ctx, &depsv1.GetDependencyRequest{Id: "github.com/cli/cli"})
+	expectCode(t, err, codes.NotFound)
+}
+
+func TestGRPCRefreshProgress(t *testing.T) {
+	db, err := database.NewSQLiteDB(path.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	defer db.CloseDbConnection()
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	// deps.dev is stubbed with the project of the only, new dependency of the graph
+	const isatty = "github.com/mattn/go-isatty"
+	depsDev := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/"+isatty {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(dependenciesloader.DependencyDetails{
+			ProjectKey: dependenciesloader.ProjectKey{ID: isatty},
+			License:    "MIT",
+			Scorecard:  dependenciesloader.Scorecard{Date: "2024-01-01T00:00:00Z", OverallScore: 6.1},
+		})
+	}))
+	defer depsDev.Close()
+	loader := dependenciesloader.NewDependenciesLoader("")
+	loader.SetApiUrl(depsDev.URL)
+	loader.SetSource(dependenciesloader.StaticSource{Nodes: []dependenciesloader.Node{{
+		VersionKey: dependenciesloader.VersionKey{System: "GO", Name: isatty, Version: "v0.0.14"},
+		Relation:   "DIRECT",
+	}}})
+	a := &Api{db: db, updater: dependenciesupdater.NewDependenciesUpdater(loader, db, 0, nil, nil)}
+	client := depsv1.NewDependencyServiceClient(dialGRPC(t, a))
+
+	stream, err := client.RefreshDependencies(context.Background(), &depsv1.RefreshDependenciesRequest{})
+	if err != nil {
+		t.Fatal("failed to refresh dependencies:", err)
+	}
+	events := receiveAll(t, stream)
+	if len(events) == 0 {
+		t.Fatal("want events of the refresh, got none")
+	}
+	progress := []string{}
+	for _, event := range events[:len(events)-1] {
+		p := event.GetProgress()
+		if p == nil {
+			t.Fatalf("want progress before the result, got %v", event)
+		}
+		progress = append(progress, fmt.Sprintf("%s %s %d/%d", p.Stage, p.Name, p.Done, p.Total))
+	}
+	want := []string{"STAGE_PLAN " + isatty + " 1/1", "STAGE_APPLY " + isatty + " 1/1"}
+	if diff := cmp.Diff(want, progress); diff != "" {
+		t.Fatalf("unexpected progress (-want +got):\n%s", diff)
+	}
+	result := events[len(events)-1].GetResult()
+	if result == nil || !cmp.Equal(result.Updated, []string{isatty}) {
+		t.Fatalf("want the result of the refresh last, got %v", events[len(events)-1])
+	}
+
+	dependency, err := client.GetDependency(context.Background(), &depsv1.GetDependencyRequest{Id: isatty})
+	if err != nil || dependency.License != "MIT" {
+		t.Fatalf("want the refreshed dependency stored, got %v, %v", dependency, err)
+	}
+}
+
+// receiveAll receives the events of a refresh until the stream ends.
+func receiveAll(t *testing.T, stream depsv1.DependencyService_RefreshDependenciesClient) []*depsv1.RefreshDependenciesResponse {
+	t.Helper()
+	events := []*depsv1.RefreshDependenciesResponse{}
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		if err != nil {
+			t.Fatal("failed to receive refresh events:", err)
+		}
+		events = append(events, event)
+	}
+}
+
+// dialGRPC serves the gRPC API of a over an in-memory listener and returns a connection to it.
+func dialGRPC(t *testing.T, a *Api, opts ...grpc.ServerOption) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := a.grpcServer(opts...)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal("failed to dial gRPC server:", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func expectCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("want code %s, got %v", code, err)
+	}
+}
@@ -0,0 +1,377 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	swaggerFiles "github.com/swaggo/files/v2"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/sbom"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/versions"
+)
+
+// operation documents a route in the OpenAPI document.
+type operation struct {
+	summary string
+	// query lists query parameters, described in parameters, required lists the ones which must be given.
+	query    []string
+	required []string
+	// request and response are values of the types of the JSON bodies, a content or a schema, nil without a body.
+	request  any
+	response any
+	// status of a successful response, 200 if zero.
+	status int
+}
+
+func (o operation) withQuery(names ...string) operation {
+	o.query = append(slices.Clone(o.query), names...)
+	return o
+}
+
+// withID adds the id query parameter used by deprecated routes instead of paths of projects.
+func (o operation) withID(required bool) operation {
+	o = o.withQuery("id")
+	if required {
+		o.required = append(slices.Clone(o.required), "id")
+	}
+	return o
+}
+
+// content maps media types of a body to values of its type or to its schema.
+type content map[string]any
+
+// schema is a JSON Schema put into the document as it is.
+type schema map[string]any
+
+// oneOf is a body of the type of any of its values.
+type oneOf []any
+
+// parameters describes path and query parameters by name.
+var parameters = map[string]schema{
+	"id":             {"description": "Project ID or package URL, in paths with slashes escaped as %2F", "schema": schema{"type": "string"}},
+	"field":          {"description": "Overridden field, a JSON Pointer such as /license, in paths the leading slash may be left out", "schema": schema{"type": "string"}},
+	"alertId":        {"description": "Alert ID", "schema": schema{"type": "integer"}},
+	"score":          {"description": "Projects with an overall score from score to score+0.99", "schema": schema{"type": "number"}},
+	"standard":       {"description": "SBOM standard", "schema": schema{"enum": []string{sbom.StandardCycloneDX, sbom.StandardSPDX}}},
+	"format":         {"description": "SBOM format, the first format of the standard by default", "schema": schema{"enum": []string{sbom.FormatJSON, sbom.FormatXML, sbom.FormatTagValue}}},
+	"dryRun":         {"description": "Only plan the updates", "schema": schema{"type": "boolean"}},
+	"class":          {"description": "Apply only updates of these classes, repeated or comma separated", "schema": schema{"type": "array", "items": schema{"enum": versions.Classes}}, "explode": true},
+	"unacknowledged": {"description": "Skip acknowledged alerts", "schema": schema{"type": "boolean"}},
+	"limit":          {"description": "Maximal number of deliveries, 100 by default", "schema": schema{"type": "integer", "minimum": 1}},
+}
+
+// sbomContent lists media types of every SBOM standard and format.
+func sbomContent() content {
+	c := content{}
+	for standard, formats := range sbom.Formats {
+		for _, format := range formats {
+			mediaType, _, _ := mime.ParseMediaType(sbom.ContentType(standard, format))
+			if format == sbom.FormatJSON {
+				c[mediaType] = schema{"type": "object"}
+			} else {
+				c[mediaType] = schema{"type": "string"}
+			}
+		}
+	}
+	return c
+}
+
+var pathVariable = regexp.MustCompile(`\{([^:}]+)(:[^}]*)?\}`)
+
+// openAPIPath strips patterns of variables from a route path and returns the names of the variables.
+func openAPIPath(routePath string) (string, []string) {
+	var names []string
+	for _, match := range pathVariable.FindAllStringSubmatch(routePath, -1) {
+		names = append(names, match[1])
+	}
+	return pathVariable.ReplaceAllString(routePath, "{$1}"), names
+}
+
+// openAPI generates the OpenAPI 3.1 document describing routes, schemas of bodies are generated
+// from their Go types.
+func openAPI(routes []route) map[string]any {
+	bodies := []any{ErrorResponse{}}
+	for _, route := range routes {
+		bodies = append(bodies, route.operation.request, route.operation.response)
+	}
+	g := newSchemaGenerator(bodies...)
+	paths := map[string]map[string]any{}
+	secured := false
+
+	for _, route := range routes {
+		routePath, variables := openAPIPath(route.path)
+		op := route.operation
+
+		params := []any{}
+		for _, name := range variables {
+			params = append(params, parameter(name, "path", true))
+		}
+		for _, name := range op.query {
+			params = append(params, parameter(name, "query", slices.Contains(op.required, name)))
+		}
+
+		status := op.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := map[string]any{"description": http.StatusText(status)}
+		if op.response != nil {
+			success["content"] = g.content(op.response)
+		}
+
+		doc := map[string]any{
+			"summary":    op.summary,
+			"parameters": params,
+			"responses": map[string]any{
+				strconv.Itoa(status): success,
+				"default": map[string]any{
+					"description": "Error",
+					"content":     g.content(ErrorResponse{}),
+				},
+			},
+		}
+		if op.request != nil {
+			doc["requestBody"] = map[string]any{"required": true, "content": g.content(op.request)}
+		}
+		if route.successor != nil {
+			doc["deprecated"] = true
+		}
+		if route.role != "" {
+			doc["description"] = "Needs an API key of the " + string(route.role) + " role or a more privileged one."
+			doc["security"] = []any{map[string]any{"bearerAuth": []string{}}, map[string]any{"apiKeyAuth": []string{}}}
+			secured = true
+		}
+
+		if paths[routePath] == nil {
+			paths[routePath] = map[string]any{}
+		}
+		paths[routePath][strings.ToLower(route.method)] = doc
+	}
+
+	components := map[string]any{"schemas": g.schemas}
+	if secured {
+		components["securitySchemes"] = map[string]any{
+			"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "description": "API key issued with the keys issue command"},
+			"apiKeyAuth": map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key", "description": "API key issued with the keys issue command"},
+		}
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "deps dev app",
+			"version":     "1.0.0",
+			"description": "Dependencies of a project with their deps.dev details, OpenSSF Scorecards and advisories. Routes outside of " + apiV1 + " are deprecated.",
+		},
+		"paths":      paths,
+		"components": components,
+	}
+}
+
+func parameter(name, in string, required bool) map[string]any {
+	p := map[string]any{"name": name, "in": in, "required": required}
+	for key, value := range parameters[name] {
+		p[key] = value
+	}
+	return p
+}
+
+// schemaGenerator generates JSON Schemas of Go types the way encoding/json encodes them. Named
+// structs become components, prefixed by their package if two of them have the same name.
+type schemaGenerator struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+// newSchemaGenerator names the components of every struct used by the bodies up front, so that
+// names don't depend on the order the bodies are generated in.
+func newSchemaGenerator(bodies ...any) *schemaGenerator {
+	g := &schemaGenerator{schemas: map[string]any{}, names: map[reflect.Type]string{}}
+
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if _, seen := g.names[t]; seen || t.Kind() != reflect.Struct {
+			return
+		}
+		if t.Name() != "" {
+			g.names[t] = strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); (field.IsExported() || field.Anonymous) && field.Tag.Get("json") != "-" {
+				walk(field.Type)
+			}
+		}
+	}
+	var walkBody func(body any)
+	walkBody = func(body any) {
+		switch b := body.(type) {
+		case nil, schema:
+		case content:
+			for _, value := range b {
+				walkBody(value)
+			}
+		case oneOf:
+			for _, value := range b {
+				walkBody(value)
+			}
+		default:
+			walk(reflect.TypeOf(b))
+		}
+	}
+	for _, body := range bodies {
+		walkBody(body)
+	}
+
+	count := map[string]int{}
+	for t := range g.names {
+		count[t.Name()]++
+	}
+	for t := range g.names {
+		if count[t.Name()] > 1 {
+			g.names[t] = packagePrefix(t) + g.names[t]
+		}
+	}
+	return g
+}
+
+func (g *schemaGenerator) content(body any) map[string]any {
+	mediaTypes, ok := body.(content)
+	if !ok {
+		mediaTypes = content{"application/json": body}
+	}
+	result := map[string]any{}
+	for mediaType, value := range mediaTypes {
+		result[mediaType] = map[string]any{"schema": g.schemaOf(value)}
+	}
+	return result
+}
+
+func (g *schemaGenerator) schemaOf(value any) schema {
+	switch v := value.(type) {
+	case schema:
+		return v
+	case oneOf:
+		schemas := []any{}
+		for _, alternative := range v {
+			schemas = append(schemas, g.schemaOf(alternative))
+		}
+		return schema{"oneOf": schemas}
+	}
+	return g.schemaOfType(reflect.TypeOf(value))
+}
+
+func (g *schemaGenerator) schemaOfType(t reflect.Type) schema {
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		// any JSON value
+		return schema{}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schema{"anyOf": []any{g.schemaOfType(t.Elem()), schema{"type": "null"}}}
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return schema{"type": "string", "contentEncoding": "base64"}
+		}
+		// nil slices are encoded as null
+		return schema{"type": []string{"array", "null"}, "items": g.schemaOfType(t.Elem())}
+	case reflect.Array:
+		return schema{"type": "array", "items": g.schemaOfType(t.Elem())}
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": g.schemaOfType(t.Elem())}
+	case reflect.Struct:
+		name, ok := g.names[t]
+		if !ok {
+			return g.object(t)
+		}
+		if _, generated := g.schemas[name]; !generated {
+			g.schemas[name] = nil // reserved, the struct may refer to itself
+			g.schemas[name] = g.object(t)
+		}
+		return schema{"$ref": "#/components/schemas/" + name}
+	default:
+		return schema{}
+	}
+}
+
+func packagePrefix(t reflect.Type) string {
+	var prefix strings.Builder
+	for _, part := range strings.Split(path.Base(t.PkgPath()), "_") {
+		if part != "" {
+			prefix.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return prefix.String()
+}
+
+func (g *schemaGenerator) object(t reflect.Type) schema {
+	properties := map[string]any{}
+	g.properties(t, properties)
+	return schema{"type": "object", "properties": properties}
+}
+
+// properties adds the fields of t encoded by encoding/json, fields of embedded structs are promoted.
+func (g *schemaGenerator) properties(t reflect.Type, properties map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.properties(field.Type, properties)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schemaOfType(field.Type)
+	}
+}
+
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// swaggerUI serves Swagger UI showing the OpenAPI document of the API.
+func swaggerUI() http.Handler {
+	files := http.FileServer(http.FS(swaggerFiles.FS))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "swagger-initializer.js" {
+			w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+			io.WriteString(w, swaggerInitializer)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+func (a *Api) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, openAPI(a.routes()))
+}
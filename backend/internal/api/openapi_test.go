@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+func TestOpenAPIDescribesEveryRoute(t *testing.T) {
+	a := &Api{}
+	spec := openAPIDocument(t, a)
+	paths := spec["paths"].(map[string]any)
+
+	described := 0
+	err := a.router().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Swagger UI is served for every method
+			if !strings.HasPrefix(template, "/docs") {
+				t.Errorf("route %s has no methods", template)
+			}
+			return nil
+		}
+		routePath, _ := openAPIPath(template)
+		for _, method := range methods {
+			item, _ := paths[routePath].(map[string]any)
+			if _, ok := item[strings.ToLower(method)]; !ok {
+				t.Errorf("%s %s is missing from the OpenAPI document", method, routePath)
+			}
+			described++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("failed to walk routes:", err)
+	}
+
+	operations := 0
+	for _, item := range paths {
+		operations += len(item.(map[string]any))
+	}
+	if operations != described {
+		t.Fatalf("the OpenAPI document has %d operations, the router %d routes", operations, described)
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	spec := openAPIDocument(t, &Api{})
+	if spec["openapi"] != "3.1.0" {
+		t.Fatalf("unexpected OpenAPI version: %v", spec["openapi"])
+	}
+
+	data, _ := json.Marshal(spec)
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	if err := compiler.AddResource("openapi.json", bytes.NewReader(data)); err != nil {
+		t.Fatal("failed to add OpenAPI document:", err)
+	}
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+	for _, name := range []string{"DependencyDetails", "Scorecard", "Check", "VersionKey"} {
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("schema %s is missing", name)
+		}
+	}
+	compiled := map[string]*jsonschema.Schema{}
+	for name := range schemas {
+		schema, err := compiler.Compile("openapi.json#/components/schemas/" + name)
+		if err != nil {
+			t.Fatalf("failed to compile schema %s: %v", name, err)
+		}
+		compiled[name] = schema
+	}
+
+	details := dependenciesloader.DependencyDetails{
+		ProjectKey: dependenciesloader.ProjectKey{ID: "github.com/cli/cli"},
+		License:    "MIT",
+		Scorecard: dependenciesloader.Scorecard{
+			OverallScore: 7.1,
+			Checks:       []dependenciesloader.Check{{Name: "Maintained", Score: 10}},
+		},
+		Overrides: []dependenciesloader.FieldOverride{{Field: "/license", Value: json.RawMessage(`"MIT"`), Upstream: json.RawMessage(`"NOASSERTION"`)}},
+	}
+	var document any
+	encoded, _ := json.Marshal(details)
+	json.Unmarshal(encoded, &document)
+	if err := compiled["DependencyDetails"].Validate(document); err != nil {
+		t.Fatal("encoded DependencyDetails don't match their schema:", err)
+	}
+	document.(map[string]any)["scorecard"].(map[string]any)["overallScore"] = "high"
+	if err := compiled["DependencyDetails"].Validate(document); err == nil {
+		t.Fatal("overallScore of a string should not match the schema")
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	handler := (&Api{}).handler()
+	for target, want := range map[string]string{
+		"/openapi.json":                `"openapi":"3.1.0"`,
+		"/docs/":                       `<div id="swagger-ui">`,
+		"/docs/swagger-initializer.js": `url: "/openapi.json"`,
+		"/docs/swagger-ui-bundle.js":   "SwaggerUIBundle",
+		"/docs/swagger-ui.css":         ".swagger-ui",
+	} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", target, nil))
+		if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), want) {
+			t.Errorf("GET %s: status %d, body does not contain %q", target, recorder.Code, want)
+		}
+	}
+}
+
+// openAPIDocument returns the OpenAPI document as it is served.
+func openAPIDocument(t *testing.T, a *Api) map[string]any {
+	data, err := json.Marshal(openAPI(a.routes()))
+	if err != nil {
+		t.Fatal("failed to encode OpenAPI document:", err)
+	}
+	var spec map[string]any
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal("failed to decode OpenAPI document:", err)
+	}
+	return spec
+}
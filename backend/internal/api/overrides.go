@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/auth"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/overrides"
+)
+
+// overrideRequest is the body of PUT /overrides, the field and the project are given as query parameters.
+type overrideRequest struct {
+	Value  json.RawMessage `json:"value"`
+	Reason string          `json:"reason"`
+	Author string          `json:"author"`
+}
+
+func (a *Api) getOverrides(w http.ResponseWriter, r *http.Request) {
+	id := ""
+	if idParam := param(r, "id"); idParam != "" {
+		var err error
+		if id, err = a.resolveID(idParam); err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+	}
+	results, err := a.db.GetOverrides(id)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// setOverride stores a manual correction of a field, it is returned instead of the upstream value
+// until it is deleted, also after the dependency is refreshed.
+func (a *Api) setOverride(w http.ResponseWriter, r *http.Request) {
+	id, err := a.resolveID(param(r, "id"))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	field, err := fieldOf(r)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	var request overrideRequest
+	if err := decodeStrict(r.Body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err, detailsOf(err)...)
+		return
+	}
+	override := overrides.Override{
+		ProjectKeyID: id,
+		Field:        field,
+		Value:        request.Value,
+		Reason:       strings.TrimSpace(request.Reason),
+		Author:       strings.TrimSpace(request.Author),
+	}
+	// overrides made with a key are by the holder of the key, the author of the body is only taken
+	// without authentication
+	if key := auth.KeyFrom(r.Context()); key != nil {
+		override.Author = key.Name
+	}
+	current, err := a.db.GetDependencyDetailsByID(id)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	if details := validateOverride(*current, override); len(details) > 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: override has %d invalid fields", database.ErrInvalidInput, len(details)), details...)
+		return
+	}
+
+	stored, err := a.db.SetOverride(override)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stored)
+}
+
+func (a *Api) deleteOverride(w http.ResponseWriter, r *http.Request) {
+	id, err := a.resolveID(param(r, "id"))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	field, err := fieldOf(r)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	if err := a.db.DeleteOverride(id, field); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// fieldOf returns the overridden field of the request. Fields are JSON Pointers, in paths of /api/v1
+// the leading slash may be left out, e.g. /api/v1/projects/{id}/overrides/license.
+func fieldOf(r *http.Request) (string, error) {
+	field := param(r, "field")
+	if field == "" {
+		return "", fmt.Errorf("%w: field is required", database.ErrInvalidInput)
+	}
+	if !strings.HasPrefix(field, "/") {
+		field = "/" + field
+	}
+	return field, nil
+}
+
+// validateOverride checks an override sent to the API against the current details of its dependency,
+// the overridden details have to be as valid as a PUT body.
+func validateOverride(current dependenciesloader.DependencyDetails, override overrides.Override) []ErrorDetail {
+	details := []ErrorDetail{}
+	if override.Reason == "" {
+		details = append(details, ErrorDetail{Field: "reason", Message: "is required"})
+	}
+	if override.Author == "" {
+		details = append(details, ErrorDetail{Field: "author", Message: "is required"})
+	}
+
+	err := overrides.Set(&current, override)
+	switch {
+	case errors.Is(err, overrides.ErrUnknownField), errors.Is(err, overrides.ErrCheckNotFound):
+		return append(details, ErrorDetail{Field: "field", Message: err.Error()})
+	case err != nil:
+		return append(details, ErrorDetail{Field: "value", Message: err.Error()})
+	}
+	return append(details, validateDependency(current)...)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/overrides"
+)
+
+func TestValidateOverride(t *testing.T) {
+	current := dependenciesloader.DependencyDetails{
+		ProjectKey: dependenciesloader.ProjectKey{ID: "github.com/cli/cli"},
+		License:    "MIT",
+		Homepage:   "https://cli.github.com",
+		Scorecard: dependenciesloader.Scorecard{
+			Date:         "2024-01-01T00:00:00Z",
+			OverallScore: 7.1,
+			Checks:       []dependenciesloader.Check{{Name: "Maintained", Score: 10}},
+		},
+	}
+
+	tests := []struct {
+		override overrides.Override
+		want     []ErrorDetail
+	}{
+		{
+			overrides.Override{Field: "/license", Value: json.RawMessage(`"Apache-2.0"`), Reason: "relicensed", Author: "jane"},
+			[]ErrorDetail{},
+		},
+		{
+			overrides.Override{Field: "/license", Value: json.RawMessage(`"Apache-2.0"`)},
+			[]ErrorDetail{{Field: "reason", Message: "is required"}, {Field: "author", Message: "is required"}},
+		},
+		{
+			overrides.Override{Field: "/homepage", Value: json.RawMessage(`"ftp://cli.github.com"`), Reason: "moved", Author: "jane"},
+			[]ErrorDetail{{Field: "homepage", Message: "must be an http or https URL"}},
+		},
+		{
+			overrides.Override{Field: "/scorecard/checks/Maintained/score", Value: json.RawMessage(`11`), Reason: "manual review", Author: "jane"},
+			[]ErrorDetail{{Field: "scorecard.checks[0].score", Message: "must be between -1 and 10, got 11"}},
+		},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, validateOverride(current, tt.override)); diff != "" {
+			t.Fatalf("unexpected details for override of %s: %s", tt.override.Field, diff)
+		}
+	}
+
+	for _, field := range []string{"/projectKey/id", "/scorecard/checks/Fuzzing/score"} {
+		details := validateOverride(current, overrides.Override{Field: field, Value: json.RawMessage(`1`), Reason: "r", Author: "a"})
+		if len(details) != 1 || details[0].Field != "field" {
+			t.Fatalf("want an invalid field detail for override of %s, got: %v", field, details)
+		}
+	}
+	details := validateOverride(current, overrides.Override{Field: "/starsCount", Value: json.RawMessage(`"many"`), Reason: "r", Author: "a"})
+	if len(details) != 1 || details[0].Field != "value" {
+		t.Fatalf("want an invalid value detail, got: %v", details)
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/jsonpatch"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/webhooks"
+)
+
+const maxPatchSize = 1 << 20
+
+// patchDependency applies a JSON Merge Patch or a JSON Patch, chosen by Content-Type, to the stored
+// details of a dependency. Patched details are validated like a PUT body and the update is conditional
+// on the If-Match header if it is given.
+func (a *Api) patchDependency(w http.ResponseWriter, r *http.Request) {
+	id, err := a.resolveID(param(r, "id"))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	var applyPatch func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case jsonpatch.MediaTypeMergePatch:
+		applyPatch = jsonpatch.Merge
+	case jsonpatch.MediaTypeJSONPatch:
+		applyPatch = jsonpatch.Apply
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported Content-Type %q, expected %s or %s",
+			r.Header.Get("Content-Type"), jsonpatch.MediaTypeMergePatch, jsonpatch.MediaTypeJSONPatch))
+		return
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		writeError(w, statusOf(err), fmt.Errorf("failed to read request body: %w", err))
+		return
+	}
+
+	// patches change upstream values, overridden fields keep returning their overrides
+	var previous, patched dependenciesloader.DependencyDetails
+	stored, err := a.db.PatchDependencyDetails(id, ifMatch(r), func(current dependenciesloader.DependencyDetails) (dependenciesloader.DependencyDetails, error) {
+		previous = current
+		result, err := patchDetails(current, patch, applyPatch)
+		patched = result
+		return result, err
+	})
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	events := []webhooks.Event{}
+	if patched.License != previous.License {
+		events = append(events, webhooks.Event{
+			Type:            webhooks.EventLicenseChanged,
+			Dependency:      stored.ProjectKey.ID,
+			Purl:            stored.Purl,
+			PreviousLicense: previous.License,
+			License:         patched.License,
+		})
+	}
+	if patched.Scorecard.OverallScore != previous.Scorecard.OverallScore {
+		events = append(events, webhooks.Event{
+			Type:                 webhooks.EventScoreBelowThreshold,
+			Dependency:           stored.ProjectKey.ID,
+			Purl:                 stored.Purl,
+			PreviousOverallScore: &previous.Scorecard.OverallScore,
+			OverallScore:         &patched.Scorecard.OverallScore,
+		})
+	}
+	a.notifier.Notify(events...)
+
+	w.Header().Set("ETag", database.ETag(*stored))
+	writeJSON(w, http.StatusOK, stored)
+}
+
+func patchDetails(
+	current dependenciesloader.DependencyDetails,
+	patch []byte,
+	applyPatch func(doc, patch []byte) ([]byte, error),
+) (dependenciesloader.DependencyDetails, error) {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return current, fmt.Errorf("failed to encode dependency: %w", err)
+	}
+	patchedDoc, err := applyPatch(doc, patch)
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return current, fmt.Errorf("%w: %v", database.ErrConflict, err)
+	}
+	if err != nil {
+		return current, fmt.Errorf("%w: %v", database.ErrInvalidInput, err)
+	}
+
+	var patched dependenciesloader.DependencyDetails
+	if err := decodeStrict(bytes.NewReader(patchedDoc), &patched); err != nil {
+		return current, &invalidFieldsError{fmt.Errorf("%w: patched dependency is invalid: %v", database.ErrInvalidInput, err), detailsOf(err)}
+	}
+
+	details := validateDependency(patched)
+	if patched.ProjectKey.ID != current.ProjectKey.ID {
+		details = append(details, ErrorDetail{Field: "projectKey.id", Message: "is read-only"})
+	}
+	if patched.Purl != current.Purl {
+		details = append(details, ErrorDetail{Field: "purl", Message: "is read-only"})
+	}
+	if len(details) > 0 {
+		return current, &invalidFieldsError{fmt.Errorf("%w: patched dependency has %d invalid fields", database.ErrInvalidInput, len(details)), details}
+	}
+	return patched, nil
+}
+
+// ifMatch returns the entity tags of the If-Match header, nil if any version matches.
+func ifMatch(r *http.Request) []string {
+	var etags []string
+	for _, header := range r.Header.Values("If-Match") {
+		for _, etag := range strings.Split(header, ",") {
+			etag = strings.TrimSpace(etag)
+			if etag == "*" {
+				return nil
+			}
+			if etag != "" {
+				etags = append(etags, etag)
+			}
+		}
+	}
+	return etags
+}
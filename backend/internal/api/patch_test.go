@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/jsonpatch"
+)
+
+func TestPatchDetails(t *testing.T) {
+	current := dependenciesloader.DependencyDetails{
+		ProjectKey: dependenciesloader.ProjectKey{ID: "github.com/cli/cli"},
+		Purl:       "pkg:golang/github.com/cli/cli@v1.14.0",
+		License:    "MIT",
+		Homepage:   "https://cli.github.com",
+		Scorecard: dependenciesloader.Scorecard{
+			OverallScore: 7.1,
+			Checks:       []dependenciesloader.Check{{Name: "Maintained", Score: 10}},
+		},
+	}
+
+	patched, err := patchDetails(current, []byte(`{"license": "Apache-2.0", "homepage": null}`), jsonpatch.Merge)
+	if err != nil {
+		t.Fatal("failed to apply merge patch:", err)
+	}
+	want := current
+	want.License = "Apache-2.0"
+	want.Homepage = ""
+	if diff := cmp.Diff(want, patched); diff != "" {
+		t.Fatalf("unexpected merge patch result: %s", diff)
+	}
+
+	patched, err = patchDetails(current, []byte(`[
+		{"op": "test", "path": "/license", "value": "MIT"},
+		{"op": "replace", "path": "/scorecard/checks/0/score", "value": 3}
+	]`), jsonpatch.Apply)
+	if err != nil {
+		t.Fatal("failed to apply JSON patch:", err)
+	}
+	if patched.Scorecard.Checks[0].Score != 3 {
+		t.Fatalf("check score was not patched: %+v", patched.Scorecard.Checks)
+	}
+
+	tests := []struct {
+		patch      string
+		applyPatch func(doc, patch []byte) ([]byte, error)
+		want       error
+		fields     []string
+	}{
+		{`[{"op": "test", "path": "/license", "value": "GPL-3.0"}]`, jsonpatch.Apply, database.ErrConflict, nil},
+		{`[{"op": "remove", "path": "/unknown"}]`, jsonpatch.Apply, database.ErrInvalidInput, nil},
+		{`{"stars": 1}`, jsonpatch.Merge, database.ErrInvalidInput, []string{"stars"}},
+		{`{"starsCount": -1, "projectKey": {"id": "other"}}`, jsonpatch.Merge, database.ErrInvalidInput, []string{"starsCount", "projectKey.id"}},
+		{`{"purl": "pkg:golang/github.com/cli/cli@v2.0.0"}`, jsonpatch.Merge, database.ErrInvalidInput, []string{"purl"}},
+	}
+	for _, test := range tests {
+		_, err := patchDetails(current, []byte(test.patch), test.applyPatch)
+		if !errors.Is(err, test.want) {
+			t.Fatalf("want %v for %s, got: %v", test.want, test.patch, err)
+		}
+		var fieldsErr *invalidFieldsError
+		var fields []string
+		if errors.As(err, &fieldsErr) {
+			for _, detail := range fieldsErr.details {
+				fields = append(fields, detail.Field)
+			}
+		}
+		if diff := cmp.Diff(test.fields, fields); diff != "" {
+			t.Fatalf("unexpected invalid fields for %s: %s", test.patch, diff)
+		}
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPatch, "/dependency/github.com/cli/cli", nil)
+	if etags := ifMatch(r); etags != nil {
+		t.Fatalf("want no ETags without If-Match, got: %v", etags)
+	}
+
+	r.Header.Add("If-Match", `"a", "b"`)
+	r.Header.Add("If-Match", `"c"`)
+	if diff := cmp.Diff([]string{`"a"`, `"b"`, `"c"`}, ifMatch(r)); diff != "" {
+		t.Fatalf("unexpected ETags: %s", diff)
+	}
+
+	r.Header.Set("If-Match", "*")
+	if etags := ifMatch(r); etags != nil {
+		t.Fatalf("want any version to match *, got: %v", etags)
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/purl"
+)
+
+// resolveID maps the id of a request to a project key ID. Besides plain IDs it accepts package URLs,
+// e.g. pkg:golang/github.com/briandowns/spinner@v1.23.0, which must match the system and, if given,
+// the version of the stored dependency. A package URL resolves to the project stored for the package,
+// e.g. pkg:golang/github.com/AlecAivazis/survey/v2 to github.com/alecaivazis/survey.
+func (a *Api) resolveID(id string) (string, error) {
+	if id == "" {
+		err := fmt.Errorf("%w: id is required", database.ErrInvalidInput)
+		return "", &invalidFieldsError{err, []ErrorDetail{{Field: "id", Message: "is required"}}}
+	}
+	if !strings.HasPrefix(id, "pkg:") {
+		return id, nil
+	}
+
+	packageURL, err := parsePurl(id)
+	if err != nil {
+		return "", err
+	}
+	versionKey := packageURL.VersionKey()
+
+	stored, err := a.db.GetVersionKey(versionKey.Name)
+	if errors.Is(err, database.ErrNotFound) {
+		// Dependencies added through the API have no stored version.
+		return versionKey.Name, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(stored.System, versionKey.System) {
+		return "", fmt.Errorf("%w: dependency %s is stored as %s package, not %s", database.ErrNotFound, versionKey.Name, stored.System, versionKey.System)
+	}
+	if versionKey.Version != "" && versionKey.Version != stored.Version {
+		return "", fmt.Errorf("%w: dependency %s is stored at version %s, not %s", database.ErrNotFound, versionKey.Name, stored.Version, versionKey.Version)
+	}
+	return a.projectKeyIDOf(versionKey.Name)
+}
+
+// projectKeyIDOf returns the project stored for a package. Packages whose project is unknown, e.g. ones
+// added through the API or without a deps.dev project, are stored under their name.
+func (a *Api) projectKeyIDOf(name string) (string, error) {
+	projectKeyID, err := a.db.GetProjectKeyIDOf(name)
+	if errors.Is(err, database.ErrNotFound) {
+		return name, nil
+	}
+	return projectKeyID, err
+}
+
+// resolvePurl fills in the project key of a dependency sent with a package URL. A project key
+// which differs from the project of the package URL is rejected.
+func (a *Api) resolvePurl(dependency *dependenciesloader.DependencyDetails) error {
+	if dependency.Purl == "" {
+		return nil
+	}
+
+	packageURL, err := parsePurl(dependency.Purl)
+	if err != nil {
+		return err
+	}
+	projectKeyID, err := a.projectKeyIDOf(packageURL.VersionKey().Name)
+	if err != nil {
+		return err
+	}
+	projectKey := dependenciesloader.ProjectKey{ID: projectKeyID}
+
+	if dependency.ProjectKey.ID == "" {
+		dependency.ProjectKey = projectKey
+	} else if dependency.ProjectKey.ID != projectKey.ID {
+		return fmt.Errorf("%w: projectKey %s does not match purl %s", database.ErrInvalidInput, dependency.ProjectKey.ID, dependency.Purl)
+	}
+	return nil
+}
+
+func parsePurl(s string) (purl.PackageURL, error) {
+	packageURL, err := purl.Parse(s)
+	if err != nil {
+		return packageURL, fmt.Errorf("%w: %v", database.ErrInvalidInput, err)
+	}
+	if !packageURL.Supported() {
+		return packageURL, fmt.Errorf("%w: purl type %s is not supported by deps.dev", database.ErrInvalidInput, packageURL.Type)
+	}
+	return packageURL, nil
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+func TestPurlOfLinkedProject(t *testing.T) {
+	db, err := database.NewSQLiteDB(path.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	defer db.CloseDbConnection()
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	// the module github.com/AlecAivazis/survey/v2 belongs to the project github.com/alecaivazis/survey
+	var dependencies dependenciesloader.Dependencies
+	readMock(t, "dependencies_mock.json", &dependencies)
+	var details struct {
+		Dependencies []dependenciesloader.DependencyDetails `json:"dependencies"`
+	}
+	readMock(t, "dependencies_details_mock.json", &details)
+	survey := dependencies.Nodes[1]
+	if err := db.LoadDependencies([]dependenciesloader.Node{survey}); err != nil {
+		t.Fatal("failed to load dependencies:", err)
+	}
+	if err := db.LoadDetailedDependencies(details.Dependencies[1:2]); err != nil {
+		t.Fatal("failed to load details:", err)
+	}
+	if err := db.LinkVersionKeys(map[string]string{survey.VersionKey.Name: details.Dependencies[1].ProjectKey.ID}); err != nil {
+		t.Fatal("failed to link version keys:", err)
+	}
+	advisories := []dependenciesloader.VersionAdvisories{{
+		VersionKey: survey.VersionKey,
+		Advisories: []dependenciesloader.Advisory{{AdvisoryKey: dependenciesloader.AdvisoryKey{ID: "GHSA-xxxx-xxxx-xxxx"}, CVSS3Score: 7.5}},
+	}}
+	if err := db.LoadAdvisories(advisories, database.SourceDepsDev); err != nil {
+		t.Fatal("failed to load advisories:", err)
+	}
+	handler := (&Api{db: db}).handler()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	const purl = "pkg:golang/github.com/AlecAivazis/survey/v2"
+	for _, target := range []string{"/api/v1/projects/" + strings.ReplaceAll(purl, "/", "%2F"), "/api/v1/projects/github.com%2Falecaivazis%2Fsurvey"} {
+		response := do("GET", target, "")
+		if response.Code != http.StatusOK {
+			t.Fatalf("want status 200 for %s, got %d: %s", target, response.Code, response.Body)
+		}
+		var dependency dependenciesloader.DependencyDetails
+		if err := json.Unmarshal(response.Body.Bytes(), &dependency); err != nil ||
+			dependency.ProjectKey.ID != "github.com/alecaivazis/survey" || dependency.Purl != purl+"@v2.2.14" {
+			t.Fatalf("unexpected project for %s: %s", target, response.Body)
+		}
+	}
+
+	response := do("GET", "/dependency/advisories?id="+purl+"@v2.2.14", "")
+	if response.Code != http.StatusOK || !strings.Contains(response.Body.String(), "GHSA-xxxx-xxxx-xxxx") {
+		t.Fatalf("want the advisory of the module, got %d: %s", response.Code, response.Body)
+	}
+	if response := do("GET", "/dependency/advisories?id="+purl+"@v2.2.13", ""); response.Code != http.StatusNotFound {
+		t.Fatalf("want status 404 for another version, got %d: %s", response.Code, response.Body)
+	}
+
+	response = do("PUT", "/api/v1/projects/github.com%2Falecaivazis%2Fsurvey", `{"purl": "`+purl+`", "license": "Apache-2.0", "scorecard": {"date": "2024-01-01T00:00:00Z"}}`)
+	if response.Code != http.StatusOK || !strings.Contains(response.Body.String(), `"license":"Apache-2.0"`) {
+		t.Fatalf("want the project updated through the purl of the module, got %d: %s", response.Code, response.Body)
+	}
+}
+
+// readMock decodes a file of the database test data into v.
+func readMock(t *testing.T, filename string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(path.Join("..", "database", "test_data", filename))
+	if err != nil {
+		t.Fatal("failed to read mock data:", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal("failed to parse mock data:", err)
+	}
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/auth"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	dependenciesupdater "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_updater"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/graphql"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/health"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/jsonpatch"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/licenses"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/overrides"
+)
+
+// apiV1 is the prefix of the versioned API. Project IDs are a single path segment with slashes
+// escaped, e.g. /api/v1/projects/github.com%2Fcli%2Fcli/scorecard, unescaped slashes are accepted as well.
+const apiV1 = "/api/v1"
+
+// deprecatedSince is sent in the Deprecation header (RFC 9745) of the routes outside of /api/v1.
+var deprecatedSince = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// route is an endpoint of the API, the OpenAPI document is generated from its operation.
+type route struct {
+	method    string
+	path      string
+	handler   http.HandlerFunc
+	operation operation
+	// successor links the route of /api/v1 replacing a deprecated route, nil for current routes.
+	successor func(r *http.Request) string
+	// role needed for the route, in the list of routes it defaults to reader for GET, admin for DELETE
+	// and editor for other methods, routes returns the role required by the authenticator.
+	role auth.Role
+}
+
+// routes lists every route of the API in the order they are matched, sub-resources of projects go
+// before the project itself since {id:.+} would match them as well.
+func (a *Api) routes() []route {
+	listProjects := operation{summary: "List projects", response: []dependenciesloader.DependencyDetails{}}
+	addProject := operation{summary: "Add a project", request: dependenciesloader.DependencyDetails{}, response: dependenciesloader.DependencyDetails{}, status: http.StatusCreated}
+	getProject := operation{summary: "Get a project", response: dependenciesloader.DependencyDetails{}}
+	replaceProject := operation{summary: "Replace the details of a project", request: dependenciesloader.DependencyDetails{}, response: dependenciesloader.DependencyDetails{}}
+	patchProject := operation{
+		summary: "Patch the details of a project",
+		request: content{
+			jsonpatch.MediaTypeMergePatch: schema{"type": "object"},
+			jsonpatch.MediaTypeJSONPatch:  []jsonpatch.Operation{},
+		},
+		response: dependenciesloader.DependencyDetails{},
+	}
+	deleteProject := operation{summary: "Delete a project", status: http.StatusNoContent}
+	getAdvisories := operation{summary: "List advisories of a project", response: []database.AdvisoryFinding{}}
+	listOverrides := operation{summary: "List overrides", response: []overrides.Override{}}
+	setOverride := operation{summary: "Override a field of a project", request: overrideRequest{}, response: overrides.Override{}}
+	deleteOverride := operation{summary: "Delete an override", status: http.StatusNoContent}
+	refresh := operation{
+		summary:  "Update dependencies, a dry run returns the planned changes",
+		query:    []string{"dryRun", "class"},
+		response: oneOf{[]string{}, []dependenciesupdater.PlannedChange{}},
+	}
+	listVulnerabilities := operation{summary: "List dependencies with advisories", response: []database.VulnerableDependency{}}
+	listAlerts := operation{summary: "List Scorecard alerts", query: []string{"unacknowledged"}, response: []database.Alert{}}
+	acknowledgeAlert := operation{summary: "Acknowledge an alert", response: database.Alert{}}
+	licenseReport := operation{summary: "Evaluate the license policy", response: licenses.Report{}}
+	healthReport := operation{summary: "Evaluate the health policy", response: health.Report{}}
+	exportSbom := operation{summary: "Export the dependency graph as an SBOM", query: []string{"format"}, response: sbomContent()}
+	importSbom := operation{summary: "Import the dependency graph from an SBOM", request: sbomContent(), response: []string{}}
+	listDeliveries := operation{summary: "List webhook deliveries", query: []string{"limit"}, response: []database.WebhookDelivery{}}
+	graphQL := operation{
+		summary: "Query projects, versions and the dependency graph with GraphQL",
+		request: graphql.Request{},
+		response: schema{"type": "object", "properties": schema{
+			"data":   schema{"type": []string{"object", "null"}},
+			"errors": schema{"type": "array", "items": schema{"type": "object", "properties": schema{"message": schema{"type": "string"}}}},
+		}},
+	}
+
+	routes := []route{
+		{method: "GET", path: apiV1 + "/projects", handler: a.getProjects, operation: listProjects.withQuery("score")},
+		{method: "POST", path: apiV1 + "/projects", handler: a.addDependency, operation: addProject},
+		{method: "GET", path: apiV1 + "/projects/{id:.+}/scorecard", handler: a.getScorecard, operation: operation{summary: "Get the Scorecard of a project", response: dependenciesloader.Scorecard{}}},
+		{method: "GET", path: apiV1 + "/projects/{id:.+}/checks", handler: a.getChecks, operation: operation{summary: "List Scorecard checks of a project", response: []dependenciesloader.Check{}}},
+		{method: "GET", path: apiV1 + "/projects/{id:.+}/advisories", handler: a.getDependencyAdvisories, operation: getAdvisories},
+		{method: "GET", path: apiV1 + "/projects/{id:.+}/overrides", handler: a.getOverrides, operation: listOverrides},
+		{method: "PUT", path: apiV1 + "/projects/{id:.+}/overrides/{field:.+}", handler: a.setOverride, operation: setOverride},
+		{method: "DELETE", path: apiV1 + "/projects/{id:.+}/overrides/{field:.+}", handler: a.deleteOverride, operation: deleteOverride},
+		{method: "GET", path: apiV1 + "/projects/{id:.+}", handler: a.getDependencyByID, operation: getProject},
+		{method: "PUT", path: apiV1 + "/projects/{id:.+}", handler: a.updateDependency, operation: replaceProject},
+		{method: "PATCH", path: apiV1 + "/projects/{id:.+}", handler: a.patchDependency, operation: patchProject},
+		{method: "DELETE", path: apiV1 + "/projects/{id:.+}", handler: a.deleteDependency, operation: deleteProject},
+		{method: "POST", path: apiV1 + "/refresh", handler: a.updateAllDependencies, operation: refresh, role: auth.RoleAdmin},
+		{method: "GET", path: apiV1 + "/overrides", handler: a.getOverrides, operation: listOverrides},
+		{method: "GET", path: apiV1 + "/vulnerabilities", handler: a.getVulnerableDependencies, operation: listVulnerabilities},
+		{method: "GET", path: apiV1 + "/alerts", handler: a.getAlerts, operation: listAlerts},
+		{method: "POST", path: apiV1 + "/alerts/{alertId}/acknowledge", handler: a.acknowledgeAlert, operation: acknowledgeAlert},
+		{method: "GET", path: apiV1 + "/policy/licenses", handler: a.getLicensePolicyReport, operation: licenseReport},
+		{method: "GET", path: apiV1 + "/policy/health", handler: a.getHealthPolicyReport, operation: healthReport},
+		{method: "GET", path: apiV1 + "/sbom/{standard}", handler: a.getSbom, operation: exportSbom},
+		{method: "POST", path: apiV1 + "/sbom", handler: a.importSbom, operation: importSbom, role: auth.RoleAdmin},
+		{method: "GET", path: apiV1 + "/webhooks/deliveries", handler: a.getWebhookDeliveries, operation: listDeliveries},
+		{method: "GET", path: "/openapi.json", handler: a.getOpenAPI, operation: operation{summary: "Get this OpenAPI document", response: schema{"type": "object"}}},
+		{method: "POST", path: "/graphql", handler: graphql.NewHandler(a.db, a.resolveID).ServeHTTP, operation: graphQL, role: auth.RoleReader},
+
+		{method: "GET", path: "/dependency", handler: a.getDependencyByID, operation: getProject.withID(true), successor: projectPath("")},
+		{method: "GET", path: "/dependency/score/{score}", handler: a.getDependencyByScore, operation: listProjects, successor: successor("/projects")},
+		{method: "GET", path: "/dependency/all", handler: a.getAllDependencies, operation: listProjects, successor: successor("/projects")},
+		{method: "GET", path: "/dependency/update", handler: a.updateAllDependencies, operation: refresh, successor: successor("/refresh"), role: auth.RoleAdmin},
+		{method: "GET", path: "/dependency/advisories", handler: a.getDependencyAdvisories, operation: getAdvisories.withID(true), successor: projectPath("/advisories")},
+		{method: "GET", path: "/dependency/vulnerable", handler: a.getVulnerableDependencies, operation: listVulnerabilities, successor: successor("/vulnerabilities")},
+		{method: "POST", path: "/dependency", handler: a.addDependency, operation: addProject, successor: successor("/projects")},
+		{method: "PUT", path: "/dependency", handler: a.updateDependency, operation: replaceProject, successor: projectPath("")},
+		{method: "DELETE", path: "/dependency", handler: a.deleteDependency, operation: deleteProject.withID(true), successor: projectPath("")},
+		{method: "PATCH", path: "/dependency/{id:.+}", handler: a.patchDependency, operation: patchProject, successor: projectPath("")},
+		{method: "GET", path: "/overrides", handler: a.getOverrides, operation: listOverrides.withID(false), successor: projectPath("/overrides")},
+		{method: "PUT", path: "/overrides", handler: a.setOverride, operation: setOverride.withID(true).withQuery("field"), successor: projectPath("/overrides")},
+		{method: "DELETE", path: "/overrides", handler: a.deleteOverride, operation: deleteOverride.withID(true).withQuery("field"), successor: projectPath("/overrides")},
+		{method: "GET", path: "/webhooks/deliveries", handler: a.getWebhookDeliveries, operation: listDeliveries, successor: successor("/webhooks/deliveries")},
+		{method: "GET", path: "/alerts", handler: a.getAlerts, operation: listAlerts, successor: successor("/alerts")},
+		{method: "GET", path: "/policy/licenses", handler: a.getLicensePolicyReport, operation: licenseReport, successor: successor("/policy/licenses")},
+		{method: "GET", path: "/policy/health", handler: a.getHealthPolicyReport, operation: healthReport, successor: successor("/policy/health")},
+		{method: "POST", path: "/alerts/{alertId}/acknowledge", handler: a.acknowledgeAlert, operation: acknowledgeAlert, successor: successor("/alerts")},
+		{method: "GET", path: "/sbom/{standard}", handler: a.getSbom, operation: exportSbom, successor: successor("/sbom")},
+		{method: "POST", path: "/sbom", handler: a.importSbom, operation: importSbom, successor: successor("/sbom"), role: auth.RoleAdmin},
+	}
+	for i := range routes {
+		routes[i].role = a.requiredRole(routes[i])
+	}
+	return routes
+}
+
+// requiredRole returns the role needed for a route, empty if it's open to requests without a key.
+// Refreshing and importing the graph rewrite dependencies, so they need an admin whatever the method.
+func (a *Api) requiredRole(r route) auth.Role {
+	if a.auth == nil {
+		return ""
+	}
+	role := r.role
+	if role == "" {
+		switch r.method {
+		case "GET":
+			role = auth.RoleReader
+		case "DELETE":
+			role = auth.RoleAdmin
+		default:
+			role = auth.RoleEditor
+		}
+	}
+	return a.auth.Required(role)
+}
+
+func (a *Api) handler() http.Handler {
+	return handlers.CORS(
+		handlers.AllowedOrigins([]string{"http://localhost:8080"}),
+		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE"}),
+		handlers.AllowedHeaders([]string{"Content-Type", "application/json", "If-Match", "Authorization", "X-API-Key"}),
+		handlers.ExposedHeaders([]string{"ETag", "Location", "Deprecation", "Link"}),
+	)(a.router())
+}
+
+func (a *Api) router() *mux.Router {
+	r := mux.NewRouter().UseEncodedPath()
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no route for %s", r.URL.Path))
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed for %s", r.Method, r.URL.Path))
+	})
+
+	for _, route := range a.routes() {
+		handler := route.handler
+		if route.successor != nil {
+			handler = deprecated(route.successor, handler)
+		}
+		if a.auth != nil {
+			handler = a.authorize(route.role, handler)
+		}
+		r.HandleFunc(route.path, handler).Methods(route.method)
+	}
+	// Swagger UI isn't a part of the API, so it's not listed in routes
+	r.Handle("/docs", http.RedirectHandler("/docs/", http.StatusMovedPermanently))
+	r.PathPrefix("/docs/").Handler(http.StripPrefix("/docs/", swaggerUI()))
+	return r
+}
+
+// deprecated marks responses of a legacy route as deprecated and links the route of /api/v1 replacing it.
+func deprecated(successor func(r *http.Request) string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", fmt.Sprintf("@%d", deprecatedSince.Unix()))
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor(r)))
+		h(w, r)
+	}
+}
+
+func successor(path string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return apiV1 + path
+	}
+}
+
+// projectPath links the resource of the project given by the id of the request, or all projects without it.
+func projectPath(suffix string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		id := param(r, "id")
+		if id == "" {
+			return apiV1 + "/projects"
+		}
+		return projectURL(id) + suffix
+	}
+}
+
+// projectURL is the path of a project in /api/v1, its ID escaped into a single segment.
+func projectURL(id string) string {
+	return apiV1 + "/projects/" + url.PathEscape(id)
+}
+
+// param returns a path variable of the route or, for legacy routes, a query parameter of the request.
+func param(r *http.Request, name string) string {
+	value, ok := mux.Vars(r)[name]
+	if !ok {
+		return r.URL.Query().Get(name)
+	}
+	// the router matches encoded paths, so escaped slashes stay in a single variable
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		return unescaped
+	}
+	return value
+}
+
+// getProjects lists all projects, or the ones with the overall score given by the score parameter.
+func (a *Api) getProjects(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("score") {
+		a.getDependencyByScore(w, r)
+		return
+	}
+	a.getAllDependencies(w, r)
+}
+
+func (a *Api) getScorecard(w http.ResponseWriter, r *http.Request) {
+	id, err := a.resolveID(param(r, "id"))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	dependency, err := a.db.GetDependencyDetailsByID(id)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dependency.Scorecard)
+}
+
+func (a *Api) getChecks(w http.ResponseWriter, r *http.Request) {
+	id, err := a.resolveID(param(r, "id"))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	dependency, err := a.db.GetDependencyDetailsByID(id)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	checks := dependency.Scorecard.Checks
+	if checks == nil {
+		checks = []dependenciesloader.Check{}
+	}
+	writeJSON(w, http.StatusOK, checks)
+}
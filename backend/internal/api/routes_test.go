@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+func TestRoutes(t *testing.T) {
+	db, err := database.NewSQLiteDB(path.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	defer db.CloseDbConnection()
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+	handler := (&Api{db: db}).handler()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+	expect := func(response *httptest.ResponseRecorder, status int, deprecated bool) {
+		t.Helper()
+		if response.Code != status {
+			t.Fatalf("want status %d, got %d: %s", status, response.Code, response.Body)
+		}
+		if got := response.Header().Get("Deprecation") != ""; got != deprecated {
+			t.Fatalf("want deprecated %v, got Deprecation header %q", deprecated, response.Header().Get("Deprecation"))
+		}
+	}
+
+	const project = "/api/v1/projects/github.com%2Fcli%2Fcli"
+	response := do("POST", "/api/v1/projects", `{
+		"projectKey": {"id": "github.com/cli/cli"},
+		"license": "MIT",
+		"scorecard": {
+			"date": "2024-01-01T00:00:00Z",
+			"overallScore": 7.1,
+			"checks": [{"name": "Maintained", "score": 10, "documentation": {"shortDescription": "d", "url": "https://example.com"}}]
+		}
+	}`)
+	expect(response, http.StatusCreated, false)
+	if location := response.Header().Get("Location"); location != project {
+		t.Fatalf("want Location %s, got %s", project, location)
+	}
+
+	for _, target := range []string{project, "/api/v1/projects/github.com/cli/cli", "/api/v1/projects/pkg:golang%2Fgithub.com%2Fcli%2Fcli"} {
+		response = do("GET", target, "")
+		expect(response, http.StatusOK, false)
+		var dependency dependenciesloader.DependencyDetails
+		if err := json.Unmarshal(response.Body.Bytes(), &dependency); err != nil || dependency.ProjectKey.ID != "github.com/cli/cli" {
+			t.Fatalf("unexpected project for %s: %s", target, response.Body)
+		}
+	}
+
+	response = do("GET", project+"/scorecard", "")
+	expect(response, http.StatusOK, false)
+	var scorecard dependenciesloader.Scorecard
+	if err := json.Unmarshal(response.Body.Bytes(), &scorecard); err != nil || scorecard.OverallScore != 7.1 {
+		t.Fatalf("unexpected scorecard: %s", response.Body)
+	}
+	response = do("GET", "/api/v1/projects/github.com/cli/cli/checks", "")
+	expect(response, http.StatusOK, false)
+	var checks []dependenciesloader.Check
+	if err := json.Unmarshal(response.Body.Bytes(), &checks); err != nil || len(checks) != 1 || checks[0].Name != "Maintained" {
+		t.Fatalf("unexpected checks: %s", response.Body)
+	}
+
+	expect(do("PUT", project, `{"projectKey": {"id": "github.com/cli/other"}, "scorecard": {"date": "2024-01-01T00:00:00Z"}}`), http.StatusBadRequest, false)
+	expect(do("PUT", project, `{"license": "Apache-2.0", "scorecard": {"date": "2024-01-01T00:00:00Z"}}`), http.StatusOK, false)
+	expect(do("PUT", project+"/overrides/license", `{"value": "MIT", "reason": "checked", "author": "jane"}`), http.StatusOK, false)
+	response = do("GET", "/api/v1/projects/github.com/cli/cli/overrides", "")
+	expect(response, http.StatusOK, false)
+	if !strings.Contains(response.Body.String(), `"field":"/license"`) {
+		t.Fatalf("override of /license was not stored: %s", response.Body)
+	}
+
+	response = do("POST", "/graphql", `{"query": "{ project(id: \"pkg:golang/github.com/cli/cli\") { license overriddenFields } }"}`)
+	expect(response, http.StatusOK, false)
+	if want := `{"data":{"project":{"license":"MIT","overriddenFields":["/license"]}}}`; strings.TrimSpace(response.Body.String()) != want {
+		t.Fatalf("want GraphQL response %s, got %s", want, response.Body)
+	}
+
+	response = do("GET", "/dependency?id=github.com/cli/cli", "")
+	expect(response, http.StatusOK, true)
+	if link := response.Header().Get("Link"); link != `<`+project+`>; rel="successor-version"` {
+		t.Fatalf("unexpected successor link: %s", link)
+	}
+	expect(do("GET", "/dependency/all", ""), http.StatusOK, true)
+	response = do("GET", "/dependency/advisories", "")
+	expect(response, http.StatusBadRequest, true)
+	if !strings.Contains(response.Body.String(), `"details":[{"field":"id","message":"is required"}]`) {
+		t.Fatalf("want the missing id in the details, got %s", response.Body)
+	}
+
+	expect(do("DELETE", project+"/overrides/license", ""), http.StatusNoContent, false)
+	expect(do("DELETE", project, ""), http.StatusNoContent, false)
+	expect(do("GET", project, ""), http.StatusNotFound, false)
+	expect(do("GET", "/api/v1/unknown", ""), http.StatusNotFound, false)
+}
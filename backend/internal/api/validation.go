@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+const (
+	minCheckScore = -1
+	maxScore      = 10
+)
+
+// dependencyRequest is the body of POST and PUT /dependency, a deps.dev project response.
+type dependencyRequest struct {
+	dependenciesloader.DependencyDetails
+	// OssFuzz is a part of deps.dev project responses which is not stored.
+	OssFuzz json.RawMessage `json:"ossFuzz,omitempty"`
+}
+
+// decodeStrict decodes a single JSON value and rejects fields unknown to v.
+func decodeStrict(body io.Reader, v any) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	if decoder.More() {
+		return errors.New("invalid request body: unexpected data after JSON value")
+	}
+	return nil
+}
+
+// detailsOf returns the field a decoding error of decodeStrict is about.
+func detailsOf(err error) []ErrorDetail {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []ErrorDetail{{Field: typeErr.Field, Message: fmt.Sprintf("must be %s, got %s", typeErr.Type, typeErr.Value)}}
+	}
+	// encoding/json has no type for unknown fields.
+	if _, field, ok := strings.Cut(err.Error(), "json: unknown field "); ok {
+		if unquoted, err := strconv.Unquote(field); err == nil {
+			field = unquoted
+		}
+		return []ErrorDetail{{Field: field, Message: "unknown field"}}
+	}
+	return nil
+}
+
+// validateDependency checks a dependency sent to the API, every invalid field is reported.
+func validateDependency(dependency dependenciesloader.DependencyDetails) []ErrorDetail {
+	details := []ErrorDetail{}
+	invalid := func(field, format string, args ...any) {
+		details = append(details, ErrorDetail{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch id := dependency.ProjectKey.ID; {
+	case id == "":
+		invalid("projectKey.id", "is required")
+	case strings.TrimSpace(id) != id || strings.ContainsAny(id, " \t\n?#"):
+		invalid("projectKey.id", "must not contain whitespace, '?' or '#'")
+	}
+
+	counts := []struct {
+		field string
+		value int
+	}{
+		{"openIssuesCount", dependency.OpenIssuesCount},
+		{"starsCount", dependency.StarsCount},
+		{"forksCount", dependency.ForksCount},
+	}
+	for _, count := range counts {
+		if count.value < 0 {
+			invalid(count.field, "must not be negative, got %d", count.value)
+		}
+	}
+
+	if dependency.Homepage != "" && !isHTTPURL(dependency.Homepage) {
+		invalid("homepage", "must be an http or https URL")
+	}
+
+	scorecard := dependency.Scorecard
+	if scorecard.Date != "" {
+		if _, err := time.Parse(time.RFC3339, scorecard.Date); err != nil {
+			invalid("scorecard.date", "must be an RFC 3339 timestamp")
+		}
+	}
+	if scorecard.OverallScore < 0 || scorecard.OverallScore > maxScore {
+		invalid("scorecard.overallScore", "must be between 0 and %d, got %v", maxScore, scorecard.OverallScore)
+	}
+
+	names := map[string]bool{}
+	for i, check := range scorecard.Checks {
+		field := fmt.Sprintf("scorecard.checks[%d]", i)
+		if check.Name == "" {
+			invalid(field+".name", "is required")
+		} else if names[check.Name] {
+			invalid(field+".name", "duplicate check %s", check.Name)
+		}
+		names[check.Name] = true
+		if check.Score < minCheckScore || check.Score > maxScore {
+			invalid(field+".score", "must be between %d and %d, got %d", minCheckScore, maxScore, check.Score)
+		}
+		if check.Documentation.URL != "" && !isHTTPURL(check.Documentation.URL) {
+			invalid(field+".documentation.url", "must be an http or https URL")
+		}
+	}
+
+	return details
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
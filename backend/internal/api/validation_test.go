@@ -0,0 +1,79 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+func TestDecodeStrict(t *testing.T) {
+	tests := []struct {
+		body    string
+		wantErr bool
+		want    []ErrorDetail
+	}{
+		{`{"projectKey": {"id": "github.com/cli/cli"}, "ossFuzz": {"lineCount": 1}}`, false, nil},
+		{`{"projectKey": {"id": "github.com/cli/cli"}, "stars": 1}`, true, []ErrorDetail{{Field: "stars", Message: "unknown field"}}},
+		{`{"scorecard": {"overallScore": "high"}}`, true, []ErrorDetail{{Field: "scorecard.overallScore", Message: "must be float64, got string"}}},
+		{`{} {}`, true, nil},
+	}
+
+	for _, test := range tests {
+		var request dependencyRequest
+		err := decodeStrict(strings.NewReader(test.body), &request)
+		if (err != nil) != test.wantErr {
+			t.Fatalf("unexpected error for %s: %v", test.body, err)
+		}
+		if err == nil {
+			continue
+		}
+		if diff := cmp.Diff(test.want, detailsOf(err)); diff != "" {
+			t.Fatalf("unexpected details for %s: %s", test.body, diff)
+		}
+	}
+}
+
+func TestValidateDependency(t *testing.T) {
+	valid := dependenciesloader.DependencyDetails{
+		ProjectKey: dependenciesloader.ProjectKey{ID: "github.com/cli/cli"},
+		StarsCount: 38228,
+		Homepage:   "https://cli.github.com",
+		Scorecard: dependenciesloader.Scorecard{
+			Date:         "2025-02-03T00:00:00Z",
+			OverallScore: 7.1,
+			Checks:       []dependenciesloader.Check{{Name: "Maintained", Score: 10}, {Name: "Fuzzing", Score: -1}},
+		},
+	}
+	if details := validateDependency(valid); len(details) != 0 {
+		t.Fatalf("valid dependency rejected: %+v", details)
+	}
+
+	invalid := dependenciesloader.DependencyDetails{
+		ForksCount: -1,
+		Homepage:   "cli.github.com",
+		Scorecard: dependenciesloader.Scorecard{
+			Date:         "yesterday",
+			OverallScore: 10.5,
+			Checks:       []dependenciesloader.Check{{Name: "Maintained", Score: 11}, {Name: "Maintained", Score: -2}},
+		},
+	}
+	var got []string
+	for _, detail := range validateDependency(invalid) {
+		got = append(got, detail.Field)
+	}
+	want := []string{
+		"projectKey.id",
+		"forksCount",
+		"homepage",
+		"scorecard.date",
+		"scorecard.overallScore",
+		"scorecard.checks[0].score",
+		"scorecard.checks[1].name",
+		"scorecard.checks[1].score",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected invalid fields: %s", diff)
+	}
+}
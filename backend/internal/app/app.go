@@ -35,11 +35,29 @@ func (app App) Run() {
 		log.Fatalf("failed to load version keys into db due to an error: %v \n exiting...", err)
 	}
 
-	detailedDependencies := app.dependenciesLoader.FetchDetailsForAllDependencies()
+	if err := app.db.LoadDependencyEdges(app.dependenciesLoader.Dependencies); err != nil {
+		log.Fatalf("failed to load dependency edges into db due to an error: %v \n exiting...", err)
+	}
+
+	detailedDependencies, projectKeyIDs := app.dependenciesLoader.FetchDetailsForAllDependencies()
 
 	if err := app.db.LoadDetailedDependencies(detailedDependencies); err != nil {
 		log.Fatalf("failed to load detailed dependencies into db due to an error: %v \n exiting...", err)
 	}
 
+	if err := app.db.LinkVersionKeys(projectKeyIDs); err != nil {
+		log.Fatalf("failed to link version keys to their projects due to an error: %v \n exiting...", err)
+	}
+
+	advisories := app.dependenciesLoader.FetchAdvisoriesForAllDependencies()
+
+	if err := app.db.LoadAdvisories(advisories, database.SourceDepsDev); err != nil {
+		log.Fatalf("failed to load advisories into db due to an error: %v \n exiting...", err)
+	}
+
+	if _, err := app.db.MatchOsvVulnerabilities(); err != nil {
+		log.Printf("failed to match imported OSV vulnerabilities due to an error: %v", err)
+	}
+
 	app.api.Run()
 }
@@ -0,0 +1,136 @@
+// Package auth authenticates requests by API keys and authorizes them by the role of the key.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+)
+
+// Role of a key, every role may do what the roles before it may.
+type Role string
+
+const (
+	// RoleReader may read everything.
+	RoleReader Role = "reader"
+	// RoleEditor may add, change and override projects and acknowledge alerts as well.
+	RoleEditor Role = "editor"
+	// RoleAdmin may delete and refresh dependencies as well.
+	RoleAdmin Role = "admin"
+)
+
+// Roles lists the roles from the least privileged.
+var Roles = []Role{RoleReader, RoleEditor, RoleAdmin}
+
+// keyPrefix marks keys of the app, e.g. for secret scanners.
+const keyPrefix = "dda_"
+
+var (
+	// ErrUnauthenticated is returned for requests without a valid key.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned for requests with a key whose role isn't allowed to do them.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// ParseRole parses a role case insensitively.
+func ParseRole(s string) (Role, error) {
+	role := Role(strings.ToLower(s))
+	if !slices.Contains(Roles, role) {
+		return "", fmt.Errorf("invalid role: %s, expected one of: reader, editor, admin", s)
+	}
+	return role, nil
+}
+
+// Allows reports whether the role may do what required may.
+func (r Role) Allows(required Role) bool {
+	return slices.Index(Roles, r) >= slices.Index(Roles, required)
+}
+
+// NewKey generates a key and returns it with the hash to store. The key is known only to its holder.
+func NewKey() (key string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	key = keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, Hash(key), nil
+}
+
+// Hash returns the hex encoded SHA-256 of a key. Keys are random, so unlike passwords they don't
+// need a slow hash.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticator checks keys of requests against the keys stored in the database.
+type Authenticator struct {
+	db           *database.SQLiteDB
+	protectReads bool
+}
+
+// NewAuthenticator returns an authenticator of keys stored in db. Without protectReads requests which
+// need only the reader role are allowed without a key.
+func NewAuthenticator(db *database.SQLiteDB, protectReads bool) *Authenticator {
+	return &Authenticator{db, protectReads}
+}
+
+// Required returns the role a request needing the given role has to be made with, empty if it may
+// be made without a key.
+func (a *Authenticator) Required(role Role) Role {
+	if role == RoleReader && !a.protectReads {
+		return ""
+	}
+	return role
+}
+
+// Authorize returns the stored key of a request made with key if its role allows the required one.
+// No key is needed if required is empty, a nil key is returned then unless one was given.
+func (a *Authenticator) Authorize(key string, required Role) (*database.APIKey, error) {
+	if key == "" {
+		if required == "" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: an API key is required", ErrUnauthenticated)
+	}
+	stored, err := a.db.GetAPIKeyByHash(Hash(key))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, fmt.Errorf("%w: invalid or revoked API key", ErrUnauthenticated)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if required != "" && !Role(stored.Role).Allows(required) {
+		return nil, fmt.Errorf("%w: role %s of key %s is not allowed, %s is required", ErrForbidden, stored.Role, stored.Name, required)
+	}
+	return &stored, nil
+}
+
+// KeyOf returns the key of an Authorization: Bearer or X-API-Key header value.
+func KeyOf(authorization, apiKey string) string {
+	if scheme, token, ok := strings.Cut(authorization, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(apiKey)
+}
+
+type contextKey struct{}
+
+// WithKey returns a context of a request authorized with key.
+func WithKey(ctx context.Context, key *database.APIKey) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// KeyFrom returns the key a request was authorized with, nil for requests made without a key.
+func KeyFrom(ctx context.Context) *database.APIKey {
+	key, _ := ctx.Value(contextKey{}).(*database.APIKey)
+	return key
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+)
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     Role
+		required Role
+		want     bool
+	}{
+		{RoleReader, RoleReader, true},
+		{RoleReader, RoleEditor, false},
+		{RoleEditor, RoleReader, true},
+		{RoleEditor, RoleAdmin, false},
+		{RoleAdmin, RoleAdmin, true},
+		{Role("owner"), RoleReader, false},
+	}
+	for _, test := range tests {
+		if got := test.role.Allows(test.required); got != test.want {
+			t.Errorf("%s allows %s: want %v, got %v", test.role, test.required, test.want, got)
+		}
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	if role, err := ParseRole("Admin"); err != nil || role != RoleAdmin {
+		t.Fatalf("want admin, got %q, %v", role, err)
+	}
+	if _, err := ParseRole("owner"); err == nil {
+		t.Fatal("unknown roles should be rejected")
+	}
+}
+
+func TestKeyOf(t *testing.T) {
+	tests := []struct {
+		authorization string
+		apiKey        string
+		want          string
+	}{
+		{"Bearer dda_a", "", "dda_a"},
+		{"bearer dda_a", "dda_b", "dda_a"},
+		{"Basic dXNlcg==", "dda_b", "dda_b"},
+		{"", " dda_b ", "dda_b"},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		if got := KeyOf(test.authorization, test.apiKey); got != test.want {
+			t.Errorf("KeyOf(%q, %q): want %q, got %q", test.authorization, test.apiKey, test.want, got)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	db, err := database.NewSQLiteDB(path.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	defer db.CloseDbConnection()
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	key, hash, err := NewKey()
+	if err != nil {
+		t.Fatal("failed to generate key:", err)
+	}
+	if !strings.HasPrefix(key, keyPrefix) || hash != Hash(key) || strings.Contains(hash, key) {
+		t.Fatalf("unexpected key %s with hash %s", key, hash)
+	}
+	stored, err := db.AddAPIKey("ci", string(RoleEditor), hash)
+	if err != nil {
+		t.Fatal("failed to add key:", err)
+	}
+
+	a := NewAuthenticator(db, false)
+	if a.Required(RoleReader) != "" || a.Required(RoleAdmin) != RoleAdmin {
+		t.Fatal("only reads should be open without protectReads")
+	}
+	if got, err := a.Authorize("", ""); got != nil || err != nil {
+		t.Fatalf("want open requests allowed without a key, got %v, %v", got, err)
+	}
+	if _, err := a.Authorize("", RoleEditor); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("want ErrUnauthenticated without a key, got %v", err)
+	}
+	if _, err := a.Authorize("dda_unknown", ""); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("want ErrUnauthenticated for an unknown key of an open request, got %v", err)
+	}
+	if got, err := a.Authorize(key, RoleEditor); err != nil || got.Name != "ci" {
+		t.Fatalf("want the editor key allowed, got %v, %v", got, err)
+	}
+	if _, err := a.Authorize(key, RoleAdmin); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("want ErrForbidden for an editor key, got %v", err)
+	}
+
+	if err := db.RevokeAPIKey(stored.ID); err != nil {
+		t.Fatal("failed to revoke key:", err)
+	}
+	if _, err := a.Authorize(key, RoleEditor); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("want ErrUnauthenticated for a revoked key, got %v", err)
+	}
+
+	if NewAuthenticator(db, true).Required(RoleReader) != RoleReader {
+		t.Fatal("reads should need a key with protectReads")
+	}
+}
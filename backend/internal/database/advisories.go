@@ -0,0 +1,223 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/purl"
+)
+
+// SourceDepsDev marks advisories reported by deps.dev for a version.
+const SourceDepsDev = "deps.dev"
+
+// AdvisoryFinding is an advisory affecting a stored version together with the source which reported it.
+type AdvisoryFinding struct {
+	dependenciesloader.Advisory
+	Source string `json:"source"`
+}
+
+type VulnerableDependency struct {
+	VersionKey dependenciesloader.VersionKey `json:"versionKey"`
+	// ProjectKeyID is the project the version belongs to, empty if details of none were fetched.
+	ProjectKeyID string            `json:"projectKeyId,omitempty"`
+	Purl         string            `json:"purl"`
+	Advisories   []AdvisoryFinding `json:"advisories"`
+}
+
+// LoadAdvisories stores advisories and replaces advisories reported by source for the given versions.
+func (s *SQLiteDB) LoadAdvisories(versionAdvisories []dependenciesloader.VersionAdvisories, source string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := loadAdvisories(tx, versionAdvisories, source); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func loadAdvisories(tx *sql.Tx, versionAdvisories []dependenciesloader.VersionAdvisories, source string) error {
+	for _, va := range versionAdvisories {
+		_, err := tx.Exec(`DELETE FROM "VersionAdvisory" WHERE versionKeyName = ? AND version = ? AND source = ?`,
+			va.VersionKey.Name, va.VersionKey.Version, source)
+		if err != nil {
+			return fmt.Errorf("failed to delete VersionAdvisory: %w", err)
+		}
+
+		for _, advisory := range va.Advisories {
+			aliases, err := json.Marshal(advisory.Aliases)
+			if err != nil {
+				return fmt.Errorf("failed to marshal aliases: %w", err)
+			}
+			_, err = tx.Exec(`
+				INSERT INTO "Advisory" (id, url, title, aliases, cvss3Score, cvss3Vector)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT(id) DO UPDATE SET url = excluded.url, title = excluded.title, aliases = excluded.aliases,
+					cvss3Score = excluded.cvss3Score, cvss3Vector = excluded.cvss3Vector`,
+				advisory.AdvisoryKey.ID,
+				advisory.URL,
+				advisory.Title,
+				string(aliases),
+				advisory.CVSS3Score,
+				advisory.CVSS3Vector,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert into Advisory: %w", err)
+			}
+
+			_, err = tx.Exec(`
+				INSERT INTO "VersionAdvisory" (versionKeyName, version, advisoryId, source)
+				VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+				va.VersionKey.Name,
+				va.VersionKey.Version,
+				advisory.AdvisoryKey.ID,
+				source,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert into VersionAdvisory: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// GetAdvisoriesByID returns advisories affecting the currently stored versions of the project. Versions
+// which aren't linked to a project are looked up by their name.
+func (s *SQLiteDB) GetAdvisoriesByID(projectKeyID string) ([]AdvisoryFinding, error) {
+	var exists int
+	err := s.db.QueryRow(`
+        SELECT (SELECT COUNT(*) FROM ProjectKey WHERE id = ?) + (SELECT COUNT(*) FROM VersionKeys vk WHERE `+ofProject+`)
+    `, projectKeyID, projectKeyID, projectKeyID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query VersionKeys: %w", err)
+	}
+	if exists == 0 {
+		return nil, fmt.Errorf("%w: dependency %s", ErrNotFound, projectKeyID)
+	}
+
+	vulnerable, err := s.getVulnerableDependencies(`WHERE `+ofProject, projectKeyID, projectKeyID)
+	if err != nil {
+		return nil, err
+	}
+	advisories := []AdvisoryFinding{}
+	for _, dependency := range vulnerable {
+		advisories = append(advisories, dependency.Advisories...)
+	}
+	return advisories, nil
+}
+
+// ofProject matches versions vk of the project given by both arguments.
+const ofProject = `(vk.projectKeyId = ? OR (vk.projectKeyId IS NULL AND vk.name = ?))`
+
+// GetAdvisoriesByNames returns advisories affecting the currently stored versions of the named
+// dependencies, keyed by name. Dependencies without advisories are left out.
+func (s *SQLiteDB) GetAdvisoriesByNames(names []string) (map[string][]AdvisoryFinding, error) {
+	advisories := map[string][]AdvisoryFinding{}
+	if len(names) == 0 {
+		return advisories, nil
+	}
+
+	vulnerable, err := s.getVulnerableDependencies(`WHERE vk.name IN (`+placeholders(len(names))+`)`, queryArgs(names)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, dependency := range vulnerable {
+		advisories[dependency.VersionKey.Name] = dependency.Advisories
+	}
+	return advisories, nil
+}
+
+// GetAdvisoriesByIDs returns advisories affecting the currently stored versions of the projects, keyed
+// by project ID. Versions which aren't linked to a project are looked up by their name, projects without
+// advisories are left out.
+func (s *SQLiteDB) GetAdvisoriesByIDs(projectKeyIDs []string) (map[string][]AdvisoryFinding, error) {
+	advisories := map[string][]AdvisoryFinding{}
+	if len(projectKeyIDs) == 0 {
+		return advisories, nil
+	}
+
+	in := `IN (` + placeholders(len(projectKeyIDs)) + `)`
+	args := append(queryArgs(projectKeyIDs), queryArgs(projectKeyIDs)...)
+	vulnerable, err := s.getVulnerableDependencies(`WHERE vk.projectKeyId `+in+` OR (vk.projectKeyId IS NULL AND vk.name `+in+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, dependency := range vulnerable {
+		projectKeyID := dependency.ProjectKeyID
+		if projectKeyID == "" {
+			projectKeyID = dependency.VersionKey.Name
+		}
+		advisories[projectKeyID] = append(advisories[projectKeyID], dependency.Advisories...)
+	}
+	return advisories, nil
+}
+
+// GetVulnerableDependencies returns every stored version affected by at least one advisory.
+func (s *SQLiteDB) GetVulnerableDependencies() ([]VulnerableDependency, error) {
+	return s.getVulnerableDependencies("")
+}
+
+func (s *SQLiteDB) getVulnerableDependencies(where string, args ...any) ([]VulnerableDependency, error) {
+	query := `
+        SELECT vk.name, vk.system, vk.version, vk.projectKeyId, a.id, a.url, a.title, a.aliases, a.cvss3Score, a.cvss3Vector, va.source
+        FROM VersionKeys vk
+        JOIN VersionAdvisory va ON va.versionKeyName = vk.name AND va.version = vk.version
+        JOIN Advisory a ON va.advisoryId = a.id
+        ` + where + `
+        ORDER BY vk.name, a.id, va.source
+    `
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query advisories: %w", err)
+	}
+	defer rows.Close()
+
+	dependencies := []VulnerableDependency{}
+	for rows.Next() {
+		var versionKey dependenciesloader.VersionKey
+		var projectKeyID sql.NullString
+		var finding AdvisoryFinding
+		var aliases string
+		err := rows.Scan(
+			&versionKey.Name,
+			&versionKey.System,
+			&versionKey.Version,
+			&projectKeyID,
+			&finding.AdvisoryKey.ID,
+			&finding.URL,
+			&finding.Title,
+			&aliases,
+			&finding.CVSS3Score,
+			&finding.CVSS3Vector,
+			&finding.Source,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Advisory: %w", err)
+		}
+		if err := json.Unmarshal([]byte(aliases), &finding.Aliases); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal aliases: %w", err)
+		}
+
+		if n := len(dependencies); n == 0 || dependencies[n-1].VersionKey != versionKey {
+			dependencies = append(dependencies, VulnerableDependency{VersionKey: versionKey, ProjectKeyID: projectKeyID.String, Purl: purl.FromVersionKey(versionKey)})
+		}
+		last := &dependencies[len(dependencies)-1]
+		last.Advisories = append(last.Advisories, finding)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over advisories: %w", err)
+	}
+
+	return dependencies, nil
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/purl"
+)
+
+type Alert struct {
+	ID             int     `json:"id"`
+	ProjectKeyID   string  `json:"projectKeyId"`
+	Purl           string  `json:"purl,omitempty"`
+	Rule           string  `json:"rule"`
+	Check          string  `json:"check"`
+	PreviousScore  float64 `json:"previousScore"`
+	Score          float64 `json:"score"`
+	Message        string  `json:"message"`
+	CreatedAt      string  `json:"createdAt"`
+	AcknowledgedAt string  `json:"acknowledgedAt"`
+}
+
+func (s *SQLiteDB) SaveAlerts(alerts []Alert) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveAlerts(tx, alerts); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func saveAlerts(tx *sql.Tx, alerts []Alert) error {
+	for _, alert := range alerts {
+		_, err := tx.Exec(`
+			INSERT INTO "Alert" (projectKeyId, rule, checkName, previousScore, score, message, createdAt, acknowledgedAt)
+			VALUES (?, ?, ?, ?, ?, ?, ?, '')`,
+			alert.ProjectKeyID,
+			alert.Rule,
+			alert.Check,
+			alert.PreviousScore,
+			alert.Score,
+			alert.Message,
+			now(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert into Alert: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetAlerts returns alerts, newest first. With unacknowledgedOnly set, acknowledged alerts are skipped.
+func (s *SQLiteDB) GetAlerts(unacknowledgedOnly bool) ([]Alert, error) {
+	return s.getAlerts(`WHERE (? = 0 OR a.acknowledgedAt = '')`, unacknowledgedOnly)
+}
+
+func (s *SQLiteDB) GetAlert(id int) (Alert, error) {
+	alerts, err := s.getAlerts(`WHERE a.id = ?`, id)
+	if err != nil {
+		return Alert{}, err
+	}
+	if len(alerts) == 0 {
+		return Alert{}, fmt.Errorf("%w: alert %d", ErrNotFound, id)
+	}
+	return alerts[0], nil
+}
+
+func (s *SQLiteDB) getAlerts(where string, args ...any) ([]Alert, error) {
+	query := `
+        SELECT a.id, a.projectKeyId, a.rule, a.checkName, a.previousScore, a.score, a.message,
+               a.createdAt, a.acknowledgedAt, vk.system, vk.name, vk.version
+        FROM Alert a
+        LEFT JOIN VersionKeys vk ON vk.name = COALESCE((` + versionOfProject("a.projectKeyId") + `), a.projectKeyId)
+        ` + where + `
+        ORDER BY a.id DESC
+    `
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
+	}
+
+	alerts := []Alert{}
+	defer rows.Close()
+
+	for rows.Next() {
+		var alert Alert
+		var system, name, version sql.NullString
+		err := rows.Scan(
+			&alert.ID,
+			&alert.ProjectKeyID,
+			&alert.Rule,
+			&alert.Check,
+			&alert.PreviousScore,
+			&alert.Score,
+			&alert.Message,
+			&alert.CreatedAt,
+			&alert.AcknowledgedAt,
+			&system,
+			&name,
+			&version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Alert: %w", err)
+		}
+		if system.Valid {
+			alert.Purl = purl.FromVersionKey(dependenciesloader.VersionKey{System: system.String, Name: name.String, Version: version.String})
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+func (s *SQLiteDB) AcknowledgeAlert(id int) error {
+	result, err := s.db.Exec(`
+		UPDATE "Alert"
+		SET acknowledgedAt = ?
+		WHERE id = ? AND acknowledgedAt = ''
+	`, now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update Alert: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows for Alert: %w", err)
+	}
+	if affected == 0 {
+		if _, err := s.GetAlert(id); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: alert %d is already acknowledged", ErrConflict, id)
+	}
+
+	return nil
+}
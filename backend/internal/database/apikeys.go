@@ -0,0 +1,99 @@
+package database
+
+import "fmt"
+
+// APIKey is a key of the API as stored, only the SHA-256 hash of the key itself is kept.
+type APIKey struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	KeyHash   string `json:"-"`
+	CreatedAt string `json:"createdAt"`
+	RevokedAt string `json:"revokedAt"`
+}
+
+// AddAPIKey stores a new key with the hash of the key and returns it.
+func (s *SQLiteDB) AddAPIKey(name, role, keyHash string) (APIKey, error) {
+	key := APIKey{Name: name, Role: role, KeyHash: keyHash, CreatedAt: now()}
+	result, err := s.db.Exec(`
+		INSERT INTO "ApiKey" (name, role, keyHash, createdAt, revokedAt)
+		VALUES (?, ?, ?, ?, '')`,
+		key.Name, key.Role, key.KeyHash, key.CreatedAt,
+	)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("failed to insert into ApiKey: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return APIKey{}, fmt.Errorf("failed to get id of ApiKey: %w", err)
+	}
+	key.ID = int(id)
+	return key, nil
+}
+
+// GetAPIKeyByHash returns the key with the hash unless it's revoked.
+func (s *SQLiteDB) GetAPIKeyByHash(keyHash string) (APIKey, error) {
+	keys, err := s.getAPIKeys(`WHERE keyHash = ? AND revokedAt = ''`, keyHash)
+	if err != nil {
+		return APIKey{}, err
+	}
+	if len(keys) == 0 {
+		return APIKey{}, fmt.Errorf("%w: api key", ErrNotFound)
+	}
+	return keys[0], nil
+}
+
+// GetAPIKeys returns every key, revoked ones included, ordered by id.
+func (s *SQLiteDB) GetAPIKeys() ([]APIKey, error) {
+	return s.getAPIKeys("")
+}
+
+func (s *SQLiteDB) getAPIKeys(where string, args ...any) ([]APIKey, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, role, keyHash, createdAt, revokedAt
+		FROM "ApiKey"
+		`+where+`
+		ORDER BY id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var key APIKey
+		if err := rows.Scan(&key.ID, &key.Name, &key.Role, &key.KeyHash, &key.CreatedAt, &key.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ApiKey: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revokes a key, revoked keys are kept to be listed.
+func (s *SQLiteDB) RevokeAPIKey(id int) error {
+	result, err := s.db.Exec(`
+		UPDATE "ApiKey"
+		SET revokedAt = ?
+		WHERE id = ? AND revokedAt = ''
+	`, now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update ApiKey: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows for ApiKey: %w", err)
+	}
+	if affected == 0 {
+		keys, err := s.getAPIKeys(`WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("%w: api key %d", ErrNotFound, id)
+		}
+		return fmt.Errorf("%w: api key %d is already revoked", ErrConflict, id)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"fmt"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+// DependencyChange is everything an update writes for a single dependency.
+type DependencyChange struct {
+	// Name of the stored version of the dependency.
+	Name string
+	// Node is stored if the dependency is new.
+	Node *dependenciesloader.Node
+	// Version replaces the stored version if not empty.
+	Version string
+	// Details replace the stored details of the project, which must exist unless the dependency is new.
+	// The version is linked to the project of the details.
+	Details *dependenciesloader.DependencyDetails
+	// Advisories reported by deps.dev for the new version.
+	Advisories *dependenciesloader.VersionAdvisories
+	// Alerts fired by the details.
+	Alerts []Alert
+}
+
+// ApplyDependencyChange writes the change in a single transaction, so a failed update leaves neither
+// the details nor the alerts of the dependency behind.
+func (s *SQLiteDB) ApplyDependencyChange(change DependencyChange) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if change.Advisories != nil {
+		if err := loadAdvisories(tx, []dependenciesloader.VersionAdvisories{*change.Advisories}, SourceDepsDev); err != nil {
+			return err
+		}
+	}
+	if change.Node != nil {
+		if err := loadDependencies(tx, []dependenciesloader.Node{*change.Node}); err != nil {
+			return err
+		}
+	}
+	if change.Details != nil {
+		projectKeyID := change.Details.ProjectKey.ID
+		if projectKeyID == "" {
+			return fmt.Errorf("%w: projectKey id is empty", ErrInvalidInput)
+		}
+		if change.Node == nil {
+			if _, err := scorecardIDOf(tx, projectKeyID); err != nil {
+				return notFound(err, "Scorecard of %s", projectKeyID)
+			}
+		}
+		// details of a new dependency may have been added through the API before it appeared in the graph
+		if err := upsertDependencyDetails(tx, *change.Details); err != nil {
+			return err
+		}
+		if err := linkVersionKey(tx, change.Name, projectKeyID); err != nil {
+			return err
+		}
+	}
+	if change.Version != "" {
+		if err := updateVersionKey(tx, change.Name, change.Version); err != nil {
+			return err
+		}
+	}
+	if err := saveAlerts(tx, change.Alerts); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -3,10 +3,15 @@ package database
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/overrides"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/purl"
 )
 
 type SQLiteDB struct {
@@ -56,7 +61,8 @@ func (s *SQLiteDB) CreateTables() error {
 			scorecardVersion TEXT,
 			scorecardCommit TEXT,
 			overallScore REAL,
-			metadata TEXT
+			metadata TEXT,
+			fetchedAt TEXT
 		);`,
 
 		`CREATE TABLE IF NOT EXISTS "DependencyDetails" (
@@ -76,7 +82,95 @@ func (s *SQLiteDB) CreateTables() error {
 		`CREATE TABLE IF NOT EXISTS "VersionKeys" (
 			name TEXT PRIMARY KEY,
 			system TEXT,
-			version TEXT
+			version TEXT,
+			relation TEXT,
+			projectKeyId TEXT
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS "DependencyEdge" (
+			fromName TEXT,
+			toName TEXT,
+			requirement TEXT,
+			PRIMARY KEY (fromName, toName),
+			FOREIGN KEY (fromName) REFERENCES "VersionKeys"(name),
+			FOREIGN KEY (toName) REFERENCES "VersionKeys"(name)
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS "WebhookDelivery" (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url TEXT,
+			event TEXT,
+			dependency TEXT,
+			payload TEXT,
+			attempt INTEGER,
+			statusCode INTEGER,
+			error TEXT,
+			createdAt TEXT
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS "Alert" (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			projectKeyId TEXT,
+			rule TEXT,
+			checkName TEXT,
+			previousScore REAL,
+			score REAL,
+			message TEXT,
+			createdAt TEXT,
+			acknowledgedAt TEXT
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS "Advisory" (
+			id TEXT PRIMARY KEY,
+			url TEXT,
+			title TEXT,
+			aliases TEXT,
+			cvss3Score REAL,
+			cvss3Vector TEXT
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS "VersionAdvisory" (
+			versionKeyName TEXT,
+			version TEXT,
+			advisoryId TEXT,
+			source TEXT,
+			PRIMARY KEY (versionKeyName, version, advisoryId, source),
+			FOREIGN KEY (versionKeyName) REFERENCES "VersionKeys"(name),
+			FOREIGN KEY (advisoryId) REFERENCES "Advisory"(id)
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS "OsvVulnerability" (
+			id TEXT PRIMARY KEY,
+			modified TEXT,
+			data TEXT
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS "Override" (
+			projectKeyId TEXT,
+			field TEXT,
+			value TEXT,
+			reason TEXT,
+			author TEXT,
+			createdAt TEXT,
+			PRIMARY KEY (projectKeyId, field),
+			FOREIGN KEY (projectKeyId) REFERENCES "ProjectKey"(id)
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS "ApiKey" (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT,
+			role TEXT,
+			keyHash TEXT UNIQUE,
+			createdAt TEXT,
+			revokedAt TEXT
+		);`,
+
+		`CREATE TABLE IF NOT EXISTS "OsvAffectedPackage" (
+			vulnerabilityId TEXT,
+			ecosystem TEXT,
+			name TEXT,
+			PRIMARY KEY (vulnerabilityId, ecosystem, name),
+			FOREIGN KEY (vulnerabilityId) REFERENCES "OsvVulnerability"(id)
 		);`,
 	}
 
@@ -87,9 +181,111 @@ func (s *SQLiteDB) CreateTables() error {
 		}
 	}
 
+	if err := s.addColumnIfNotExists("Scorecard", "fetchedAt", "TEXT"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfNotExists("VersionKeys", "relation", "TEXT"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfNotExists("VersionKeys", "projectKeyId", "TEXT"); err != nil {
+		return err
+	}
+	// older versions of the app joined versions and projects by name
+	stmt := `UPDATE "VersionKeys" SET projectKeyId = name
+		WHERE projectKeyId IS NULL AND name IN (SELECT id FROM "ProjectKey")`
+	if _, err := s.db.Exec(stmt); err != nil {
+		return fmt.Errorf("error executing statement: %s \n error: %w", stmt, err)
+	}
+	if err := s.addUniqueConstraints(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// addUniqueConstraints makes every project have a single DependencyDetails row, every Scorecard
+// a single Check of a name and equal Documentation a single row. Duplicates written by older
+// versions of the app are removed first, keeping the latest details of a project.
+func (s *SQLiteDB) addUniqueConstraints() error {
+	statements := []string{
+		`DELETE FROM "DependencyDetails"
+		 WHERE id NOT IN (SELECT MAX(id) FROM "DependencyDetails" GROUP BY projectKeyId)`,
+		`DELETE FROM "Check"
+		 WHERE scorecardId NOT IN (SELECT scorecardId FROM "DependencyDetails" WHERE scorecardId IS NOT NULL)`,
+		`DELETE FROM "Scorecard"
+		 WHERE id NOT IN (SELECT scorecardId FROM "DependencyDetails" WHERE scorecardId IS NOT NULL)`,
+		`DELETE FROM "Check"
+		 WHERE id NOT IN (SELECT MAX(id) FROM "Check" GROUP BY scorecardId, name)`,
+		`UPDATE "Check" SET documentationId = (
+		     SELECT MIN(d2.id) FROM "Documentation" d1
+		     JOIN "Documentation" d2 ON d2.shortDescription IS d1.shortDescription AND d2.url IS d1.url
+		     WHERE d1.id = "Check".documentationId
+		 )
+		 WHERE documentationId IS NOT NULL`,
+		`DELETE FROM "Documentation"
+		 WHERE id NOT IN (SELECT MIN(id) FROM "Documentation" GROUP BY shortDescription, url)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS "DependencyDetailsProjectKey" ON "DependencyDetails" (projectKeyId)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS "CheckScorecardName" ON "Check" (scorecardId, name)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS "DocumentationContent" ON "Documentation" (shortDescription, url)`,
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("error executing statement: %s \n error: %w", stmt, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
+// addColumnIfNotExists brings tables created by older versions of the app up to date,
+// since CREATE TABLE IF NOT EXISTS leaves an existing table untouched.
+func (s *SQLiteDB) addColumnIfNotExists(table, column, definition string) error {
+	rows, err := s.db.Query(fmt.Sprintf(`PRAGMA table_info("%s")`, table))
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid          int
+			name, ctype  string
+			notNull, pk  int
+			defaultValue sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("failed to scan columns of %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating over columns of %s: %w", table, err)
+	}
+	rows.Close()
+
+	stmt := fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN %s %s`, table, column, definition)
+	if _, err := s.db.Exec(stmt); err != nil {
+		return fmt.Errorf("error executing statement: %s \n error: %w", stmt, err)
+	}
+
+	return nil
+}
+
+// LoadDependencies stores versions of the nodes. Versions are stored by name, of several nodes of
+// a name only the first one is stored.
 func (s *SQLiteDB) LoadDependencies(nodes []dependenciesloader.Node) error {
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -97,16 +293,54 @@ func (s *SQLiteDB) LoadDependencies(nodes []dependenciesloader.Node) error {
 	}
 	defer tx.Rollback()
 
+	if err := loadDependencies(tx, nodes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func loadDependencies(tx *sql.Tx, nodes []dependenciesloader.Node) error {
+	loaded := map[string]bool{}
 	for _, node := range nodes {
-		_, err := tx.Exec(`INSERT INTO "VersionKeys" (name, system, version) VALUES (?, ?, ?) ON CONFLICT(name) DO NOTHING`,
+		if loaded[node.VersionKey.Name] {
+			continue
+		}
+		loaded[node.VersionKey.Name] = true
+		_, err := tx.Exec(`INSERT INTO "VersionKeys" (name, system, version, relation) VALUES (?, ?, ?, ?)
+			ON CONFLICT(name) DO UPDATE SET relation = excluded.relation`,
 			node.VersionKey.Name,
 			node.VersionKey.System,
 			node.VersionKey.Version,
+			node.Relation,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert into VersionKeys: %w", err)
 		}
 	}
+	return nil
+}
+
+// LinkVersionKeys stores the project every version belongs to, projectKeyIDs are keyed by the names
+// of the versions. deps.dev names projects after their repositories, e.g. the module
+// github.com/AlecAivazis/survey/v2 belongs to the project github.com/alecaivazis/survey, so versions
+// and details of projects are joined through the stored project instead of the name.
+func (s *SQLiteDB) LinkVersionKeys(projectKeyIDs map[string]string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for name, projectKeyID := range projectKeyIDs {
+		if err := linkVersionKey(tx, name, projectKeyID); err != nil {
+			return err
+		}
+	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
@@ -115,6 +349,124 @@ func (s *SQLiteDB) LoadDependencies(nodes []dependenciesloader.Node) error {
 	return nil
 }
 
+func linkVersionKey(tx *sql.Tx, name, projectKeyID string) error {
+	if _, err := tx.Exec(`UPDATE "VersionKeys" SET projectKeyId = ? WHERE name = ?`, projectKeyID, name); err != nil {
+		return fmt.Errorf("failed to link VersionKey %s to project %s: %w", name, projectKeyID, err)
+	}
+	return nil
+}
+
+// GetProjectKeyIDOf returns the project the stored version of a dependency belongs to, ErrNotFound
+// is wrapped if the version isn't stored or isn't linked to a project.
+func (s *SQLiteDB) GetProjectKeyIDOf(name string) (string, error) {
+	var projectKeyID sql.NullString
+	if err := s.db.QueryRow(`SELECT projectKeyId FROM VersionKeys WHERE name = ?`, name).Scan(&projectKeyID); err != nil {
+		return "", notFound(err, "VersionKey %s", name)
+	}
+	if !projectKeyID.Valid {
+		return "", fmt.Errorf("%w: project of VersionKey %s", ErrNotFound, name)
+	}
+	return projectKeyID.String, nil
+}
+
+// GetProjectKeyIDsByName returns the projects of all stored versions which are linked to one, keyed
+// by the names of the versions.
+func (s *SQLiteDB) GetProjectKeyIDsByName() (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT name, projectKeyId FROM VersionKeys WHERE projectKeyId IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query VersionKeys: %w", err)
+	}
+	defer rows.Close()
+
+	projectKeyIDs := map[string]string{}
+	for rows.Next() {
+		var name, projectKeyID string
+		if err := rows.Scan(&name, &projectKeyID); err != nil {
+			return nil, fmt.Errorf("failed to scan VersionKeys: %w", err)
+		}
+		projectKeyIDs[name] = projectKeyID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over VersionKeys: %w", err)
+	}
+	return projectKeyIDs, nil
+}
+
+// GetProjectKeyIDsByNames returns the projects of the named versions, keyed by the names of the versions.
+// Versions which aren't stored or aren't linked to a project are left out.
+func (s *SQLiteDB) GetProjectKeyIDsByNames(names []string) (map[string]string, error) {
+	projectKeyIDs := map[string]string{}
+	if len(names) == 0 {
+		return projectKeyIDs, nil
+	}
+
+	rows, err := s.db.Query(`SELECT name, projectKeyId FROM VersionKeys WHERE projectKeyId IS NOT NULL AND name IN (`+placeholders(len(names))+`)`, queryArgs(names)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query VersionKeys: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, projectKeyID string
+		if err := rows.Scan(&name, &projectKeyID); err != nil {
+			return nil, fmt.Errorf("failed to scan VersionKeys: %w", err)
+		}
+		projectKeyIDs[name] = projectKeyID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over VersionKeys: %w", err)
+	}
+	return projectKeyIDs, nil
+}
+
+// GetVersionNamesByProjectKeyIDs returns the name of the stored version of each project, keyed by project
+// ID. Versions which aren't linked to a project are matched by name, projects without a version are left out.
+func (s *SQLiteDB) GetVersionNamesByProjectKeyIDs(projectKeyIDs []string) (map[string]string, error) {
+	names := map[string]string{}
+	if len(projectKeyIDs) == 0 {
+		return names, nil
+	}
+
+	rows, err := s.db.Query(`
+        SELECT pk.id, COALESCE((`+versionOfProject("pk.id")+`), (SELECT name FROM VersionKeys WHERE projectKeyId IS NULL AND name = pk.id))
+        FROM ProjectKey pk
+        WHERE pk.id IN (`+placeholders(len(projectKeyIDs))+`)
+    `, queryArgs(projectKeyIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query VersionKeys: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var projectKeyID string
+		var name sql.NullString
+		if err := rows.Scan(&projectKeyID, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan VersionKeys: %w", err)
+		}
+		if name.Valid {
+			names[projectKeyID] = name.String
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over VersionKeys: %w", err)
+	}
+	return names, nil
+}
+
+// GetVersionKey returns the stored version of a dependency, ErrNotFound is wrapped if there is none.
+func (s *SQLiteDB) GetVersionKey(name string) (dependenciesloader.VersionKey, error) {
+	var versionKey dependenciesloader.VersionKey
+	err := s.db.QueryRow(`SELECT name, system, version FROM VersionKeys WHERE name = ?`, name).Scan(
+		&versionKey.Name,
+		&versionKey.System,
+		&versionKey.Version,
+	)
+	if err != nil {
+		return versionKey, notFound(err, "VersionKey %s", name)
+	}
+	return versionKey, nil
+}
+
 func (s *SQLiteDB) GetVersionKeys() ([]dependenciesloader.VersionKey, error) {
 	query := `SELECT name, system, version FROM VersionKeys`
 
@@ -142,6 +494,8 @@ func (s *SQLiteDB) GetVersionKeys() ([]dependenciesloader.VersionKey, error) {
 	return versionKeys, nil
 }
 
+// LoadDetailedDependencies stores details of the dependencies, replacing details stored before,
+// so loading the same details again leaves the database unchanged.
 func (s *SQLiteDB) LoadDetailedDependencies(dependenciesDetails []dependenciesloader.DependencyDetails) error {
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -150,70 +504,8 @@ func (s *SQLiteDB) LoadDetailedDependencies(dependenciesDetails []dependencieslo
 	defer tx.Rollback()
 
 	for _, details := range dependenciesDetails {
-		_, err := tx.Exec(`INSERT INTO "ProjectKey" (id) VALUES (?) ON CONFLICT(id) DO NOTHING`, details.ProjectKey.ID)
-		if err != nil {
-			return fmt.Errorf("failed to insert into ProjectKey: %w", err)
-		}
-
-		scorecardResult, err := tx.Exec(`
-			INSERT INTO "Scorecard" (date, repositoryName, repositoryCommit, scorecardVersion, scorecardCommit, overallScore, metadata) 
-			VALUES (?, ?, ?, ?, ?, ?, ?)`,
-			details.Scorecard.Date,
-			details.Scorecard.Repository.Name,
-			details.Scorecard.Repository.Commit,
-			details.Scorecard.Scorecard.Version,
-			details.Scorecard.Scorecard.Commit,
-			details.Scorecard.OverallScore,
-			fmt.Sprintf("%v", details.Scorecard.Metadata),
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert into Scorecard: %w", err)
-		}
-
-		scorecardId, err := scorecardResult.LastInsertId()
-		if err != nil {
-			return fmt.Errorf("failed to get last insert id for Scorecard: %w", err)
-		}
-
-		for _, check := range details.Scorecard.Checks {
-			docResult, err := tx.Exec(`INSERT INTO "Documentation" (shortDescription, url) VALUES (?, ?)`,
-				check.Documentation.ShortDescription, check.Documentation.URL)
-			if err != nil {
-				return fmt.Errorf("failed to insert into Documentation: %w", err)
-			}
-
-			docId, err := docResult.LastInsertId()
-			if err != nil {
-				return fmt.Errorf("failed to get last insert id for Documentation: %w", err)
-			}
-
-			_, err = tx.Exec(`
-                INSERT INTO "Check" (name, documentationId, score, reason, scorecardId) 
-                VALUES (?, ?, ?, ?, ?)`,
-				check.Name,
-				docId,
-				check.Score,
-				check.Reason,
-				scorecardId,
-			)
-			if err != nil {
-				return fmt.Errorf("failed to insert into Check: %w", err)
-			}
-		}
-
-		_, err = tx.Exec(`
-		INSERT INTO "DependencyDetails" (projectKeyId, openIssuesCount, starsCount, forksCount, license, description, homepage, scorecardId) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-			details.ProjectKey.ID,
-			details.OpenIssuesCount,
-			details.StarsCount,
-			details.ForksCount,
-			details.License,
-			details.Description,
-			details.Homepage,
-			scorecardId)
-		if err != nil {
-			return fmt.Errorf("failed to insert into DependencyDetails: %w", err)
+		if err := upsertDependencyDetails(tx, details); err != nil {
+			return err
 		}
 	}
 
@@ -225,76 +517,24 @@ func (s *SQLiteDB) LoadDetailedDependencies(dependenciesDetails []dependencieslo
 }
 
 func (s *SQLiteDB) AddNewDependencyDetails(details dependenciesloader.DependencyDetails) error {
+	if details.ProjectKey.ID == "" {
+		return fmt.Errorf("%w: projectKey id is empty", ErrInvalidInput)
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	_, err = tx.Exec(`INSERT INTO "ProjectKey" (id) VALUES (?) ON CONFLICT(id) DO NOTHING`, details.ProjectKey.ID)
-	if err != nil {
-		return fmt.Errorf("failed to insert into ProjectKey: %w", err)
+	if _, err := scorecardIDOf(tx, details.ProjectKey.ID); err == nil {
+		return fmt.Errorf("%w: dependency %s already exists", ErrConflict, details.ProjectKey.ID)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to query DependencyDetails: %w", err)
 	}
 
-	scorecardResult, err := tx.Exec(`
-			INSERT INTO "Scorecard" (date, repositoryName, repositoryCommit, scorecardVersion, scorecardCommit, overallScore, metadata) 
-			VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		details.Scorecard.Date,
-		details.Scorecard.Repository.Name,
-		details.Scorecard.Repository.Commit,
-		details.Scorecard.Scorecard.Version,
-		details.Scorecard.Scorecard.Commit,
-		details.Scorecard.OverallScore,
-		fmt.Sprintf("%v", details.Scorecard.Metadata),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to insert into Scorecard: %w", err)
-	}
-
-	scorecardId, err := scorecardResult.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("failed to get last insert id for Scorecard: %w", err)
-	}
-
-	for _, check := range details.Scorecard.Checks {
-		docResult, err := tx.Exec(`INSERT INTO "Documentation" (shortDescription, url) VALUES (?, ?)`,
-			check.Documentation.ShortDescription, check.Documentation.URL)
-		if err != nil {
-			return fmt.Errorf("failed to insert into Documentation: %w", err)
-		}
-
-		docId, err := docResult.LastInsertId()
-		if err != nil {
-			return fmt.Errorf("failed to get last insert id for Documentation: %w", err)
-		}
-
-		_, err = tx.Exec(`
-                INSERT INTO "Check" (name, documentationId, score, reason, scorecardId) 
-                VALUES (?, ?, ?, ?, ?)`,
-			check.Name,
-			docId,
-			check.Score,
-			check.Reason,
-			scorecardId,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert into Check: %w", err)
-		}
-	}
-
-	_, err = tx.Exec(`
-		INSERT INTO "DependencyDetails" (projectKeyId, openIssuesCount, starsCount, forksCount, license, description, homepage, scorecardId) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		details.ProjectKey.ID,
-		details.OpenIssuesCount,
-		details.StarsCount,
-		details.ForksCount,
-		details.License,
-		details.Description,
-		details.Homepage,
-		scorecardId)
-	if err != nil {
-		return fmt.Errorf("failed to insert into DependencyDetails: %w", err)
+	if err := upsertDependencyDetails(tx, details); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -317,7 +557,12 @@ func (s *SQLiteDB) UpdateVersionKeys(name, version string) error {
 		}
 	}()
 
-	_, err = tx.Exec(`
+	err = updateVersionKey(tx, name, version)
+	return err
+}
+
+func updateVersionKey(tx *sql.Tx, name, version string) error {
+	_, err := tx.Exec(`
 		UPDATE "VersionKeys" 
 		SET version = ?
 		WHERE name = ?
@@ -325,179 +570,366 @@ func (s *SQLiteDB) UpdateVersionKeys(name, version string) error {
 	if err != nil {
 		return fmt.Errorf("failed to update DependencyDetails: %w", err)
 	}
-
 	return nil
 }
 
 func (s *SQLiteDB) UpdateDependencyDetails(newDetails dependenciesloader.DependencyDetails) error {
 	projectKeyID := newDetails.ProjectKey.ID
+	if projectKeyID == "" {
+		return fmt.Errorf("%w: projectKey id is empty", ErrInvalidInput)
+	}
 
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
+	defer tx.Rollback()
 
-	var scorecardID int
-	query := `
-		SELECT s.id 
-		FROM DependencyDetails dd
-		JOIN Scorecard s ON dd.scorecardId = s.id
-		JOIN ProjectKey pk ON dd.projectKeyId = pk.id
-		WHERE pk.id = ?
-	`
-	err = tx.QueryRow(query, projectKeyID).Scan(&scorecardID)
-	if err != nil {
-		return fmt.Errorf("failed to get related scorecard ID: %w", err)
+	if _, err := scorecardIDOf(tx, projectKeyID); err != nil {
+		return notFound(err, "Scorecard of %s", projectKeyID)
 	}
 
-	_, err = tx.Exec(`
-		UPDATE "DependencyDetails" 
-		SET openIssuesCount = ?, starsCount = ?, forksCount = ?, license = ?, description = ?, homepage = ?
-		WHERE projectKeyId = ?
-	`, newDetails.OpenIssuesCount, newDetails.StarsCount, newDetails.ForksCount, newDetails.License, newDetails.Description, newDetails.Homepage, projectKeyID)
-	if err != nil {
-		return fmt.Errorf("failed to update DependencyDetails: %w", err)
+	if err := upsertDependencyDetails(tx, newDetails); err != nil {
+		return err
 	}
 
-	metadataJSON, _ := json.Marshal(newDetails.Scorecard.Metadata)
-	_, err = tx.Exec(`
-		UPDATE "Scorecard" 
-		SET date = ?, scorecardVersion = ?, scorecardCommit = ?, overallScore = ?, metadata = ?
-		WHERE id = ?
-	`, newDetails.Scorecard.Date, newDetails.Scorecard.Scorecard.Version, newDetails.Scorecard.Scorecard.Commit, newDetails.Scorecard.OverallScore, string(metadataJSON), scorecardID)
-	if err != nil {
-		return fmt.Errorf("failed to update Scorecard: %w", err)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	_, err = tx.Exec(`
-		DELETE FROM "Check"
-		WHERE scorecardId = (SELECT scorecardId FROM DependencyDetails WHERE projectKeyId = ?)
-	`, projectKeyID)
+	return nil
+}
+
+// scorecardIDOf returns the Scorecard of the stored details of a project, sql.ErrNoRows if there are none.
+func scorecardIDOf(tx *sql.Tx, projectKeyID string) (int64, error) {
+	var scorecardID int64
+	err := tx.QueryRow(`SELECT scorecardId FROM "DependencyDetails" WHERE projectKeyId = ?`, projectKeyID).Scan(&scorecardID)
+	return scorecardID, err
+}
+
+// upsertDependencyDetails inserts details of a project or replaces the stored ones in place. The project
+// keeps its DependencyDetails and Scorecard rows, Checks are matched by name and Documentation is shared
+// by equal checks, so writing the same details twice changes nothing but the fetch time.
+func upsertDependencyDetails(tx *sql.Tx, details dependenciesloader.DependencyDetails) error {
+	projectKeyID := details.ProjectKey.ID
+
+	_, err := tx.Exec(`INSERT INTO "ProjectKey" (id) VALUES (?) ON CONFLICT(id) DO NOTHING`, projectKeyID)
 	if err != nil {
-		return fmt.Errorf("failed to delete Check for projectKeyID %s: %w", projectKeyID, err)
+		return fmt.Errorf("failed to insert into ProjectKey: %w", err)
 	}
 
-	_, err = tx.Exec(`
-		DELETE FROM "Documentation"
-		WHERE id IN (
-			SELECT documentationId 
-			FROM "Check"
-			WHERE scorecardId = (SELECT scorecardId FROM DependencyDetails WHERE projectKeyId = ?)
+	scorecard := details.Scorecard
+	scorecardID, err := scorecardIDOf(tx, projectKeyID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		result, err := tx.Exec(`
+			INSERT INTO "Scorecard" (date, repositoryName, repositoryCommit, scorecardVersion, scorecardCommit, overallScore, metadata, fetchedAt)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			scorecard.Date,
+			scorecard.Repository.Name,
+			scorecard.Repository.Commit,
+			scorecard.Scorecard.Version,
+			scorecard.Scorecard.Commit,
+			scorecard.OverallScore,
+			metadataOf(scorecard.Metadata),
+			now(),
 		)
-	`, projectKeyID)
+		if err != nil {
+			return fmt.Errorf("failed to insert into Scorecard: %w", err)
+		}
+		if scorecardID, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("failed to get last insert id for Scorecard: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get Scorecard of %s: %w", projectKeyID, err)
+	default:
+		_, err = tx.Exec(`
+			UPDATE "Scorecard"
+			SET date = ?, repositoryName = ?, repositoryCommit = ?, scorecardVersion = ?, scorecardCommit = ?,
+			    overallScore = ?, metadata = ?, fetchedAt = ?
+			WHERE id = ?`,
+			scorecard.Date,
+			scorecard.Repository.Name,
+			scorecard.Repository.Commit,
+			scorecard.Scorecard.Version,
+			scorecard.Scorecard.Commit,
+			scorecard.OverallScore,
+			metadataOf(scorecard.Metadata),
+			now(),
+			scorecardID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update Scorecard: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO "DependencyDetails" (projectKeyId, openIssuesCount, starsCount, forksCount, license, description, homepage, scorecardId)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(projectKeyId) DO UPDATE SET
+			openIssuesCount = excluded.openIssuesCount,
+			starsCount = excluded.starsCount,
+			forksCount = excluded.forksCount,
+			license = excluded.license,
+			description = excluded.description,
+			homepage = excluded.homepage`,
+		projectKeyID,
+		details.OpenIssuesCount,
+		details.StarsCount,
+		details.ForksCount,
+		details.License,
+		details.Description,
+		details.Homepage,
+		scorecardID,
+	)
 	if err != nil {
-		return fmt.Errorf("failed to delete Documentation for projectKeyID %s: %w", projectKeyID, err)
+		return fmt.Errorf("failed to upsert DependencyDetails: %w", err)
 	}
 
-	for _, check := range newDetails.Scorecard.Checks {
+	names := []any{scorecardID}
+	for _, check := range scorecard.Checks {
 		_, err := tx.Exec(`
-			INSERT OR IGNORE INTO "Documentation" (shortDescription, url)
-			VALUES (?, ?)
-		`, check.Documentation.ShortDescription, check.Documentation.URL)
+			INSERT INTO "Documentation" (shortDescription, url) VALUES (?, ?)
+			ON CONFLICT(shortDescription, url) DO NOTHING`,
+			check.Documentation.ShortDescription, check.Documentation.URL)
 		if err != nil {
 			return fmt.Errorf("failed to insert Documentation for check %s: %w", check.Name, err)
 		}
 
-		_, err = tx.Exec(`
-			UPDATE "Documentation"
-			SET shortDescription = ?, url = ?
-			WHERE shortDescription = ? AND url = ?
-		`, check.Documentation.ShortDescription, check.Documentation.URL, check.Documentation.ShortDescription, check.Documentation.URL)
-		if err != nil {
-			return fmt.Errorf("failed to update Documentation for check %s: %w", check.Name, err)
-		}
-
-		var documentationID int
-		err = tx.QueryRow(`
-			SELECT id FROM "Documentation"
-			WHERE shortDescription = ? AND url = ?
-		`, check.Documentation.ShortDescription, check.Documentation.URL).Scan(&documentationID)
+		var documentationID int64
+		err = tx.QueryRow(`SELECT id FROM "Documentation" WHERE shortDescription = ? AND url = ?`,
+			check.Documentation.ShortDescription, check.Documentation.URL).Scan(&documentationID)
 		if err != nil {
 			return fmt.Errorf("failed to get Documentation ID for check %s: %w", check.Name, err)
 		}
 
 		_, err = tx.Exec(`
-			INSERT OR IGNORE INTO "Check" (name, score, reason, documentationId, scorecardId)
-			VALUES (?, ?, ?, ?, ?)
-		`, check.Name, check.Score, check.Reason, documentationID, scorecardID)
+			INSERT INTO "Check" (name, documentationId, score, reason, scorecardId) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(scorecardId, name) DO UPDATE SET
+				documentationId = excluded.documentationId,
+				score = excluded.score,
+				reason = excluded.reason`,
+			check.Name, documentationID, check.Score, check.Reason, scorecardID)
 		if err != nil {
-			return fmt.Errorf("failed to insert Check for %s: %w", check.Name, err)
+			return fmt.Errorf("failed to upsert Check %s: %w", check.Name, err)
 		}
+		names = append(names, check.Name)
+	}
 
-		_, err = tx.Exec(`
-			UPDATE "Check"
-			SET score = ?, reason = ?, documentationId = ?
-			WHERE name = ? AND scorecardId = ?
-		`, check.Score, check.Reason, documentationID, check.Name, scorecardID)
-		if err != nil {
-			return fmt.Errorf("failed to update Check for %s: %w", check.Name, err)
-		}
+	// checks which are not reported anymore
+	_, err = tx.Exec(`DELETE FROM "Check" WHERE scorecardId = ? AND name NOT IN (`+placeholders(len(names)-1)+`)`, names...)
+	if err != nil {
+		return fmt.Errorf("failed to delete Checks of %s: %w", projectKeyID, err)
 	}
 
 	return nil
 }
 
+// GetDependencyDetailsByID returns the details of a project with manual overrides applied.
 func (s *SQLiteDB) GetDependencyDetailsByID(projectKeyID string) (*dependenciesloader.DependencyDetails, error) {
-	var detail dependenciesloader.DependencyDetails
+	details, err := getDependencyDetails(s.db, projectKeyID)
+	if err != nil {
+		return nil, err
+	}
+	return withOverrides(s.db, details)
+}
+
+// GetUpstreamDependencyDetailsByID returns the details of a project as they were fetched, without overrides.
+func (s *SQLiteDB) GetUpstreamDependencyDetailsByID(projectKeyID string) (*dependenciesloader.DependencyDetails, error) {
+	return getDependencyDetails(s.db, projectKeyID)
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func getDependencyDetails(q querier, projectKeyID string) (*dependenciesloader.DependencyDetails, error) {
+	details, err := getDependencyDetailsByIDs(q, []string{projectKeyID})
+	if err != nil {
+		return nil, err
+	}
+	detail, ok := details[projectKeyID]
+	if !ok {
+		return nil, notFound(sql.ErrNoRows, "DependencyDetails %s", projectKeyID)
+	}
+	return detail, nil
+}
+
+// GetDependencyDetailsByIDs returns the details of projects with manual overrides applied, keyed by
+// project key ID. Projects which aren't stored are left out. The details are read with a fixed number
+// of queries however many projects are requested.
+func (s *SQLiteDB) GetDependencyDetailsByIDs(projectKeyIDs []string) (map[string]*dependenciesloader.DependencyDetails, error) {
+	details, err := getDependencyDetailsByIDs(s.db, projectKeyIDs)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := getOverridesByIDs(s.db, projectKeyIDs)
+	if err != nil {
+		return nil, err
+	}
+	for id, detail := range details {
+		if err := overrides.Apply(detail, stored[id]); err != nil {
+			return nil, fmt.Errorf("failed to apply overrides of %s: %w", id, err)
+		}
+	}
+	return details, nil
+}
+
+// GetProjectKeyIDs returns the IDs of all stored projects in ascending order.
+func (s *SQLiteDB) GetProjectKeyIDs() ([]string, error) {
+	rows, err := s.db.Query(`SELECT id FROM ProjectKey ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ProjectKey: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan ProjectKey: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DependencyFilter selects projects, a project has to match every set field.
+type DependencyFilter struct {
+	// Search is a case insensitive substring of the ID.
+	Search string
+	// License is compared case insensitively.
+	License  string
+	MinScore *float64
+	MaxScore *float64
+	// Vulnerable selects projects whose stored versions have, or haven't, advisories.
+	Vulnerable *bool
+}
+
+// FindDependencies returns the details of projects matching the filter ordered by ID. Filters see
+// the details with overrides applied, as they are returned.
+func (s *SQLiteDB) FindDependencies(filter DependencyFilter) ([]*dependenciesloader.DependencyDetails, error) {
+	ids, err := s.GetProjectKeyIDs()
+	if err != nil {
+		return nil, err
+	}
+	details, err := s.GetDependencyDetailsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	var advisories map[string][]AdvisoryFinding
+	if filter.Vulnerable != nil {
+		if advisories, err = s.GetAdvisoriesByIDs(ids); err != nil {
+			return nil, err
+		}
+	}
+
+	result := []*dependenciesloader.DependencyDetails{}
+	for _, id := range ids {
+		detail, ok := details[id]
+		switch {
+		case !ok,
+			filter.Search != "" && !strings.Contains(strings.ToLower(id), strings.ToLower(filter.Search)),
+			filter.License != "" && !strings.EqualFold(detail.License, filter.License),
+			filter.MinScore != nil && detail.Scorecard.OverallScore < *filter.MinScore,
+			filter.MaxScore != nil && detail.Scorecard.OverallScore > *filter.MaxScore,
+			filter.Vulnerable != nil && (len(advisories[id]) > 0) != *filter.Vulnerable:
+			continue
+		}
+		result = append(result, detail)
+	}
+	return result, nil
+}
+
+// versionOfProject selects the name of the stored version of the project in the column. A project may have
+// versions of several packages, e.g. github.com/alecaivazis/survey of the modules github.com/AlecAivazis/survey
+// and github.com/AlecAivazis/survey/v2, the package named like the project is preferred.
+func versionOfProject(column string) string {
+	return `SELECT COALESCE(MAX(CASE WHEN name = ` + column + ` THEN name END), MIN(name)) FROM VersionKeys WHERE projectKeyId = ` + column
+}
+
+func getDependencyDetailsByIDs(q querier, projectKeyIDs []string) (map[string]*dependenciesloader.DependencyDetails, error) {
+	details := map[string]*dependenciesloader.DependencyDetails{}
+	if len(projectKeyIDs) == 0 {
+		return details, nil
+	}
 
 	query := `SELECT pk.id, dd.openIssuesCount, dd.starsCount, dd.forksCount, dd.license,
                      dd.description, dd.homepage, sc.date, sc.repositoryName, sc.repositoryCommit,
-                     sc.scorecardVersion, sc.scorecardCommit, sc.overallScore, sc.metadata
+                     sc.scorecardVersion, sc.scorecardCommit, sc.overallScore, sc.metadata,
+                     vk.system, vk.name, vk.version
               FROM DependencyDetails dd
               JOIN ProjectKey pk ON dd.projectKeyId = pk.id
               JOIN Scorecard sc ON dd.scorecardId = sc.id
-              WHERE pk.id = ?`
-
-	var metadataStr string
-
-	err := s.db.QueryRow(query, projectKeyID).Scan(
-		&detail.ProjectKey.ID,
-		&detail.OpenIssuesCount,
-		&detail.StarsCount,
-		&detail.ForksCount,
-		&detail.License,
-		&detail.Description,
-		&detail.Homepage,
-		&detail.Scorecard.Date,
-		&detail.Scorecard.Repository.Name,
-		&detail.Scorecard.Repository.Commit,
-		&detail.Scorecard.Scorecard.Version,
-		&detail.Scorecard.Scorecard.Commit,
-		&detail.Scorecard.OverallScore,
-		&metadataStr,
-	)
+              LEFT JOIN VersionKeys vk ON vk.name = (` + versionOfProject("pk.id") + `)
+              WHERE pk.id IN (` + placeholders(len(projectKeyIDs)) + `)`
+
+	rows, err := q.Query(query, queryArgs(projectKeyIDs)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get DependencyDetails: %w", err)
+		return nil, fmt.Errorf("failed to query DependencyDetails: %w", err)
 	}
+	defer rows.Close()
 
-	if err := json.Unmarshal([]byte(metadataStr), &detail.Scorecard.Metadata); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	for rows.Next() {
+		var detail dependenciesloader.DependencyDetails
+		var metadataStr string
+		var system, name, version sql.NullString
+
+		err := rows.Scan(
+			&detail.ProjectKey.ID,
+			&detail.OpenIssuesCount,
+			&detail.StarsCount,
+			&detail.ForksCount,
+			&detail.License,
+			&detail.Description,
+			&detail.Homepage,
+			&detail.Scorecard.Date,
+			&detail.Scorecard.Repository.Name,
+			&detail.Scorecard.Repository.Commit,
+			&detail.Scorecard.Scorecard.Version,
+			&detail.Scorecard.Scorecard.Commit,
+			&detail.Scorecard.OverallScore,
+			&metadataStr,
+			&system,
+			&name,
+			&version,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan DependencyDetails: %w", err)
+		}
+		if system.Valid {
+			detail.Purl = purl.FromVersionKey(dependenciesloader.VersionKey{System: system.String, Name: name.String, Version: version.String})
+		}
+		if err := json.Unmarshal([]byte(metadataStr), &detail.Scorecard.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+		details[detail.ProjectKey.ID] = &detail
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over DependencyDetails: %w", err)
+	}
+	rows.Close()
 
-	checkQuery := `SELECT c.name, c.score, c.reason, d.shortDescription, d.url
+	checkQuery := `SELECT dd.projectKeyId, c.name, c.score, c.reason, d.shortDescription, d.url
                    FROM "Check" c
                    JOIN Documentation d ON c.documentationId = d.id
-                   WHERE c.scorecardId = (SELECT sc.id FROM Scorecard sc
-                                          JOIN DependencyDetails dd ON dd.scorecardId = sc.id
-                                          JOIN ProjectKey pk ON dd.projectKeyId = pk.id
-                                          WHERE pk.id = ?)`
+                   JOIN DependencyDetails dd ON dd.scorecardId = c.scorecardId
+                   WHERE dd.projectKeyId IN (` + placeholders(len(projectKeyIDs)) + `)
+                   ORDER BY c.id`
 
-	rows, err := s.db.Query(checkQuery, projectKeyID)
+	checkRows, err := q.Query(checkQuery, queryArgs(projectKeyIDs)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Checks: %w", err)
 	}
-	defer rows.Close()
+	defer checkRows.Close()
 
-	for rows.Next() {
+	for checkRows.Next() {
+		var projectKeyID string
 		var check dependenciesloader.Check
-		err := rows.Scan(
+		err := checkRows.Scan(
+			&projectKeyID,
 			&check.Name,
 			&check.Score,
 			&check.Reason,
@@ -507,14 +939,30 @@ func (s *SQLiteDB) GetDependencyDetailsByID(projectKeyID string) (*dependenciesl
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan Check: %w", err)
 		}
-		detail.Scorecard.Checks = append(detail.Scorecard.Checks, check)
+		if detail, ok := details[projectKeyID]; ok {
+			detail.Scorecard.Checks = append(detail.Scorecard.Checks, check)
+		}
 	}
 
-	if err := rows.Err(); err != nil {
+	if err := checkRows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating over Checks: %w", err)
 	}
 
-	return &detail, nil
+	return details, nil
+}
+
+// placeholders returns n comma separated parameters of a query, e.g. for an IN list.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// queryArgs converts values to arguments of a query.
+func queryArgs[T any](values []T) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
 }
 
 func (s *SQLiteDB) GetAllDependencies() ([]dependenciesloader.DependencyDetails, error) {
@@ -603,7 +1051,7 @@ func (s *SQLiteDB) DeleteDependencyWithDetails(projectKeyID string) error {
     `
 	err = tx.QueryRow(query, projectKeyID).Scan(&dependencyDetailsID, &scorecardID)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve related IDs: %w", err)
+		return notFound(err, "DependencyDetails %s", projectKeyID)
 	}
 
 	_, err = tx.Exec(`
@@ -614,18 +1062,6 @@ func (s *SQLiteDB) DeleteDependencyWithDetails(projectKeyID string) error {
 		return fmt.Errorf("failed to delete Checks: %w", err)
 	}
 
-	_, err = tx.Exec(`
-        DELETE FROM "Documentation"
-        WHERE id IN (
-            SELECT DISTINCT documentationId 
-            FROM "Check" 
-            WHERE scorecardId = ?
-        )
-    `, scorecardID)
-	if err != nil {
-		return fmt.Errorf("failed to delete Documentation: %w", err)
-	}
-
 	_, err = tx.Exec(`
         DELETE FROM "Scorecard"
         WHERE id = ?
@@ -642,6 +1078,31 @@ func (s *SQLiteDB) DeleteDependencyWithDetails(projectKeyID string) error {
 		return fmt.Errorf("failed to delete DependencyDetails: %w", err)
 	}
 
+	_, err = tx.Exec(`
+        DELETE FROM "Override"
+        WHERE projectKeyId = ?
+    `, projectKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete Overrides: %w", err)
+	}
+
+	_, err = tx.Exec(`
+        DELETE FROM "Alert"
+        WHERE projectKeyId = ?
+    `, projectKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete Alerts: %w", err)
+	}
+
+	// versions stay in the graph, only their link to the deleted project is removed
+	_, err = tx.Exec(`
+        UPDATE "VersionKeys" SET projectKeyId = NULL
+        WHERE projectKeyId = ?
+    `, projectKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink VersionKeys: %w", err)
+	}
+
 	_, err = tx.Exec(`
         DELETE FROM "ProjectKey"
         WHERE id = ?
@@ -652,3 +1113,50 @@ func (s *SQLiteDB) DeleteDependencyWithDetails(projectKeyID string) error {
 
 	return nil
 }
+
+// GetStaleScorecards returns dependencies whose Scorecard was last fetched before now-maxAge, the name
+// of a stored version of the project or the project ID if none is linked to it.
+// Rows stored before fetch times were tracked fall back to the Scorecard date.
+func (s *SQLiteDB) GetStaleScorecards(maxAge time.Duration) ([]string, error) {
+	query := `
+        SELECT COALESCE(
+            (SELECT vk.name FROM VersionKeys vk WHERE vk.projectKeyId = dd.projectKeyId ORDER BY vk.name LIMIT 1),
+            dd.projectKeyId
+        )
+        FROM DependencyDetails dd
+        JOIN Scorecard sc ON dd.scorecardId = sc.id
+        WHERE COALESCE(sc.fetchedAt, sc.date) < ?
+    `
+
+	cutoff := time.Now().UTC().Add(-maxAge).Format(time.RFC3339)
+	rows, err := s.db.Query(query, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stale scorecards: %w", err)
+	}
+
+	var projectKeyIDs []string
+	defer rows.Close()
+
+	for rows.Next() {
+		var projectKeyID string
+		if err := rows.Scan(&projectKeyID); err != nil {
+			return nil, fmt.Errorf("failed to scan DependencyDetails: %w", err)
+		}
+		projectKeyIDs = append(projectKeyIDs, projectKeyID)
+	}
+
+	return projectKeyIDs, nil
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// metadataOf encodes Scorecard metadata the way GetDependencyDetailsByID decodes it.
+func metadataOf(metadata []string) string {
+	if metadata == nil {
+		metadata = []string{}
+	}
+	encoded, _ := json.Marshal(metadata)
+	return string(encoded)
+}
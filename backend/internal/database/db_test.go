@@ -2,12 +2,16 @@ package database
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/overrides"
 )
 
 func GetTestDatabase(t *testing.T) *SQLiteDB {
@@ -49,6 +53,69 @@ func TestLoadDependencies(t *testing.T) {
 	}
 }
 
+func TestLoadDependenciesKeepsFirstVersion(t *testing.T) {
+	db, err := NewSQLiteDB(path.Join(t.TempDir(), "versions.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	defer db.CloseDbConnection()
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	// a lockfile with ms hoisted at 2.1.3 and 2.1.2 nested under debug
+	nodes := []dependenciesloader.Node{
+		{VersionKey: dependenciesloader.VersionKey{System: "NPM", Name: "ms", Version: "2.1.3"}, Relation: "DIRECT"},
+		{VersionKey: dependenciesloader.VersionKey{System: "NPM", Name: "ms", Version: "2.1.2"}, Relation: "INDIRECT"},
+	}
+	if err := db.LoadDependencies(nodes); err != nil {
+		t.Fatal("failed to load dependencies:", err)
+	}
+
+	graph, err := db.GetDependencyGraph()
+	if err != nil {
+		t.Fatal("failed to get dependency graph:", err)
+	}
+	if diff := cmp.Diff(nodes[:1], graph.Nodes, cmpopts.IgnoreFields(dependenciesloader.Node{}, "Errors")); diff != "" {
+		t.Fatalf("want the first node of ms stored (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplyDependencyChange(t *testing.T) {
+	db, err := NewSQLiteDB(path.Join(t.TempDir(), "changes.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	defer db.CloseDbConnection()
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	details := getDetailedDependenciesMock(t, "dependencies_details_mock.json")[1]
+	node := dependenciesloader.Node{VersionKey: dependenciesloader.VersionKey{System: "GO", Name: "github.com/AlecAivazis/survey/v2", Version: "v2.2.14"}, Relation: "DIRECT"}
+	alert := Alert{ProjectKeyID: details.ProjectKey.ID, Rule: "overall-score-below-5", Score: 4}
+
+	// an update of details which were never stored fails as a whole
+	err = db.ApplyDependencyChange(DependencyChange{Name: node.VersionKey.Name, Version: "v2.3.0", Details: &details, Alerts: []Alert{alert}})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound for an update of unknown details, got: %v", err)
+	}
+	if alerts, err := db.GetAlerts(false); err != nil || len(alerts) != 0 {
+		t.Fatalf("want no alerts of a failed change, got %v, %v", alerts, err)
+	}
+
+	if err := db.ApplyDependencyChange(DependencyChange{Name: node.VersionKey.Name, Node: &node, Details: &details, Alerts: []Alert{alert}}); err != nil {
+		t.Fatal("failed to apply change:", err)
+	}
+	alerts, err := db.GetAlerts(false)
+	if err != nil || len(alerts) != 1 || alerts[0].Purl != "pkg:golang/github.com/AlecAivazis/survey/v2@v2.2.14" {
+		t.Fatalf("want the alert of the project with the purl of the module, got %v, %v", alerts, err)
+	}
+	if got, err := db.GetProjectKeyIDOf(node.VersionKey.Name); err != nil || got != details.ProjectKey.ID {
+		t.Fatalf("want the module linked to %s, got %q, %v", details.ProjectKey.ID, got, err)
+	}
+}
+
 func TestLoadDetailedDependencies(t *testing.T) {
 
 	db := GetTestDatabase(t)
@@ -71,6 +138,127 @@ func TestGetVersionKeys(t *testing.T) {
 	}
 }
 
+func TestLinkVersionKeys(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	// deps.dev names the project of the module after its repository
+	if err := db.LinkVersionKeys(map[string]string{"github.com/AlecAivazis/survey/v2": "github.com/alecaivazis/survey"}); err != nil {
+		t.Fatal("failed to link version keys:", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"github.com/AlecAivazis/survey/v2", "github.com/alecaivazis/survey"},
+		// linked by name when the tables were created
+		{"github.com/cli/cli", "github.com/cli/cli"},
+	}
+	for _, test := range tests {
+		got, err := db.GetProjectKeyIDOf(test.name)
+		if err != nil || got != test.want {
+			t.Fatalf("project of %s: want %s, got %q, %v", test.name, test.want, got, err)
+		}
+	}
+	if _, err := db.GetProjectKeyIDOf("github.com/unknown/unknown"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound for unknown dependency, got: %v", err)
+	}
+
+	projectKeyIDs, err := db.GetProjectKeyIDsByNames([]string{"github.com/AlecAivazis/survey/v2", "github.com/unknown/unknown"})
+	if err != nil {
+		t.Fatal("failed to get project key ids by names:", err)
+	}
+	if diff := cmp.Diff(map[string]string{"github.com/AlecAivazis/survey/v2": "github.com/alecaivazis/survey"}, projectKeyIDs); diff != "" {
+		t.Fatalf("unexpected projects of versions (-want +got):\n%s", diff)
+	}
+	names, err := db.GetVersionNamesByProjectKeyIDs([]string{"github.com/alecaivazis/survey", "github.com/cli/cli", "github.com/charmbracelet/glamour"})
+	if err != nil {
+		t.Fatal("failed to get version names by project key ids:", err)
+	}
+	// glamour has details but no stored version
+	want := map[string]string{"github.com/alecaivazis/survey": "github.com/AlecAivazis/survey/v2", "github.com/cli/cli": "github.com/cli/cli"}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Fatalf("unexpected versions of projects (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadDetailedDependenciesIsIdempotent(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	before := countRows(t, db, "DependencyDetails", "Scorecard", "Check", "Documentation")
+	if err := db.LoadDetailedDependencies(getDetailedDependenciesMock(t, "dependencies_details_mock.json")[:5]); err != nil {
+		t.Fatalf("failed to load detailed dependencies again: %v", err)
+	}
+	after := countRows(t, db, "DependencyDetails", "Scorecard", "Check", "Documentation")
+
+	if !cmp.Equal(before, after) {
+		t.Fatal("loading the same details twice changed the number of rows: ", cmp.Diff(before, after))
+	}
+}
+
+func TestCreateTablesRemovesDuplicates(t *testing.T) {
+	db, err := NewSQLiteDB(path.Join(t.TempDir(), "duplicates.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	defer db.CloseDbConnection()
+
+	// details stored twice for a project by older versions of the app, without unique constraints
+	statements := []string{
+		`CREATE TABLE "Documentation" (id INTEGER PRIMARY KEY AUTOINCREMENT, shortDescription TEXT, url TEXT)`,
+		`CREATE TABLE "Check" (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, documentationId INTEGER, score INTEGER, reason TEXT, scorecardId INTEGER)`,
+		`CREATE TABLE "Scorecard" (id INTEGER PRIMARY KEY AUTOINCREMENT, date TEXT, repositoryName TEXT, repositoryCommit TEXT, scorecardVersion TEXT, scorecardCommit TEXT, overallScore REAL, metadata TEXT)`,
+		`CREATE TABLE "DependencyDetails" (id INTEGER PRIMARY KEY AUTOINCREMENT, projectKeyId TEXT, openIssuesCount INTEGER, starsCount INTEGER, forksCount INTEGER, license TEXT, description TEXT, homepage TEXT, scorecardId INTEGER)`,
+		`INSERT INTO "Documentation" (shortDescription, url) VALUES ('d', 'u'), ('d', 'u')`,
+		`INSERT INTO "Scorecard" (overallScore, metadata) VALUES (5, '[]'), (6, '[]')`,
+		`INSERT INTO "Check" (name, documentationId, score, scorecardId) VALUES ('Maintained', 1, 5, 1), ('Maintained', 2, 6, 2), ('Maintained', 2, 7, 2)`,
+		`INSERT INTO "DependencyDetails" (projectKeyId, license, scorecardId) VALUES ('github.com/cli/cli', 'MIT', 1), ('github.com/cli/cli', 'MIT', 2)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.db.Exec(stmt); err != nil {
+			t.Fatalf("failed to prepare database: %v", err)
+		}
+	}
+
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	want := map[string]int{"DependencyDetails": 1, "Scorecard": 1, "Check": 1, "Documentation": 1}
+	if got := countRows(t, db, "DependencyDetails", "Scorecard", "Check", "Documentation"); !cmp.Equal(got, want) {
+		t.Fatal("duplicates were not removed: ", cmp.Diff(want, got))
+	}
+
+	var score, documentationID int
+	if err := db.db.QueryRow(`SELECT score, documentationId FROM "Check"`).Scan(&score, &documentationID); err != nil {
+		t.Fatal("failed to query Check:", err)
+	}
+	if score != 7 || documentationID != 1 {
+		t.Fatalf("latest check should be kept with the remaining documentation, got score: %d, documentationId: %d", score, documentationID)
+	}
+
+	if _, err := db.db.Exec(`INSERT INTO "DependencyDetails" (projectKeyId, scorecardId) VALUES ('github.com/cli/cli', 3)`); err == nil {
+		t.Fatal("second DependencyDetails row of a project was accepted")
+	}
+}
+
+func TestGetVersionKey(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	got, err := db.GetVersionKey("github.com/cli/cli")
+	if err != nil {
+		t.Fatal("failed to get version key:", err)
+	}
+	want := dependenciesloader.VersionKey{System: "GO", Name: "github.com/cli/cli", Version: "v1.14.0"}
+	if got != want {
+		t.Fatalf("got != want, want: %v, got: %v", want, got)
+	}
+
+	if _, err := db.GetVersionKey("github.com/unknown/unknown"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound for unknown dependency, got: %v", err)
+	}
+}
+
 func TestGetAllDependencies(t *testing.T) {
 	db := GetTestDatabase(t)
 	checkAllDependencies(t, db, 5)
@@ -84,6 +272,9 @@ func TestAddNewDependencyDetails(t *testing.T) {
 	if err := db.AddNewDependencyDetails(detailedDependencies[5]); err != nil {
 		t.Fatal("failed to add new dependency details:", err)
 	}
+	if err := db.AddNewDependencyDetails(detailedDependencies[5]); !errors.Is(err, ErrConflict) {
+		t.Fatalf("want ErrConflict when adding dependency details twice, got: %v", err)
+	}
 
 	checkAllDependencies(t, db, 6)
 }
@@ -97,6 +288,7 @@ func TestGetDependencyDetailsByID(t *testing.T) {
 	}
 
 	want := getDetailedDependenciesMock(t, "dependencies_details_mock.json")[0]
+	want.Purl = "pkg:golang/github.com/cli/cli@v1.14.0"
 
 	if !cmp.Equal(*got, want) {
 		t.Fatal("dependency details from test db are not equal to mocks: ", cmp.Diff(*got, want))
@@ -143,6 +335,397 @@ func TestGetDependenciesByOverallScore(t *testing.T) {
 	}
 }
 
+func TestGetStaleScorecards(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	fresh, err := db.GetStaleScorecards(24 * time.Hour)
+	if err != nil {
+		t.Fatal("failed to get stale scorecards:", err)
+	}
+	if len(fresh) != 0 {
+		t.Fatalf("just fetched scorecards reported as stale, want: %d, got: %d", 0, len(fresh))
+	}
+
+	stale, err := db.GetStaleScorecards(-time.Hour)
+	if err != nil {
+		t.Fatal("failed to get stale scorecards:", err)
+	}
+	const want = 6
+	if len(stale) != want {
+		t.Fatalf("got != want, want: %d, got: %d", want, len(stale))
+	}
+}
+
+func TestLoadAdvisories(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	advisory := dependenciesloader.Advisory{
+		AdvisoryKey: dependenciesloader.AdvisoryKey{ID: "GHSA-xxxx-xxxx-xxxx"},
+		Title:       "Test advisory",
+		Aliases:     []string{"CVE-2024-0001"},
+		CVSS3Score:  7.5,
+	}
+	versionAdvisories := []dependenciesloader.VersionAdvisories{
+		{
+			VersionKey: dependenciesloader.VersionKey{System: "GO", Name: "github.com/briandowns/spinner", Version: "v1.11.1"},
+			Advisories: []dependenciesloader.Advisory{advisory},
+		},
+		{
+			VersionKey: dependenciesloader.VersionKey{System: "GO", Name: "github.com/cli/cli", Version: "v1.0.0"},
+			Advisories: []dependenciesloader.Advisory{advisory},
+		},
+	}
+
+	if err := db.LoadAdvisories(versionAdvisories, SourceDepsDev); err != nil {
+		t.Fatal("failed to load advisories:", err)
+	}
+
+	got, err := db.GetAdvisoriesByID("github.com/briandowns/spinner")
+	if err != nil {
+		t.Fatal("failed to get advisories by id:", err)
+	}
+	if len(got) != 1 || !cmp.Equal(got[0].Advisory, advisory) || got[0].Source != SourceDepsDev {
+		t.Fatalf("unexpected advisories: %+v", got)
+	}
+
+	vulnerable, err := db.GetVulnerableDependencies()
+	if err != nil {
+		t.Fatal("failed to get vulnerable dependencies:", err)
+	}
+	// advisory of github.com/cli/cli affects a version different from the stored one
+	const want = 1
+	if len(vulnerable) != want {
+		t.Fatalf("got != want, want: %d, got: %d", want, len(vulnerable))
+	}
+	if vulnerable[0].Purl != "pkg:golang/github.com/briandowns/spinner@v1.11.1" {
+		t.Fatalf("unexpected purl of vulnerable dependency: %s", vulnerable[0].Purl)
+	}
+}
+
+func TestGetDependencyGraph(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	nodes := []dependenciesloader.Node{
+		{VersionKey: dependenciesloader.VersionKey{System: "GO", Name: "github.com/cli/cli", Version: "v1.14.0"}, Relation: "SELF"},
+		{VersionKey: dependenciesloader.VersionKey{System: "GO", Name: "github.com/briandowns/spinner", Version: "v1.11.1"}, Relation: "DIRECT"},
+		{VersionKey: dependenciesloader.VersionKey{System: "GO", Name: "github.com/AlecAivazis/survey/v2", Version: "v2.2.14"}, Relation: "DIRECT"},
+	}
+	dependencies := dependenciesloader.Dependencies{
+		Nodes: nodes,
+		Edges: []dependenciesloader.Edge{
+			{FromNode: 0, ToNode: 1, Requirement: "v1.11.1"},
+			{FromNode: 0, ToNode: 2, Requirement: "v2.2.14"},
+		},
+	}
+	if err := db.LoadDependencies(nodes); err != nil {
+		t.Fatal("failed to load version keys:", err)
+	}
+	if err := db.LoadDependencyEdges(dependencies); err != nil {
+		t.Fatal("failed to load dependency edges:", err)
+	}
+
+	graph, err := db.GetDependencyGraph()
+	if err != nil {
+		t.Fatal("failed to get dependency graph:", err)
+	}
+	if graph.Nodes[0].VersionKey.Name != "github.com/cli/cli" || graph.Nodes[0].Relation != "SELF" {
+		t.Fatalf("root node should be first, got: %+v", graph.Nodes[0])
+	}
+
+	type namedEdge struct{ From, To, Requirement string }
+	got := []namedEdge{}
+	for _, edge := range graph.Edges {
+		got = append(got, namedEdge{graph.Nodes[edge.FromNode].VersionKey.Name, graph.Nodes[edge.ToNode].VersionKey.Name, edge.Requirement})
+	}
+	want := []namedEdge{
+		{"github.com/cli/cli", "github.com/AlecAivazis/survey/v2", "v2.2.14"},
+		{"github.com/cli/cli", "github.com/briandowns/spinner", "v1.11.1"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected edges (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetDependencyGraphByNames(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	const spinner = "github.com/briandowns/spinner"
+	names := []string{spinner, "github.com/unknown/unknown"}
+
+	nodes, err := db.GetNodesByNames(names)
+	if err != nil {
+		t.Fatal("failed to get nodes by names:", err)
+	}
+	wantNodes := map[string]dependenciesloader.Node{
+		spinner: {VersionKey: dependenciesloader.VersionKey{System: "GO", Name: spinner, Version: "v1.11.1"}, Relation: "DIRECT"},
+	}
+	if diff := cmp.Diff(wantNodes, nodes); diff != "" {
+		t.Fatalf("unexpected nodes (-want +got):\n%s", diff)
+	}
+
+	edges, err := db.GetDependencyEdgesByNames(names)
+	if err != nil {
+		t.Fatal("failed to get dependency edges by names:", err)
+	}
+	wantEdges := []DependencyEdge{{From: "github.com/cli/cli", To: spinner, Requirement: "v1.11.1"}}
+	if diff := cmp.Diff(wantEdges, edges); diff != "" {
+		t.Fatalf("unexpected edges (-want +got):\n%s", diff)
+	}
+
+	advisories, err := db.GetAdvisoriesByNames([]string{spinner, "github.com/cli/cli"})
+	if err != nil {
+		t.Fatal("failed to get advisories by names:", err)
+	}
+	// the advisory of github.com/cli/cli affects a version different from the stored one
+	if len(advisories) != 1 || len(advisories[spinner]) != 1 || advisories[spinner][0].AdvisoryKey.ID != "GHSA-xxxx-xxxx-xxxx" {
+		t.Fatalf("unexpected advisories: %+v", advisories)
+	}
+}
+
+func TestPatchDependencyDetails(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	const id = "github.com/cli/cli"
+	current, err := db.GetDependencyDetailsByID(id)
+	if err != nil {
+		t.Fatal("failed to get dependency details:", err)
+	}
+	etag := ETag(*current)
+
+	setLicense := func(license string) func(dependenciesloader.DependencyDetails) (dependenciesloader.DependencyDetails, error) {
+		return func(details dependenciesloader.DependencyDetails) (dependenciesloader.DependencyDetails, error) {
+			details.License = license
+			return details, nil
+		}
+	}
+
+	patched, err := db.PatchDependencyDetails(id, []string{etag}, setLicense("Apache-2.0"))
+	if err != nil {
+		t.Fatal("failed to patch dependency details:", err)
+	}
+	if patched.License != "Apache-2.0" || ETag(*patched) == etag {
+		t.Fatalf("license was not patched: %s", patched.License)
+	}
+
+	if _, err := db.PatchDependencyDetails(id, []string{etag}, setLicense("BSD-3-Clause")); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("want ErrPreconditionFailed for outdated ETag, got: %v", err)
+	}
+	if _, err := db.PatchDependencyDetails("github.com/unknown/unknown", nil, setLicense("MIT")); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound for unknown dependency, got: %v", err)
+	}
+	rename := func(details dependenciesloader.DependencyDetails) (dependenciesloader.DependencyDetails, error) {
+		details.ProjectKey.ID = "github.com/cli/other"
+		return details, nil
+	}
+	if _, err := db.PatchDependencyDetails(id, nil, rename); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("want ErrInvalidInput when changing projectKey, got: %v", err)
+	}
+
+	restored, err := db.PatchDependencyDetails(id, []string{ETag(*patched)}, setLicense(current.License))
+	if err != nil {
+		t.Fatal("failed to restore license:", err)
+	}
+	if ETag(*restored) != etag {
+		t.Fatal("restored details should have the original ETag: ", cmp.Diff(*current, *restored))
+	}
+}
+
+func TestErrors(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	if _, err := db.GetDependencyDetailsByID("github.com/unknown/unknown"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound for unknown dependency, got: %v", err)
+	}
+	if err := db.DeleteDependencyWithDetails("github.com/unknown/unknown"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound when deleting unknown dependency, got: %v", err)
+	}
+	unknown := dependenciesloader.DependencyDetails{ProjectKey: dependenciesloader.ProjectKey{ID: "github.com/unknown/unknown"}}
+	if err := db.UpdateDependencyDetails(unknown); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound when updating unknown dependency, got: %v", err)
+	}
+	if err := db.AddNewDependencyDetails(dependenciesloader.DependencyDetails{}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("want ErrInvalidInput when adding dependency without projectKey, got: %v", err)
+	}
+
+	if err := db.SaveAlerts([]Alert{{ProjectKeyID: "github.com/cli/cli", Rule: "test"}}); err != nil {
+		t.Fatal("failed to save alert:", err)
+	}
+	alerts, err := db.GetAlerts(true)
+	if err != nil || len(alerts) == 0 {
+		t.Fatalf("failed to get alerts: %v", err)
+	}
+	if err := db.AcknowledgeAlert(alerts[0].ID); err != nil {
+		t.Fatal("failed to acknowledge alert:", err)
+	}
+	if err := db.AcknowledgeAlert(alerts[0].ID); !errors.Is(err, ErrConflict) {
+		t.Fatalf("want ErrConflict when acknowledging alert twice, got: %v", err)
+	}
+	if err := db.AcknowledgeAlert(-1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound for unknown alert, got: %v", err)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	const id = "github.com/briandowns/spinner"
+	upstream := getDetailedDependenciesMock(t, "dependencies_details_mock.json")[4]
+
+	for _, override := range []overrides.Override{
+		{ProjectKeyID: id, Field: "/license", Value: json.RawMessage(`"MIT"`), Reason: "LICENSE file is MIT", Author: "jane"},
+		{ProjectKeyID: id, Field: "/scorecard/checks/Code-Review/score", Value: json.RawMessage(`9`), Reason: "reviewed manually", Author: "jane"},
+	} {
+		if _, err := db.SetOverride(override); err != nil {
+			t.Fatal("failed to set override:", err)
+		}
+	}
+
+	// a refresh writes upstream values only
+	refreshed := upstream
+	refreshed.License = "BSD-3-Clause"
+	if err := db.UpdateDependencyDetails(refreshed); err != nil {
+		t.Fatal("failed to update dependency details:", err)
+	}
+
+	got, err := db.GetDependencyDetailsByID(id)
+	if err != nil {
+		t.Fatal("failed to get dependency details:", err)
+	}
+	if got.License != "MIT" || got.Scorecard.Checks[1].Score != 9 {
+		t.Fatalf("overrides were not applied, license: %s, Code-Review score: %d", got.License, got.Scorecard.Checks[1].Score)
+	}
+	want := []dependenciesloader.FieldOverride{
+		{Field: "/license", Value: json.RawMessage(`"MIT"`), Upstream: json.RawMessage(`"BSD-3-Clause"`), Reason: "LICENSE file is MIT", Author: "jane"},
+		{Field: "/scorecard/checks/Code-Review/score", Value: json.RawMessage(`9`), Upstream: json.RawMessage(`5`), Reason: "reviewed manually", Author: "jane"},
+	}
+	if diff := cmp.Diff(want, got.Overrides, cmpopts.IgnoreFields(dependenciesloader.FieldOverride{}, "CreatedAt")); diff != "" {
+		t.Fatalf("unexpected overrides: %s", diff)
+	}
+
+	stored, err := db.GetUpstreamDependencyDetailsByID(id)
+	if err != nil {
+		t.Fatal("failed to get upstream dependency details:", err)
+	}
+	if stored.License != "BSD-3-Clause" || stored.Overrides != nil {
+		t.Fatalf("upstream details should not be overridden, license: %s", stored.License)
+	}
+
+	invalid := []overrides.Override{
+		{ProjectKeyID: id, Field: "/purl", Value: json.RawMessage(`"pkg:golang/x"`)},
+		{ProjectKeyID: id, Field: "/scorecard/checks/Unknown/score", Value: json.RawMessage(`1`)},
+		{ProjectKeyID: id, Field: "/starsCount", Value: json.RawMessage(`"many"`)},
+		{ProjectKeyID: id, Field: "/license", Value: json.RawMessage(`null`)},
+	}
+	for _, override := range invalid {
+		if _, err := db.SetOverride(override); !errors.Is(err, ErrInvalidInput) {
+			t.Fatalf("want ErrInvalidInput for override of %s, got: %v", override.Field, err)
+		}
+	}
+	if _, err := db.SetOverride(overrides.Override{ProjectKeyID: "github.com/unknown/unknown", Field: "/license", Value: json.RawMessage(`"MIT"`)}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound for override of unknown dependency, got: %v", err)
+	}
+
+	if err := db.DeleteOverride(id, "/license"); err != nil {
+		t.Fatal("failed to delete override:", err)
+	}
+	if err := db.DeleteOverride(id, "/license"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound for deleted override, got: %v", err)
+	}
+	if got, err = db.GetDependencyDetailsByID(id); err != nil || got.License != "BSD-3-Clause" {
+		t.Fatalf("upstream license should be returned after deleting its override, got: %s, %v", got.License, err)
+	}
+
+	if err := db.UpdateDependencyDetails(upstream); err != nil {
+		t.Fatal("failed to restore dependency details:", err)
+	}
+}
+
+func TestGetDependencyDetailsByIDs(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	ids := []string{"github.com/cli/cli", "github.com/briandowns/spinner"}
+	got, err := db.GetDependencyDetailsByIDs(append(ids, "github.com/unknown/unknown"))
+	if err != nil {
+		t.Fatal("failed to get dependency details by ids:", err)
+	}
+	if len(got) != len(ids) {
+		t.Fatalf("want details of %d dependencies, got %d", len(ids), len(got))
+	}
+	for _, id := range ids {
+		want, err := db.GetDependencyDetailsByID(id)
+		if err != nil {
+			t.Fatal("failed to get dependency details by id:", err)
+		}
+		if diff := cmp.Diff(want, got[id]); diff != "" {
+			t.Fatalf("details of %s differ from the ones read by id (-want +got):\n%s", id, diff)
+		}
+	}
+	// the override of Code-Review set by TestOverrides is still stored
+	if len(got["github.com/briandowns/spinner"].Overrides) != 1 {
+		t.Fatalf("overrides were not applied: %+v", got["github.com/briandowns/spinner"].Overrides)
+	}
+}
+
+func TestFindDependencies(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	ids, err := db.GetProjectKeyIDs()
+	if err != nil {
+		t.Fatal("failed to get project key ids:", err)
+	}
+	idsOf := func(filter DependencyFilter) []string {
+		details, err := db.FindDependencies(filter)
+		if err != nil {
+			t.Fatal("failed to find dependencies:", err)
+		}
+		result := []string{}
+		for _, d := range details {
+			result = append(result, d.ProjectKey.ID)
+		}
+		return result
+	}
+
+	if diff := cmp.Diff(ids, idsOf(DependencyFilter{})); diff != "" {
+		t.Fatalf("empty filter doesn't match every dependency (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"github.com/cli/cli"}, idsOf(DependencyFilter{Search: "CLI/cli"})); diff != "" {
+		t.Fatalf("search matched wrong dependencies (-want +got):\n%s", diff)
+	}
+
+	// the module github.com/AlecAivazis/survey/v2 is linked to the project github.com/alecaivazis/survey
+	survey, err := db.GetVersionKey("github.com/AlecAivazis/survey/v2")
+	if err != nil {
+		t.Fatal("failed to get version key:", err)
+	}
+	advisories := []dependenciesloader.VersionAdvisories{{
+		VersionKey: survey,
+		Advisories: []dependenciesloader.Advisory{{AdvisoryKey: dependenciesloader.AdvisoryKey{ID: "GHSA-yyyy-yyyy-yyyy"}}},
+	}}
+	if err := db.LoadAdvisories(advisories, SourceDepsDev); err != nil {
+		t.Fatal("failed to load advisories:", err)
+	}
+	byID, err := db.GetAdvisoriesByIDs([]string{"github.com/alecaivazis/survey", "github.com/cli/cli"})
+	if err != nil {
+		t.Fatal("failed to get advisories by ids:", err)
+	}
+	if len(byID) != 1 || len(byID["github.com/alecaivazis/survey"]) != 1 {
+		t.Fatalf("want the advisory of the module keyed by its project, got: %+v", byID)
+	}
+	vulnerable, notVulnerable := true, false
+	wantVulnerable := []string{"github.com/alecaivazis/survey", "github.com/briandowns/spinner"}
+	if diff := cmp.Diff(wantVulnerable, idsOf(DependencyFilter{Vulnerable: &vulnerable})); diff != "" {
+		t.Fatalf("unexpected vulnerable dependencies (-want +got):\n%s", diff)
+	}
+	wantNotVulnerable := []string{"github.com/alecthomas/chroma", "github.com/aymerick/douceur", "github.com/charmbracelet/glamour", "github.com/cli/cli"}
+	if diff := cmp.Diff(wantNotVulnerable, idsOf(DependencyFilter{Vulnerable: &notVulnerable})); diff != "" {
+		t.Fatalf("unexpected dependencies without advisories (-want +got):\n%s", diff)
+	}
+	score := 100.0
+	if got := idsOf(DependencyFilter{MinScore: &score}); len(got) != 0 {
+		t.Fatalf("want no dependencies scored at least %v, got %v", score, got)
+	}
+}
+
 func TestDeleteDependencyWithDetails(t *testing.T) {
 	db := GetTestDatabase(t)
 
@@ -160,6 +743,97 @@ func TestDeleteDependencyWithDetails(t *testing.T) {
 	if len(got) != want {
 		t.Fatalf("got != want, want: %d, got: %d", want, len(got))
 	}
+
+	if overrides, err := db.GetOverrides("github.com/briandowns/spinner"); err != nil || len(overrides) != 0 {
+		t.Fatalf("overrides of a deleted dependency should be deleted, got: %v, %v", overrides, err)
+	}
+}
+
+func TestAddAfterDelete(t *testing.T) {
+	db, err := NewSQLiteDB(path.Join(t.TempDir(), "delete.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	defer db.CloseDbConnection()
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	details := getDetailedDependenciesMock(t, "dependencies_details_mock.json")[1]
+	node := dependenciesloader.Node{VersionKey: dependenciesloader.VersionKey{System: "GO", Name: "github.com/AlecAivazis/survey/v2", Version: "v2.2.14"}, Relation: "DIRECT"}
+	alert := Alert{ProjectKeyID: details.ProjectKey.ID, Rule: "overall-score-below-5", Score: 4}
+	if err := db.ApplyDependencyChange(DependencyChange{Name: node.VersionKey.Name, Node: &node, Details: &details, Alerts: []Alert{alert}}); err != nil {
+		t.Fatal("failed to apply change:", err)
+	}
+
+	if err := db.DeleteDependencyWithDetails(details.ProjectKey.ID); err != nil {
+		t.Fatal("failed to delete dependency details:", err)
+	}
+	if alerts, err := db.GetAlerts(false); err != nil || len(alerts) != 0 {
+		t.Fatalf("alerts of a deleted dependency should be deleted, got: %v, %v", alerts, err)
+	}
+	if _, err := db.GetProjectKeyIDOf(node.VersionKey.Name); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want the module unlinked from the deleted project, got: %v", err)
+	}
+
+	if err := db.AddNewDependencyDetails(details); err != nil {
+		t.Fatal("failed to add deleted dependency again:", err)
+	}
+	got, err := db.GetDependencyDetailsByID(details.ProjectKey.ID)
+	if err != nil {
+		t.Fatal("failed to get dependency details:", err)
+	}
+	// only one set of checks of the project, without the details of checks which aren't stored
+	want := details
+	want.Scorecard.Checks = nil
+	for _, check := range details.Scorecard.Checks {
+		check.Details = nil
+		want.Scorecard.Checks = append(want.Scorecard.Checks, check)
+	}
+	if !cmp.Equal(*got, want) {
+		t.Fatal("dependency added again should start clean: ", cmp.Diff(*got, want))
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	db := GetTestDatabase(t)
+
+	added, err := db.AddAPIKey("ci", "editor", "hash-of-ci")
+	if err != nil {
+		t.Fatal("failed to add api key:", err)
+	}
+	if _, err := db.AddAPIKey("copy", "admin", "hash-of-ci"); err == nil {
+		t.Fatal("keys with the same hash should not be added")
+	}
+
+	got, err := db.GetAPIKeyByHash("hash-of-ci")
+	if err != nil {
+		t.Fatal("failed to get api key by hash:", err)
+	}
+	if diff := cmp.Diff(added, got); diff != "" {
+		t.Fatalf("stored key differs from the added one (-want +got):\n%s", diff)
+	}
+
+	if err := db.RevokeAPIKey(added.ID); err != nil {
+		t.Fatal("failed to revoke api key:", err)
+	}
+	if _, err := db.GetAPIKeyByHash("hash-of-ci"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound for a revoked key, got %v", err)
+	}
+	if err := db.RevokeAPIKey(added.ID); !errors.Is(err, ErrConflict) {
+		t.Fatalf("want ErrConflict for a key revoked twice, got %v", err)
+	}
+	if err := db.RevokeAPIKey(added.ID + 100); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("want ErrNotFound for an unknown key, got %v", err)
+	}
+
+	keys, err := db.GetAPIKeys()
+	if err != nil {
+		t.Fatal("failed to get api keys:", err)
+	}
+	if len(keys) != 1 || keys[0].RevokedAt == "" {
+		t.Fatalf("want the revoked key listed, got %+v", keys)
+	}
 }
 
 func TestCleanupTestDatabase(t *testing.T) {
@@ -169,6 +843,18 @@ func TestCleanupTestDatabase(t *testing.T) {
 	}
 }
 
+func countRows(t *testing.T, db *SQLiteDB, tables ...string) map[string]int {
+	counts := map[string]int{}
+	for _, table := range tables {
+		var count int
+		if err := db.db.QueryRow(`SELECT COUNT(*) FROM "` + table + `"`).Scan(&count); err != nil {
+			t.Fatalf("failed to count rows of %s: %v", table, err)
+		}
+		counts[table] = count
+	}
+	return counts
+}
+
 func checkAllDependencies(t *testing.T, db *SQLiteDB, want int) {
 	dependencies, err := db.GetAllDependencies()
 	if err != nil {
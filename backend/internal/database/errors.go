@@ -0,0 +1,24 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the database are wrapped around these, so callers can tell them apart with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrPreconditionFailed is returned if a write is conditional on a version of a row which isn't stored anymore.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// notFound wraps ErrNotFound if err is sql.ErrNoRows, other errors are wrapped with the message.
+func notFound(err error, format string, args ...any) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: "+format, append([]any{ErrNotFound}, args...)...)
+	}
+	return fmt.Errorf("failed to get "+format+": %w", append(args, err)...)
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+// LoadDependencyEdges replaces stored edges of the dependency graph. Edges of deps.dev refer to nodes
+// by their index, they are stored by the names of the nodes instead.
+func (s *SQLiteDB) LoadDependencyEdges(dependencies dependenciesloader.Dependencies) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM "DependencyEdge"`); err != nil {
+		return fmt.Errorf("failed to delete DependencyEdge: %w", err)
+	}
+
+	for _, edge := range dependencies.Edges {
+		if edge.FromNode < 0 || edge.FromNode >= len(dependencies.Nodes) || edge.ToNode < 0 || edge.ToNode >= len(dependencies.Nodes) {
+			return fmt.Errorf("edge %d -> %d refers to a missing node", edge.FromNode, edge.ToNode)
+		}
+		_, err := tx.Exec(`
+			INSERT INTO "DependencyEdge" (fromName, toName, requirement) VALUES (?, ?, ?)
+			ON CONFLICT DO NOTHING`,
+			dependencies.Nodes[edge.FromNode].VersionKey.Name,
+			dependencies.Nodes[edge.ToNode].VersionKey.Name,
+			edge.Requirement,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert into DependencyEdge: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetDependencyGraph returns stored versions as graph nodes, sorted by name with the root (SELF) node first,
+// and the edges between them.
+func (s *SQLiteDB) GetDependencyGraph() (dependenciesloader.Dependencies, error) {
+	graph := dependenciesloader.Dependencies{Nodes: []dependenciesloader.Node{}, Edges: []dependenciesloader.Edge{}}
+
+	rows, err := s.db.Query(`
+        SELECT name, system, version, relation
+        FROM VersionKeys
+        ORDER BY relation = 'SELF' DESC, name
+    `)
+	if err != nil {
+		return graph, fmt.Errorf("failed to query VersionKeys: %w", err)
+	}
+	defer rows.Close()
+
+	indexes := map[string]int{}
+	for rows.Next() {
+		var node dependenciesloader.Node
+		var relation sql.NullString
+		if err := rows.Scan(&node.VersionKey.Name, &node.VersionKey.System, &node.VersionKey.Version, &relation); err != nil {
+			return graph, fmt.Errorf("failed to scan VersionKeys: %w", err)
+		}
+		node.Relation = relation.String
+		indexes[node.VersionKey.Name] = len(graph.Nodes)
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	if err := rows.Err(); err != nil {
+		return graph, fmt.Errorf("error iterating over VersionKeys: %w", err)
+	}
+	rows.Close()
+
+	edgeRows, err := s.db.Query(`SELECT fromName, toName, requirement FROM DependencyEdge ORDER BY fromName, toName`)
+	if err != nil {
+		return graph, fmt.Errorf("failed to query DependencyEdge: %w", err)
+	}
+	defer edgeRows.Close()
+
+	for edgeRows.Next() {
+		var from, to, requirement string
+		if err := edgeRows.Scan(&from, &to, &requirement); err != nil {
+			return graph, fmt.Errorf("failed to scan DependencyEdge: %w", err)
+		}
+		fromIndex, fromOk := indexes[from]
+		toIndex, toOk := indexes[to]
+		if !fromOk || !toOk {
+			continue
+		}
+		graph.Edges = append(graph.Edges, dependenciesloader.Edge{FromNode: fromIndex, ToNode: toIndex, Requirement: requirement})
+	}
+
+	return graph, edgeRows.Err()
+}
+
+// DependencyEdge is an edge of the dependency graph between versions given by their names.
+type DependencyEdge struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Requirement string `json:"requirement"`
+}
+
+// GetNodesByNames returns stored versions as graph nodes keyed by name, names which aren't stored are left out.
+func (s *SQLiteDB) GetNodesByNames(names []string) (map[string]dependenciesloader.Node, error) {
+	nodes := map[string]dependenciesloader.Node{}
+	if len(names) == 0 {
+		return nodes, nil
+	}
+
+	rows, err := s.db.Query(`
+        SELECT name, system, version, relation
+        FROM VersionKeys
+        WHERE name IN (`+placeholders(len(names))+`)`,
+		queryArgs(names)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query VersionKeys: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var node dependenciesloader.Node
+		var relation sql.NullString
+		if err := rows.Scan(&node.VersionKey.Name, &node.VersionKey.System, &node.VersionKey.Version, &relation); err != nil {
+			return nil, fmt.Errorf("failed to scan VersionKeys: %w", err)
+		}
+		node.Relation = relation.String
+		nodes[node.VersionKey.Name] = node
+	}
+
+	return nodes, rows.Err()
+}
+
+// GetDependencyEdgesByNames returns the edges from or to any of the named versions, ordered by the
+// names of their nodes.
+func (s *SQLiteDB) GetDependencyEdgesByNames(names []string) ([]DependencyEdge, error) {
+	edges := []DependencyEdge{}
+	if len(names) == 0 {
+		return edges, nil
+	}
+
+	in := placeholders(len(names))
+	rows, err := s.db.Query(`
+        SELECT fromName, toName, requirement
+        FROM DependencyEdge
+        WHERE fromName IN (`+in+`) OR toName IN (`+in+`)
+        ORDER BY fromName, toName`,
+		append(queryArgs(names), queryArgs(names)...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query DependencyEdge: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var edge DependencyEdge
+		if err := rows.Scan(&edge.From, &edge.To, &edge.Requirement); err != nil {
+			return nil, fmt.Errorf("failed to scan DependencyEdge: %w", err)
+		}
+		edges = append(edges, edge)
+	}
+
+	return edges, rows.Err()
+}
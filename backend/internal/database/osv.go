@@ -0,0 +1,122 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/osv"
+)
+
+// SourceOsv marks advisories matched against an imported OSV database.
+const SourceOsv = "osv"
+
+// ImportOsvVulnerabilities stores vulnerabilities read from an OSV dump. Entries already stored
+// are replaced only if the imported one was modified later.
+func (s *SQLiteDB) ImportOsvVulnerabilities(vulnerabilities []osv.Vulnerability) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, vulnerability := range vulnerabilities {
+		data, err := json.Marshal(vulnerability)
+		if err != nil {
+			return fmt.Errorf("failed to marshal OSV vulnerability %s: %w", vulnerability.ID, err)
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO "OsvVulnerability" (id, modified, data) VALUES (?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET modified = excluded.modified, data = excluded.data
+			WHERE excluded.modified > OsvVulnerability.modified`,
+			vulnerability.ID, vulnerability.Modified, string(data))
+		if err != nil {
+			return fmt.Errorf("failed to insert into OsvVulnerability: %w", err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			continue
+		}
+
+		if _, err := tx.Exec(`DELETE FROM "OsvAffectedPackage" WHERE vulnerabilityId = ?`, vulnerability.ID); err != nil {
+			return fmt.Errorf("failed to delete OsvAffectedPackage: %w", err)
+		}
+		for _, affected := range vulnerability.Affected {
+			_, err := tx.Exec(`
+				INSERT INTO "OsvAffectedPackage" (vulnerabilityId, ecosystem, name) VALUES (?, ?, ?)
+				ON CONFLICT DO NOTHING`,
+				vulnerability.ID, affected.Package.Ecosystem, affected.Package.Name)
+			if err != nil {
+				return fmt.Errorf("failed to insert into OsvAffectedPackage: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteDB) GetOsvVulnerabilities(ecosystem, name string) ([]osv.Vulnerability, error) {
+	query := `
+        SELECT v.data
+        FROM OsvVulnerability v
+        JOIN OsvAffectedPackage p ON p.vulnerabilityId = v.id
+        WHERE p.ecosystem = ? AND p.name = ?
+    `
+
+	rows, err := s.db.Query(query, ecosystem, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OSV vulnerabilities: %w", err)
+	}
+	defer rows.Close()
+
+	vulnerabilities := []osv.Vulnerability{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan OsvVulnerability: %w", err)
+		}
+		var vulnerability osv.Vulnerability
+		if err := json.Unmarshal([]byte(data), &vulnerability); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal OSV vulnerability: %w", err)
+		}
+		vulnerabilities = append(vulnerabilities, vulnerability)
+	}
+
+	return vulnerabilities, nil
+}
+
+// MatchOsvVulnerabilities matches stored versions against the imported OSV vulnerabilities
+// and stores the matches as advisories from SourceOsv. It returns the number of matches.
+func (s *SQLiteDB) MatchOsvVulnerabilities() (int, error) {
+	versionKeys, err := s.GetVersionKeys()
+	if err != nil {
+		return 0, err
+	}
+
+	matches := []dependenciesloader.VersionAdvisories{}
+	count := 0
+	for _, versionKey := range versionKeys {
+		ecosystem, ok := osv.Ecosystem(versionKey.System)
+		if !ok {
+			continue
+		}
+		vulnerabilities, err := s.GetOsvVulnerabilities(ecosystem, versionKey.Name)
+		if err != nil {
+			return 0, err
+		}
+		for _, match := range osv.Match(vulnerabilities, []dependenciesloader.VersionKey{versionKey}) {
+			count += len(match.Advisories)
+			matches = append(matches, match)
+		}
+	}
+
+	if err := s.LoadAdvisories(matches, SourceOsv); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/overrides"
+)
+
+// GetOverrides returns overrides of a project ordered by field, or overrides of all projects if projectKeyID is empty.
+func (s *SQLiteDB) GetOverrides(projectKeyID string) ([]overrides.Override, error) {
+	return getOverrides(s.db, projectKeyID)
+}
+
+func getOverrides(q querier, projectKeyID string) ([]overrides.Override, error) {
+	query := `
+        SELECT projectKeyId, field, value, reason, author, createdAt
+        FROM "Override"
+        WHERE (? = '' OR projectKeyId = ?)
+        ORDER BY projectKeyId, field
+    `
+	return queryOverrides(q, query, projectKeyID, projectKeyID)
+}
+
+// getOverridesByIDs returns overrides of the projects keyed by project key ID.
+func getOverridesByIDs(q querier, projectKeyIDs []string) (map[string][]overrides.Override, error) {
+	byID := map[string][]overrides.Override{}
+	if len(projectKeyIDs) == 0 {
+		return byID, nil
+	}
+
+	query := `
+        SELECT projectKeyId, field, value, reason, author, createdAt
+        FROM "Override"
+        WHERE projectKeyId IN (` + placeholders(len(projectKeyIDs)) + `)
+        ORDER BY projectKeyId, field
+    `
+	stored, err := queryOverrides(q, query, queryArgs(projectKeyIDs)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, override := range stored {
+		byID[override.ProjectKeyID] = append(byID[override.ProjectKeyID], override)
+	}
+	return byID, nil
+}
+
+func queryOverrides(q querier, query string, args ...any) ([]overrides.Override, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query overrides: %w", err)
+	}
+	defer rows.Close()
+
+	result := []overrides.Override{}
+	for rows.Next() {
+		var override overrides.Override
+		var value string
+		err := rows.Scan(
+			&override.ProjectKeyID,
+			&override.Field,
+			&value,
+			&override.Reason,
+			&override.Author,
+			&override.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Override: %w", err)
+		}
+		override.Value = json.RawMessage(value)
+		result = append(result, override)
+	}
+
+	return result, rows.Err()
+}
+
+// SetOverride stores an override of a field, replacing an earlier override of the same field.
+// The field has to exist in the stored details of the project and the value has to fit it.
+func (s *SQLiteDB) SetOverride(override overrides.Override) (overrides.Override, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return override, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	details, err := getDependencyDetails(tx, override.ProjectKeyID)
+	if err != nil {
+		return override, err
+	}
+	if err := overrides.Set(details, override); err != nil {
+		return override, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	var value bytes.Buffer
+	if err := json.Compact(&value, override.Value); err != nil {
+		return override, fmt.Errorf("%w: invalid value of %s: %v", ErrInvalidInput, override.Field, err)
+	}
+	override.Value = value.Bytes()
+	override.CreatedAt = now()
+
+	_, err = tx.Exec(`
+        INSERT INTO "Override" (projectKeyId, field, value, reason, author, createdAt)
+        VALUES (?, ?, ?, ?, ?, ?)
+        ON CONFLICT(projectKeyId, field) DO UPDATE SET
+            value = excluded.value,
+            reason = excluded.reason,
+            author = excluded.author,
+            createdAt = excluded.createdAt`,
+		override.ProjectKeyID,
+		override.Field,
+		value.String(),
+		override.Reason,
+		override.Author,
+		override.CreatedAt,
+	)
+	if err != nil {
+		return override, fmt.Errorf("failed to upsert Override: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return override, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return override, nil
+}
+
+// DeleteOverride removes an override, so the upstream value of the field is returned again.
+func (s *SQLiteDB) DeleteOverride(projectKeyID, field string) error {
+	result, err := s.db.Exec(`DELETE FROM "Override" WHERE projectKeyId = ? AND field = ?`, projectKeyID, field)
+	if err != nil {
+		return fmt.Errorf("failed to delete Override: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return fmt.Errorf("%w: override of %s of %s", ErrNotFound, field, projectKeyID)
+	}
+	return nil
+}
+
+// withOverrides applies stored overrides to details read from upstream tables.
+func withOverrides(q querier, details *dependenciesloader.DependencyDetails) (*dependenciesloader.DependencyDetails, error) {
+	stored, err := getOverrides(q, details.ProjectKey.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := overrides.Apply(details, stored); err != nil {
+		return nil, fmt.Errorf("failed to apply overrides of %s: %w", details.ProjectKey.ID, err)
+	}
+	return details, nil
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+// ETag identifies a version of stored dependency details, it changes whenever any of their fields does.
+func ETag(details dependenciesloader.DependencyDetails) string {
+	data, _ := json.Marshal(details)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// PatchDependencyDetails replaces the details of a project with the result of patch in a single transaction.
+// If etags are given, one of them has to be the ETag of the stored details, otherwise ErrPreconditionFailed
+// is returned and nothing is written. Errors of patch are returned as they are. The ETag is the one of the
+// details with overrides applied, while patch gets and returns the upstream details, overrides are kept.
+func (s *SQLiteDB) PatchDependencyDetails(
+	projectKeyID string,
+	etags []string,
+	patch func(dependenciesloader.DependencyDetails) (dependenciesloader.DependencyDetails, error),
+) (*dependenciesloader.DependencyDetails, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := getDependencyDetails(tx, projectKeyID)
+	if err != nil {
+		return nil, err
+	}
+	overridden, err := getDependencyDetails(tx, projectKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if overridden, err = withOverrides(tx, overridden); err != nil {
+		return nil, err
+	}
+	if etag := ETag(*overridden); len(etags) > 0 && !slices.Contains(etags, etag) {
+		return nil, fmt.Errorf("%w: details of %s were changed, their ETag is %s", ErrPreconditionFailed, projectKeyID, etag)
+	}
+
+	patched, err := patch(*current)
+	if err != nil {
+		return nil, err
+	}
+	if patched.ProjectKey.ID != projectKeyID {
+		return nil, fmt.Errorf("%w: projectKey of %s can't be changed", ErrInvalidInput, projectKeyID)
+	}
+	if err := upsertDependencyDetails(tx, patched); err != nil {
+		return nil, err
+	}
+
+	stored, err := getDependencyDetails(tx, projectKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if stored, err = withOverrides(tx, stored); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return stored, nil
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"fmt"
+)
+
+type WebhookDelivery struct {
+	ID         int    `json:"id"`
+	URL        string `json:"url"`
+	Event      string `json:"event"`
+	Dependency string `json:"dependency"`
+	Payload    string `json:"payload"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"statusCode"`
+	Error      string `json:"error"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+func (s *SQLiteDB) LogWebhookDelivery(delivery WebhookDelivery) error {
+	_, err := s.db.Exec(`
+		INSERT INTO "WebhookDelivery" (url, event, dependency, payload, attempt, statusCode, error, createdAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		delivery.URL,
+		delivery.Event,
+		delivery.Dependency,
+		delivery.Payload,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.Error,
+		now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert into WebhookDelivery: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookDeliveries returns the most recent delivery attempts, newest first.
+func (s *SQLiteDB) GetWebhookDeliveries(limit int) ([]WebhookDelivery, error) {
+	query := `
+        SELECT id, url, event, dependency, payload, attempt, statusCode, error, createdAt
+        FROM WebhookDelivery
+        ORDER BY id DESC
+        LIMIT ?
+    `
+
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+
+	deliveries := []WebhookDelivery{}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery WebhookDelivery
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.URL,
+			&delivery.Event,
+			&delivery.Dependency,
+			&delivery.Payload,
+			&delivery.Attempt,
+			&delivery.StatusCode,
+			&delivery.Error,
+			&delivery.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan WebhookDelivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,106 @@
+package dependenciesloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+const depsDevApiUrl = "https://api.deps.dev/v3"
+
+// FetchAdvisoriesForAllDependencies fetches known advisories of every node of the dependency graph.
+// Nodes whose advisories cannot be fetched are skipped, as well as nodes without a package system,
+// e.g. the root of an imported SBOM.
+func (l *Loader) FetchAdvisoriesForAllDependencies() []VersionAdvisories {
+	versionAdvisories := []VersionAdvisories{}
+	cache := map[string]Advisory{}
+	for _, node := range l.Dependencies.Nodes {
+		if node.VersionKey.System == "" {
+			continue
+		}
+		advisories, err := l.fetchAdvisories(node.VersionKey, cache)
+		if err != nil {
+			log.Printf("failed to fetch advisories for dependency: %s due to an error: %v", node.VersionKey.Name, err)
+			continue
+		}
+		versionAdvisories = append(versionAdvisories, VersionAdvisories{node.VersionKey, advisories})
+	}
+
+	return versionAdvisories
+}
+
+// FetchAdvisories fetches version details of versionKey and then details of every advisory affecting it.
+func (l *Loader) FetchAdvisories(versionKey VersionKey) ([]Advisory, error) {
+	return l.fetchAdvisories(versionKey, map[string]Advisory{})
+}
+
+func (l *Loader) fetchAdvisories(versionKey VersionKey, cache map[string]Advisory) ([]Advisory, error) {
+	versionDetails, err := l.FetchVersionDetails(versionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	advisories := []Advisory{}
+	for _, advisoryKey := range versionDetails.AdvisoryKeys {
+		advisory, ok := cache[advisoryKey.ID]
+		if !ok {
+			advisory, err = l.FetchAdvisory(advisoryKey.ID)
+			if err != nil {
+				return nil, err
+			}
+			cache[advisoryKey.ID] = advisory
+		}
+		advisories = append(advisories, advisory)
+	}
+
+	return advisories, nil
+}
+
+func (l *Loader) FetchVersionDetails(versionKey VersionKey) (VersionDetails, error) {
+	apiUrl := fmt.Sprintf("%s/systems/%s/packages/%s/versions/%s",
+		l.apiUrl,
+		url.PathEscape(versionKey.System),
+		url.PathEscape(versionKey.Name),
+		url.PathEscape(versionKey.Version),
+	)
+
+	var details VersionDetails
+	if err := getJSON(apiUrl, &details); err != nil {
+		return VersionDetails{}, err
+	}
+	return details, nil
+}
+
+func (l *Loader) FetchAdvisory(id string) (Advisory, error) {
+	var advisory Advisory
+	if err := getJSON(l.apiUrl+"/advisories/"+url.PathEscape(id), &advisory); err != nil {
+		return Advisory{}, err
+	}
+	return advisory, nil
+}
+
+func getJSON(apiUrl string, v any) error {
+	resp, err := http.Get(apiUrl)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received non-OK HTTP status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	return nil
+}
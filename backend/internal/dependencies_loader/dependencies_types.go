@@ -1,5 +1,7 @@
 package dependenciesloader
 
+import "encoding/json"
+
 type VersionKey struct {
 	System  string `json:"system"`
 	Name    string `json:"name"`
@@ -63,6 +65,7 @@ type Scorecard struct {
 
 type DependencyDetails struct {
 	ProjectKey      ProjectKey `json:"projectKey"`
+	Purl            string     `json:"purl,omitempty"`
 	OpenIssuesCount int        `json:"openIssuesCount"`
 	StarsCount      int        `json:"starsCount"`
 	ForksCount      int        `json:"forksCount"`
@@ -70,4 +73,41 @@ type DependencyDetails struct {
 	Description     string     `json:"description"`
 	Homepage        string     `json:"homepage"`
 	Scorecard       Scorecard  `json:"scorecard"`
+	// Overrides lists the fields whose values were corrected by hand, they aren't a part of deps.dev data.
+	Overrides []FieldOverride `json:"overrides,omitempty"`
+}
+
+// FieldOverride is a manually corrected field of DependencyDetails, Upstream is the value it replaces.
+type FieldOverride struct {
+	Field     string          `json:"field"`
+	Value     json.RawMessage `json:"value"`
+	Upstream  json.RawMessage `json:"upstream"`
+	Reason    string          `json:"reason"`
+	Author    string          `json:"author"`
+	CreatedAt string          `json:"createdAt"`
+}
+
+type AdvisoryKey struct {
+	ID string `json:"id"`
+}
+
+type VersionDetails struct {
+	VersionKey   VersionKey    `json:"versionKey"`
+	PublishedAt  string        `json:"publishedAt"`
+	Licenses     []string      `json:"licenses"`
+	AdvisoryKeys []AdvisoryKey `json:"advisoryKeys"`
+}
+
+type Advisory struct {
+	AdvisoryKey AdvisoryKey `json:"advisoryKey"`
+	URL         string      `json:"url"`
+	Title       string      `json:"title"`
+	Aliases     []string    `json:"aliases"`
+	CVSS3Score  float64     `json:"cvss3Score"`
+	CVSS3Vector string      `json:"cvss3Vector"`
+}
+
+type VersionAdvisories struct {
+	VersionKey VersionKey `json:"versionKey"`
+	Advisories []Advisory `json:"advisories"`
 }
@@ -11,14 +11,51 @@ import (
 
 type Loader struct {
 	repositoryUrl string
+	apiUrl        string
+	source        Source
 	Dependencies  Dependencies
 }
 
+// Source provides the dependency graph of the tracked project when deps.dev can't resolve it,
+// e.g. a graph read from an SBOM.
+type Source interface {
+	Dependencies() (Dependencies, error)
+}
+
+// StaticSource always provides the same graph.
+type StaticSource Dependencies
+
+func (s StaticSource) Dependencies() (Dependencies, error) {
+	return Dependencies(s), nil
+}
+
 func NewDependenciesLoader(repositoryUrl string) *Loader {
-	return &Loader{repositoryUrl: repositoryUrl}
+	return &Loader{repositoryUrl: repositoryUrl, apiUrl: depsDevApiUrl}
 }
 
+// SetApiUrl makes the loader fetch details and advisories from another deps.dev v3 API,
+// e.g. a mirror or a test server.
+func (l *Loader) SetApiUrl(apiUrl string) {
+	l.apiUrl = apiUrl
+}
+
+// SetSource makes the loader take the dependency graph from the source instead of
+// the :dependencies endpoint of deps.dev. Details are still fetched from deps.dev.
+func (l *Loader) SetSource(source Source) {
+	l.source = source
+}
+
+// FetchDepsDevDependencies fetches the dependency graph from deps.dev, or from the source if set.
 func (l *Loader) FetchDepsDevDependencies() error {
+	if l.source != nil {
+		dependencies, err := l.source.Dependencies()
+		if err != nil {
+			return fmt.Errorf("failed to read dependencies from source: %w", err)
+		}
+		l.Dependencies = dependencies
+		return nil
+	}
+
 	resp, err := http.Get(l.repositoryUrl)
 	if err != nil {
 		return fmt.Errorf("failed to make request: %w", err)
@@ -41,19 +78,36 @@ func (l *Loader) FetchDepsDevDependencies() error {
 	return nil
 }
 
-func (l *Loader) FetchDetailsForAllDependencies() []DependencyDetails {
+// FetchDetailsForAllDependencies fetches details of the projects of all nodes from deps.dev. Besides the
+// details it returns the ID of the project of every node name, projects are often named differently
+// than the packages, e.g. github.com/alecaivazis/survey for the module github.com/AlecAivazis/survey/v2.
+func (l *Loader) FetchDetailsForAllDependencies() ([]DependencyDetails, map[string]string) {
 	detailedDependencies := []DependencyDetails{}
+	projectKeyIDs := map[string]string{}
+	fetched := map[string]bool{}
 	for _, dependency := range l.Dependencies.Nodes {
-		url := "https://api.deps.dev/v3/projects/" + strings.ReplaceAll(dependency.VersionKey.Name, "/", "%2F")
-		dependencyDetails, err := l.FetchDependencyDetails(url)
+		if _, ok := projectKeyIDs[dependency.VersionKey.Name]; ok {
+			continue
+		}
+		dependencyDetails, err := l.FetchProjectDetails(dependency.VersionKey.Name)
 		if err != nil {
 			log.Printf("failed to fetch details for dependency: %s due to an error: %v", dependency.VersionKey.Name, err)
 			continue
 		}
+		projectKeyIDs[dependency.VersionKey.Name] = dependencyDetails.ProjectKey.ID
+		if fetched[dependencyDetails.ProjectKey.ID] {
+			continue
+		}
+		fetched[dependencyDetails.ProjectKey.ID] = true
 		detailedDependencies = append(detailedDependencies, dependencyDetails)
 	}
 
-	return detailedDependencies
+	return detailedDependencies, projectKeyIDs
+}
+
+// FetchProjectDetails fetches details of the project of a package from deps.dev.
+func (l *Loader) FetchProjectDetails(name string) (DependencyDetails, error) {
+	return l.FetchDependencyDetails(l.apiUrl + "/projects/" + strings.ReplaceAll(name, "/", "%2F"))
 }
 
 func (l *Loader) FetchDependencyDetails(apiUrl string) (DependencyDetails, error) {
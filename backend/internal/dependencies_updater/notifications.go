@@ -0,0 +1,56 @@
+package dependenciesupdater
+
+import (
+	"slices"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/webhooks"
+)
+
+func eventsFor(change PlannedChange) []webhooks.Event {
+	events := []webhooks.Event{}
+
+	if slices.Contains(change.Reasons, ReasonNewDependency) {
+		events = append(events, webhooks.Event{
+			Type:       webhooks.EventDependencyAdded,
+			Dependency: change.Name,
+			Purl:       change.Purl,
+			Version:    change.NewVersion,
+			License:    change.NewLicense,
+		})
+	} else if change.NewVersion != "" && change.NewVersion != change.CurrentVersion {
+		events = append(events, webhooks.Event{
+			Type:            webhooks.EventVersionChanged,
+			Dependency:      change.Name,
+			Purl:            change.Purl,
+			PreviousVersion: change.CurrentVersion,
+			Version:         change.NewVersion,
+		})
+	}
+
+	if !change.fetched {
+		return events
+	}
+
+	scoreEvent := webhooks.Event{
+		Type:         webhooks.EventScoreBelowThreshold,
+		Dependency:   change.Name,
+		Purl:         change.Purl,
+		OverallScore: &change.NewOverallScore,
+	}
+	if change.hasCurrentDetails {
+		scoreEvent.PreviousOverallScore = &change.CurrentOverallScore
+	}
+	events = append(events, scoreEvent)
+
+	if change.hasCurrentDetails && change.CurrentLicense != change.NewLicense {
+		events = append(events, webhooks.Event{
+			Type:            webhooks.EventLicenseChanged,
+			Dependency:      change.Name,
+			Purl:            change.Purl,
+			PreviousLicense: change.CurrentLicense,
+			License:         change.NewLicense,
+		})
+	}
+
+	return events
+}
@@ -0,0 +1,66 @@
+package dependenciesupdater
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/webhooks"
+)
+
+func TestEventsFor(t *testing.T) {
+	score := func(score float64) *float64 { return &score }
+	const purl = "pkg:golang/github.com/briandowns/spinner@v1.12.0"
+	tests := []struct {
+		name   string
+		change PlannedChange
+		want   []webhooks.Event
+	}{
+		{
+			name:   "new dependency without a project",
+			change: PlannedChange{Name: "example.com/internal", Reasons: []string{ReasonNewDependency}, NewVersion: "v1.0.0"},
+			want:   []webhooks.Event{{Type: webhooks.EventDependencyAdded, Dependency: "example.com/internal", Version: "v1.0.0"}},
+		},
+		{
+			name: "new dependency",
+			change: PlannedChange{
+				Name: "github.com/briandowns/spinner", Purl: purl, Reasons: []string{ReasonNewDependency},
+				NewVersion: "v1.12.0", NewLicense: "Apache-2.0", NewOverallScore: 4.2, fetched: true,
+			},
+			want: []webhooks.Event{
+				{Type: webhooks.EventDependencyAdded, Dependency: "github.com/briandowns/spinner", Purl: purl, Version: "v1.12.0", License: "Apache-2.0"},
+				{Type: webhooks.EventScoreBelowThreshold, Dependency: "github.com/briandowns/spinner", Purl: purl, OverallScore: score(4.2)},
+			},
+		},
+		{
+			name: "version and license change",
+			change: PlannedChange{
+				Name: "github.com/briandowns/spinner", Purl: purl, Reasons: []string{ReasonVersionChange},
+				CurrentVersion: "v1.11.1", NewVersion: "v1.12.0", CurrentLicense: "MIT", NewLicense: "Apache-2.0",
+				CurrentOverallScore: 6, NewOverallScore: 4.2, fetched: true, hasCurrentDetails: true,
+			},
+			want: []webhooks.Event{
+				{Type: webhooks.EventVersionChanged, Dependency: "github.com/briandowns/spinner", Purl: purl, PreviousVersion: "v1.11.1", Version: "v1.12.0"},
+				{Type: webhooks.EventScoreBelowThreshold, Dependency: "github.com/briandowns/spinner", Purl: purl, OverallScore: score(4.2), PreviousOverallScore: score(6)},
+				{Type: webhooks.EventLicenseChanged, Dependency: "github.com/briandowns/spinner", Purl: purl, PreviousLicense: "MIT", License: "Apache-2.0"},
+			},
+		},
+		{
+			name: "stale scorecard",
+			change: PlannedChange{
+				Name: "github.com/briandowns/spinner", Reasons: []string{ReasonStaleScorecard},
+				CurrentVersion: "v1.11.1", NewVersion: "v1.11.1", CurrentLicense: "MIT", NewLicense: "MIT",
+				CurrentOverallScore: 6, NewOverallScore: 6, fetched: true, hasCurrentDetails: true,
+			},
+			want: []webhooks.Event{
+				{Type: webhooks.EventScoreBelowThreshold, Dependency: "github.com/briandowns/spinner", OverallScore: score(6), PreviousOverallScore: score(6)},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, eventsFor(test.change)); diff != "" {
+				t.Fatalf("unexpected events (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package dependenciesupdater
+
+import (
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+const (
+	ReasonVersionChange  = "version-change"
+	ReasonStaleScorecard = "stale-scorecard"
+	ReasonNewDependency  = "new-dependency"
+)
+
+const (
+	CheckAdded   = "added"
+	CheckRemoved = "removed"
+	CheckChanged = "changed"
+)
+
+// PlannedChange describes what an update would write to the database for a single dependency.
+type PlannedChange struct {
+	Name                string           `json:"name"`
+	Purl                string           `json:"purl,omitempty"`
+	Reasons             []string         `json:"reasons"`
+	CurrentVersion      string           `json:"currentVersion"`
+	NewVersion          string           `json:"newVersion"`
+	Class               string           `json:"class"`
+	CurrentOverallScore float64          `json:"currentOverallScore"`
+	NewOverallScore     float64          `json:"newOverallScore"`
+	CurrentLicense      string           `json:"currentLicense"`
+	NewLicense          string           `json:"newLicense"`
+	Checks              []CheckChange    `json:"checks"`
+	Alerts              []database.Alert `json:"alerts"`
+
+	node              dependenciesloader.Node
+	details           dependenciesloader.DependencyDetails
+	advisories        *dependenciesloader.VersionAdvisories
+	fetched           bool
+	hasCurrentDetails bool
+}
+
+type CheckChange struct {
+	Name         string `json:"name"`
+	Change       string `json:"change"`
+	CurrentScore int    `json:"currentScore"`
+	NewScore     int    `json:"newScore"`
+}
+
+// FilterByClass keeps changes whose version change belongs to one of the given classes.
+// Changes made only because of a stale Scorecard have no version change and are classified as versions.ClassNone.
+func FilterByClass(plan []PlannedChange, classes []string) []PlannedChange {
+	filtered := []PlannedChange{}
+	for _, change := range plan {
+		for _, class := range classes {
+			if change.Class == class {
+				filtered = append(filtered, change)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func diffChecks(current, updated []dependenciesloader.Check) []CheckChange {
+	changes := []CheckChange{}
+
+	currentScores := map[string]int{}
+	for _, check := range current {
+		currentScores[check.Name] = check.Score
+	}
+
+	for _, check := range updated {
+		currentScore, ok := currentScores[check.Name]
+		switch {
+		case !ok:
+			changes = append(changes, CheckChange{Name: check.Name, Change: CheckAdded, NewScore: check.Score})
+		case currentScore != check.Score:
+			changes = append(changes, CheckChange{Name: check.Name, Change: CheckChanged, CurrentScore: currentScore, NewScore: check.Score})
+		}
+		delete(currentScores, check.Name)
+	}
+
+	for _, check := range current {
+		if _, ok := currentScores[check.Name]; ok {
+			changes = append(changes, CheckChange{Name: check.Name, Change: CheckRemoved, CurrentScore: check.Score})
+		}
+	}
+
+	return changes
+}
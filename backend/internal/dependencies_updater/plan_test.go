@@ -0,0 +1,90 @@
+package dependenciesupdater
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/versions"
+)
+
+func TestDiffChecks(t *testing.T) {
+	check := func(name string, score int) dependenciesloader.Check {
+		return dependenciesloader.Check{Name: name, Score: score}
+	}
+	tests := []struct {
+		name             string
+		current, updated []dependenciesloader.Check
+		want             []CheckChange
+	}{
+		{
+			name:    "unchanged",
+			current: []dependenciesloader.Check{check("Maintained", 10), check("Fuzzing", 0)},
+			updated: []dependenciesloader.Check{check("Fuzzing", 0), check("Maintained", 10)},
+			want:    []CheckChange{},
+		},
+		{
+			name:    "first scorecard",
+			updated: []dependenciesloader.Check{check("Maintained", 10)},
+			want:    []CheckChange{{Name: "Maintained", Change: CheckAdded, NewScore: 10}},
+		},
+		{
+			name:    "changed",
+			current: []dependenciesloader.Check{check("Maintained", 10), check("Code-Review", 8)},
+			updated: []dependenciesloader.Check{check("Maintained", 0), check("Code-Review", 8)},
+			want:    []CheckChange{{Name: "Maintained", Change: CheckChanged, CurrentScore: 10, NewScore: 0}},
+		},
+		{
+			name:    "added and removed",
+			current: []dependenciesloader.Check{check("Maintained", 10), check("Fuzzing", -1)},
+			updated: []dependenciesloader.Check{check("Maintained", 10), check("SAST", 4)},
+			want: []CheckChange{
+				{Name: "SAST", Change: CheckAdded, NewScore: 4},
+				{Name: "Fuzzing", Change: CheckRemoved, CurrentScore: -1},
+			},
+		},
+		{
+			name:    "no scorecard anymore",
+			current: []dependenciesloader.Check{check("Maintained", 10)},
+			want:    []CheckChange{{Name: "Maintained", Change: CheckRemoved, CurrentScore: 10}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, diffChecks(test.current, test.updated)); diff != "" {
+				t.Fatalf("unexpected changes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFilterByClass(t *testing.T) {
+	plan := []PlannedChange{
+		{Name: "major", Class: versions.ClassMajor},
+		{Name: "patch", Class: versions.ClassPatch},
+		{Name: "stale", Class: versions.ClassNone},
+		{Name: "new", Class: versions.ClassNew},
+	}
+	tests := []struct {
+		name    string
+		classes []string
+		want    []string
+	}{
+		{"single class", []string{versions.ClassPatch}, []string{"patch"}},
+		{"several classes keep the order of the plan", []string{versions.ClassNew, versions.ClassMajor}, []string{"major", "new"}},
+		{"stale scorecards", []string{versions.ClassNone}, []string{"stale"}},
+		{"no class", []string{}, []string{}},
+		{"unknown class", []string{"huge"}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := []string{}
+			for _, change := range FilterByClass(plan, test.classes) {
+				got = append(got, change.Name)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Fatalf("unexpected changes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
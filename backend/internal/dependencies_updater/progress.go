@@ -0,0 +1,25 @@
+package dependenciesupdater
+
+// Stages of an update reported to a Progress.
+const (
+	StagePlan  = "plan"
+	StageApply = "apply"
+)
+
+// Progress is called after each dependency of a stage is done, done of total dependencies of the
+// stage are done so far.
+type Progress func(stage, name string, done, total int)
+
+// WithProgress returns a copy of the updater reporting its progress to p. The copy shares the loader,
+// database and notifier of u, so it's meant for a single update, e.g. one streamed to a client.
+func (u *Updater) WithProgress(p Progress) *Updater {
+	updater := *u
+	updater.progress = p
+	return &updater
+}
+
+func (u *Updater) report(stage, name string, done, total int) {
+	if u.progress != nil {
+		u.progress(stage, name, done, total)
+	}
+}
@@ -2,72 +2,310 @@ package dependenciesupdater
 
 import (
 	"fmt"
-	"strings"
+	"log"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/alerts"
 	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
 	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/purl"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/versions"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/webhooks"
 )
 
 type Updater struct {
-	loader *dependenciesloader.Loader
-	db     *database.SQLiteDB
+	loader          *dependenciesloader.Loader
+	db              *database.SQLiteDB
+	scorecardMaxAge time.Duration
+	notifier        *webhooks.Dispatcher
+	alerts          *alerts.Evaluator
+	progress        Progress
+	// running serializes updates of the updater and its copies, which share the graph of the loader.
+	running *sync.Mutex
 }
 
-func NewDependenciesUpdater(loader *dependenciesloader.Loader, db *database.SQLiteDB) *Updater {
-	return &Updater{loader, db}
+// NewDependenciesUpdater creates an Updater which, besides reacting to version changes,
+// refreshes details of dependencies whose Scorecard is older than scorecardMaxAge.
+// A zero scorecardMaxAge disables the staleness based refresh.
+// Applied changes are reported to the notifier and newly fetched Scorecards are checked
+// against the alert rules of the evaluator; both may be nil.
+func NewDependenciesUpdater(
+	loader *dependenciesloader.Loader,
+	db *database.SQLiteDB,
+	scorecardMaxAge time.Duration,
+	notifier *webhooks.Dispatcher,
+	evaluator *alerts.Evaluator,
+) *Updater {
+	return &Updater{loader: loader, db: db, scorecardMaxAge: scorecardMaxAge, notifier: notifier, alerts: evaluator, running: &sync.Mutex{}}
 }
 
 func (u *Updater) UpdateDependencies() ([]string, error) {
-	dependenciesToUpdate, err := u.FindDependenciesToUpdate()
+	_, updatedDependencies, err := u.Refresh(false, nil)
+	return updatedDependencies, err
+}
+
+// Refresh updates dependencies from deps.dev with only the changes of the given classes, all changes
+// if there are none. A dry run returns the planned changes without applying them, otherwise the names
+// of the updated dependencies are returned. Refreshes and imports of the updater and its copies run one
+// at a time, so a plan is always applied against the graph it was made for.
+func (u *Updater) Refresh(dryRun bool, classes []string) ([]PlannedChange, []string, error) {
+	u.running.Lock()
+	defer u.running.Unlock()
+
+	plan, err := u.PlanUpdates()
+	if err != nil {
+		return nil, []string{}, fmt.Errorf("update dependencies failed due to an error: %w", err)
+	}
+	if len(classes) > 0 {
+		plan = FilterByClass(plan, classes)
+	}
+	if dryRun {
+		return plan, nil, nil
+	}
+	updatedDependencies, err := u.ApplyUpdates(plan)
+	return nil, updatedDependencies, err
+}
+
+// ImportDependencies applies the graph, e.g. one read from an uploaded SBOM, like an update from deps.dev:
+// new nodes are added with details fetched from deps.dev, changed versions are updated and the stored
+// edges are replaced. The graph is used for this update only, the loader keeps its source.
+func (u *Updater) ImportDependencies(dependencies dependenciesloader.Dependencies) ([]string, error) {
+	u.running.Lock()
+	defer u.running.Unlock()
+
+	plan, err := u.planUpdates(dependencies)
 	if err != nil {
 		return []string{}, fmt.Errorf("update dependencies failed due to an error: %w", err)
 	}
+	return u.applyUpdates(plan, dependencies)
+}
 
-	for _, dependency := range dependenciesToUpdate {
-		url := "https://api.deps.dev/v3/projects/" + strings.ReplaceAll(dependency, "/", "%2F")
-		newDetails, err := u.loader.FetchDependencyDetails(url)
-		if err != nil {
+// ApplyUpdates writes changes returned by PlanUpdates to the database.
+// The plan may be filtered before, e.g. with FilterByClass, to apply only some of the updates.
+// Unlike Refresh, PlanUpdates and ApplyUpdates aren't serialized with other updates, e.g. of the API,
+// they are meant for a single update of the process like the update command.
+func (u *Updater) ApplyUpdates(plan []PlannedChange) ([]string, error) {
+	return u.applyUpdates(plan, u.loader.Dependencies)
+}
+
+// applyUpdates writes the plan and replaces the stored edges with the ones of the graph it was planned for.
+func (u *Updater) applyUpdates(plan []PlannedChange, dependencies dependenciesloader.Dependencies) ([]string, error) {
+	updatedDependencies := []string{}
+	events := []webhooks.Event{}
+	for i, change := range plan {
+		if err := u.applyChange(change); err != nil {
+			u.notifier.Notify(events...)
 			return []string{}, fmt.Errorf("update dependencies failed due to an error: %w", err)
 		}
-		if err = u.db.UpdateDependencyDetails(newDetails); err != nil {
+		updatedDependencies = append(updatedDependencies, change.Name)
+		events = append(events, eventsFor(change)...)
+		u.report(StageApply, change.Name, i+1, len(plan))
+	}
+	u.notifier.Notify(events...)
+
+	if len(dependencies.Nodes) > 0 {
+		if err := u.db.LoadDependencyEdges(dependencies); err != nil {
 			return []string{}, fmt.Errorf("update dependencies failed due to an error: %w", err)
 		}
 	}
 
-	for _, node := range u.loader.Dependencies.Nodes {
-		if err := u.db.UpdateVersionKeys(node.VersionKey.Name, node.VersionKey.Version); err != nil {
-			return []string{}, fmt.Errorf("update dependencies failed due to an error: %w", err)
+	if len(plan) > 0 {
+		if _, err := u.db.MatchOsvVulnerabilities(); err != nil {
+			log.Printf("failed to match imported OSV vulnerabilities due to an error: %v", err)
 		}
 	}
 
-	return dependenciesToUpdate, nil
+	return updatedDependencies, nil
 }
 
-func (u *Updater) FindDependenciesToUpdate() ([]string, error) {
-	dependenciesToUpdate := []string{}
+// applyChange writes the change together with its alerts in a single transaction.
+func (u *Updater) applyChange(change PlannedChange) error {
+	dbChange := database.DependencyChange{Name: change.Name, Advisories: change.advisories, Alerts: change.Alerts}
+	if slices.Contains(change.Reasons, ReasonNewDependency) {
+		dbChange.Node = &change.node
+	} else if change.NewVersion != "" && change.NewVersion != change.CurrentVersion {
+		dbChange.Version = change.NewVersion
+	}
+	if change.fetched {
+		dbChange.Details = &change.details
+	}
+	return u.db.ApplyDependencyChange(dbChange)
+}
+
+// PlanUpdates fetches the current dependency graph and details from deps.dev and compares them
+// with the database without writing anything. UpdateDependencies applies the returned plan.
+func (u *Updater) PlanUpdates() ([]PlannedChange, error) {
+	if err := u.loader.FetchDepsDevDependencies(); err != nil {
+		return nil, err
+	}
+	return u.planUpdates(u.loader.Dependencies)
+}
+
+// planUpdates compares the graph and details fetched from deps.dev for its nodes with the database.
+func (u *Updater) planUpdates(dependencies dependenciesloader.Dependencies) ([]PlannedChange, error) {
 	dbDependenciesVersions, err := u.db.GetVersionKeys()
 	if err != nil {
-		return []string{}, err
+		return nil, err
 	}
-	err = u.loader.FetchDepsDevDependencies()
+
+	dependenciesToUpdate := FindDependenciesToUpdate(dbDependenciesVersions, dependencies)
+
+	staleDependencies, err := u.FindStaleDependencies()
 	if err != nil {
-		return []string{}, err
+		return nil, err
 	}
 
+	reasons := map[string][]string{}
+	for _, dependency := range dependenciesToUpdate {
+		reasons[dependency] = append(reasons[dependency], ReasonVersionChange)
+	}
+	for _, dependency := range staleDependencies {
+		if _, ok := reasons[dependency]; !ok {
+			dependenciesToUpdate = append(dependenciesToUpdate, dependency)
+		}
+		reasons[dependency] = append(reasons[dependency], ReasonStaleScorecard)
+	}
+	for _, node := range dependencies.Nodes {
+		if _, ok := reasons[node.VersionKey.Name]; ok {
+			// another version of a package is a node of its own, but only the first one is stored
+			continue
+		}
+		if versionOf(node.VersionKey.Name, dbDependenciesVersions) == "" {
+			dependenciesToUpdate = append(dependenciesToUpdate, node.VersionKey.Name)
+			reasons[node.VersionKey.Name] = []string{ReasonNewDependency}
+		}
+	}
+
+	plan := []PlannedChange{}
+	for i, dependency := range dependenciesToUpdate {
+		node := nodeOf(dependency, dependencies)
+		newVersionKey := node.VersionKey
+		currentVersion := versionOf(dependency, dbDependenciesVersions)
+		change := PlannedChange{
+			Name:           dependency,
+			Reasons:        reasons[dependency],
+			CurrentVersion: currentVersion,
+			NewVersion:     newVersionKey.Version,
+			Class:          versions.Classify(newVersionKey.System, currentVersion, newVersionKey.Version),
+			Checks:         []CheckChange{},
+			Alerts:         []database.Alert{},
+			node:           node,
+		}
+		if slices.Equal(change.Reasons, []string{ReasonStaleScorecard}) {
+			// the stored version of a stale Scorecard may be missing from the graph, it's kept all the same
+			change.NewVersion = currentVersion
+			change.Class = versions.ClassNone
+		}
+		if newVersionKey.System != "" {
+			change.Purl = purl.FromVersionKey(newVersionKey)
+		}
+
+		if newVersionKey.Version != "" && newVersionKey.Version != currentVersion {
+			if advisories, err := u.loader.FetchAdvisories(newVersionKey); err == nil {
+				change.advisories = &dependenciesloader.VersionAdvisories{VersionKey: newVersionKey, Advisories: advisories}
+			} else {
+				log.Printf("failed to fetch advisories for dependency: %s due to an error: %v", dependency, err)
+			}
+		}
+
+		newDetails, err := u.loader.FetchProjectDetails(dependency)
+		if err != nil {
+			if currentVersion != "" {
+				return nil, err
+			}
+			// Same as on startup, dependencies without a deps.dev project are stored without details.
+			log.Printf("failed to fetch details for dependency: %s due to an error: %v", dependency, err)
+			plan = append(plan, change)
+			u.report(StagePlan, dependency, i+1, len(dependenciesToUpdate))
+			continue
+		}
+		change.NewOverallScore = newDetails.Scorecard.OverallScore
+		change.NewLicense = newDetails.License
+		change.details = newDetails
+		change.fetched = true
+
+		// compared to upstream details, manual overrides would be reported as changes on every refresh
+		var currentScorecard *dependenciesloader.Scorecard
+		if currentDetails, err := u.db.GetUpstreamDependencyDetailsByID(newDetails.ProjectKey.ID); err == nil {
+			change.CurrentOverallScore = currentDetails.Scorecard.OverallScore
+			change.CurrentLicense = currentDetails.License
+			change.hasCurrentDetails = true
+			currentScorecard = &currentDetails.Scorecard
+		}
+		var currentChecks []dependenciesloader.Check
+		if currentScorecard != nil {
+			currentChecks = currentScorecard.Checks
+		}
+		change.Checks = diffChecks(currentChecks, newDetails.Scorecard.Checks)
+		change.Alerts = u.alerts.Evaluate(newDetails.ProjectKey.ID, currentScorecard, newDetails.Scorecard)
+
+		plan = append(plan, change)
+		u.report(StagePlan, dependency, i+1, len(dependenciesToUpdate))
+	}
+
+	return plan, nil
+}
+
+// FindDependenciesToUpdate returns stored dependencies whose version differs from the one in the graph.
+func FindDependenciesToUpdate(dbDependenciesVersions []dependenciesloader.VersionKey, dependencies dependenciesloader.Dependencies) []string {
+	dependenciesToUpdate := []string{}
 	for _, dbDependency := range dbDependenciesVersions {
-		if u.checkVersion(dbDependency) {
+		if checkVersion(dbDependency, dependencies) {
 			dependenciesToUpdate = append(dependenciesToUpdate, dbDependency.Name)
 		}
 	}
+	return dependenciesToUpdate
+}
 
-	return dependenciesToUpdate, err
+// FindStaleDependencies returns dependencies whose Scorecard should be re-fetched
+// regardless of a version change.
+func (u *Updater) FindStaleDependencies() ([]string, error) {
+	if u.scorecardMaxAge <= 0 {
+		return []string{}, nil
+	}
+	return u.db.GetStaleScorecards(u.scorecardMaxAge)
 }
 
-func (u *Updater) checkVersion(dbDependency dependenciesloader.VersionKey) bool {
-	for _, node := range u.loader.Dependencies.Nodes {
+func checkVersion(dbDependency dependenciesloader.VersionKey, dependencies dependenciesloader.Dependencies) bool {
+	for _, node := range dependencies.Nodes {
 		if dbDependency.Name == node.VersionKey.Name {
-			return dbDependency.Version != node.VersionKey.Version
+			return versionChanged(node.VersionKey.System, dbDependency.Version, node.VersionKey.Version)
 		}
 	}
 	return false
 }
+
+// versionChanged compares versions by their precedence, so that e.g. a differing +incompatible
+// suffix is not reported as a change. Unparsable versions are compared as plain strings.
+func versionChanged(system, current, latest string) bool {
+	currentVersion, err := versions.Parse(system, current)
+	if err != nil {
+		return current != latest
+	}
+	latestVersion, err := versions.Parse(system, latest)
+	if err != nil {
+		return current != latest
+	}
+	return currentVersion.Compare(latestVersion) != 0
+}
+
+// nodeOf returns the first node of the name, the one which is stored.
+func nodeOf(name string, dependencies dependenciesloader.Dependencies) dependenciesloader.Node {
+	for _, node := range dependencies.Nodes {
+		if node.VersionKey.Name == name {
+			return node
+		}
+	}
+	return dependenciesloader.Node{}
+}
+
+func versionOf(name string, versionKeys []dependenciesloader.VersionKey) string {
+	for _, versionKey := range versionKeys {
+		if versionKey.Name == name {
+			return versionKey.Version
+		}
+	}
+	return ""
+}
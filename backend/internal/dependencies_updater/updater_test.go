@@ -0,0 +1,333 @@
+package dependenciesupdater
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/alerts"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/versions"
+)
+
+const (
+	cli     = "github.com/cli/cli"
+	spinner = "github.com/briandowns/spinner"
+	survey  = "github.com/AlecAivazis/survey/v2"
+	isatty  = "github.com/mattn/go-isatty"
+)
+
+func TestPlanAndApplyUpdates(t *testing.T) {
+	db := getTestDatabase(t)
+	// spinner is updated to a version with an advisory and a worse Scorecard, isatty is new
+	graph := dependenciesloader.Dependencies{
+		Nodes: []dependenciesloader.Node{
+			node(cli, "v1.14.0", "SELF"),
+			node(spinner, "v1.12.0", "DIRECT"),
+			node(survey, "v2.2.14", "DIRECT"),
+			node(isatty, "v0.0.14", "INDIRECT"),
+		},
+		Edges: []dependenciesloader.Edge{{FromNode: 0, ToNode: 1}, {FromNode: 0, ToNode: 2}, {FromNode: 1, ToNode: 3}},
+	}
+	stub := &depsDevStub{
+		projects: map[string]dependenciesloader.DependencyDetails{
+			spinner: project(spinner, "Apache-2.0", 4.2, 0),
+			isatty:  project(isatty, "MIT", 6.1, 10),
+		},
+		versions:   map[string][]string{spinner + "@v1.12.0": {"GHSA-xxxx-xxxx-xxxx"}},
+		advisories: map[string]dependenciesloader.Advisory{"GHSA-xxxx-xxxx-xxxx": {AdvisoryKey: dependenciesloader.AdvisoryKey{ID: "GHSA-xxxx-xxxx-xxxx"}, CVSS3Score: 7.5}},
+	}
+	updater := getTestUpdater(t, db, stub, graph)
+
+	var progress []string
+	plan, err := updater.WithProgress(func(stage, name string, done, total int) {
+		progress = append(progress, stage+" "+name)
+	}).PlanUpdates()
+	if err != nil {
+		t.Fatal("failed to plan updates:", err)
+	}
+	want := []PlannedChange{
+		{
+			Name: spinner, Purl: "pkg:golang/github.com/briandowns/spinner@v1.12.0", Reasons: []string{ReasonVersionChange},
+			CurrentVersion: "v1.11.1", NewVersion: "v1.12.0", Class: versions.ClassMinor,
+			CurrentOverallScore: 6, NewOverallScore: 4.2, CurrentLicense: "MIT", NewLicense: "Apache-2.0",
+			Checks: []CheckChange{{Name: "Maintained", Change: CheckChanged, CurrentScore: 10, NewScore: 0}},
+			Alerts: []database.Alert{
+				{ProjectKeyID: spinner, Rule: "overall-score-below-5"},
+				{ProjectKeyID: spinner, Rule: "overall-score-drop"},
+				{ProjectKeyID: spinner, Rule: "maintained-zero"},
+			},
+		},
+		{
+			Name: isatty, Purl: "pkg:golang/github.com/mattn/go-isatty@v0.0.14", Reasons: []string{ReasonNewDependency},
+			NewVersion: "v0.0.14", Class: versions.ClassNew, NewOverallScore: 6.1, NewLicense: "MIT",
+			Checks: []CheckChange{{Name: "Maintained", Change: CheckAdded, NewScore: 10}},
+			Alerts: []database.Alert{},
+		},
+	}
+	opts := cmp.Options{
+		cmpopts.IgnoreUnexported(PlannedChange{}),
+		cmpopts.IgnoreFields(database.Alert{}, "Check", "PreviousScore", "Score", "Message"),
+	}
+	if diff := cmp.Diff(want, plan, opts); diff != "" {
+		t.Fatalf("unexpected plan (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"plan " + spinner, "plan " + isatty}, progress); diff != "" {
+		t.Fatalf("unexpected progress (-want +got):\n%s", diff)
+	}
+
+	updated, err := updater.ApplyUpdates(plan)
+	if err != nil {
+		t.Fatal("failed to apply updates:", err)
+	}
+	if diff := cmp.Diff([]string{spinner, isatty}, updated); diff != "" {
+		t.Fatalf("unexpected updated dependencies (-want +got):\n%s", diff)
+	}
+
+	details, err := db.GetDependencyDetailsByID(spinner)
+	if err != nil || details.Scorecard.OverallScore != 4.2 || details.Purl != "pkg:golang/github.com/briandowns/spinner@v1.12.0" {
+		t.Fatalf("want the details and version of spinner updated, got %+v, %v", details, err)
+	}
+	if advisories, err := db.GetAdvisoriesByID(spinner); err != nil || len(advisories) != 1 {
+		t.Fatalf("want the advisory of the new version of spinner, got %v, %v", advisories, err)
+	}
+	if stored, err := db.GetAlerts(false); err != nil || len(stored) != 3 || stored[0].Purl != details.Purl {
+		t.Fatalf("want the alerts of spinner stored, got %+v, %v", stored, err)
+	}
+	if projectKeyID, err := db.GetProjectKeyIDOf(isatty); err != nil || projectKeyID != isatty {
+		t.Fatalf("want isatty linked to its project, got %q, %v", projectKeyID, err)
+	}
+	stored, err := db.GetDependencyGraph()
+	if err != nil || len(stored.Nodes) != 4 || len(stored.Edges) != 3 {
+		t.Fatalf("want the graph stored, got %+v, %v", stored, err)
+	}
+
+	// the applied plan leaves nothing to update
+	if plan, err := updater.PlanUpdates(); err != nil || len(plan) != 0 {
+		t.Fatalf("want an empty plan after the update, got %+v, %v", plan, err)
+	}
+}
+
+func TestPlanUpdatesOfRenamedProject(t *testing.T) {
+	db := getTestDatabase(t)
+	graph := dependenciesloader.Dependencies{Nodes: []dependenciesloader.Node{
+		node(cli, "v1.14.0", "SELF"),
+		node(spinner, "v1.11.1", "DIRECT"),
+		node(survey, "v2.3.0", "DIRECT"),
+	}}
+	// deps.dev returns the project github.com/alecaivazis/survey for the module
+	stub := &depsDevStub{projects: map[string]dependenciesloader.DependencyDetails{survey: project("github.com/alecaivazis/survey", "MIT", 5.5, 10)}}
+	updater := getTestUpdater(t, db, stub, graph)
+
+	plan, err := updater.PlanUpdates()
+	if err != nil {
+		t.Fatal("failed to plan updates:", err)
+	}
+	if len(plan) != 1 || plan[0].Name != survey || plan[0].CurrentOverallScore != 5.5 || len(plan[0].Checks) != 0 {
+		t.Fatalf("want survey compared with the stored project, got %+v", plan)
+	}
+	if _, err := updater.ApplyUpdates(plan); err != nil {
+		t.Fatal("failed to apply updates:", err)
+	}
+	details, err := db.GetDependencyDetailsByID("github.com/alecaivazis/survey")
+	if err != nil || details.Purl != "pkg:golang/github.com/AlecAivazis/survey/v2@v2.3.0" {
+		t.Fatalf("want the new version of the module, got %+v, %v", details, err)
+	}
+}
+
+func TestPlanStaleScorecards(t *testing.T) {
+	db := getTestDatabase(t)
+	// spinner isn't a node of the graph anymore, survey is at its stored version
+	graph := dependenciesloader.Dependencies{Nodes: []dependenciesloader.Node{node(cli, "v1.14.0", "SELF"), node(survey, "v2.2.14", "DIRECT")}}
+	stub := &depsDevStub{projects: map[string]dependenciesloader.DependencyDetails{
+		spinner: project(spinner, "MIT", 6, 10),
+		survey:  project("github.com/alecaivazis/survey", "MIT", 5.5, 10),
+	}}
+	updater := getTestUpdater(t, db, stub, graph)
+	// Scorecards are fetched at a precision of seconds, so they are stale a second later
+	updater.scorecardMaxAge = time.Nanosecond
+	time.Sleep(1100 * time.Millisecond)
+
+	plan, err := updater.PlanUpdates()
+	if err != nil {
+		t.Fatal("failed to plan updates:", err)
+	}
+	got := map[string]PlannedChange{}
+	for _, change := range plan {
+		got[change.Name] = change
+	}
+	for name, version := range map[string]string{spinner: "v1.11.1", survey: "v2.2.14"} {
+		change := got[name]
+		if change.Class != versions.ClassNone || change.NewVersion != version || !cmp.Equal(change.Reasons, []string{ReasonStaleScorecard}) {
+			t.Fatalf("want a refresh of the Scorecard of %s at %s, got %+v", name, version, change)
+		}
+	}
+	if len(plan) != 2 || len(FilterByClass(plan, []string{versions.ClassNone})) != 2 || len(FilterByClass(plan, []string{versions.ClassPatch})) != 0 {
+		t.Fatalf("want both stale Scorecards classified as %s, got %+v", versions.ClassNone, plan)
+	}
+}
+
+func TestConcurrentRefreshes(t *testing.T) {
+	db := getTestDatabase(t)
+	graph := dependenciesloader.Dependencies{Nodes: []dependenciesloader.Node{
+		node(cli, "v1.14.0", "SELF"),
+		node(spinner, "v1.12.0", "DIRECT"),
+		node(survey, "v2.3.0", "DIRECT"),
+	}}
+	stub := &depsDevStub{projects: map[string]dependenciesloader.DependencyDetails{
+		spinner: project(spinner, "MIT", 6, 10),
+		survey:  project("github.com/alecaivazis/survey", "MIT", 5.5, 10),
+	}}
+	updater := getTestUpdater(t, db, stub, graph)
+
+	// progress of refreshes sharing the loader must not interleave
+	var mu sync.Mutex
+	progress := []string{}
+	var wg sync.WaitGroup
+	for _, refresh := range []string{"a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := updater.WithProgress(func(stage, name string, done, total int) {
+				mu.Lock()
+				defer mu.Unlock()
+				progress = append(progress, refresh)
+				time.Sleep(10 * time.Millisecond)
+			}).Refresh(true, nil)
+			if err != nil {
+				t.Error("failed to refresh:", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(progress) != 4 || progress[0] != progress[1] || progress[2] != progress[3] {
+		t.Fatalf("want the progress of one refresh after the other, got %v", progress)
+	}
+}
+
+func TestImportDependenciesKeepsSource(t *testing.T) {
+	db := getTestDatabase(t)
+	graph := dependenciesloader.Dependencies{
+		Nodes: []dependenciesloader.Node{node(cli, "v1.14.0", "SELF"), node(spinner, "v1.11.1", "DIRECT"), node(survey, "v2.2.14", "DIRECT")},
+		Edges: []dependenciesloader.Edge{{FromNode: 0, ToNode: 1}, {FromNode: 0, ToNode: 2}},
+	}
+	updater := getTestUpdater(t, db, &depsDevStub{}, graph)
+
+	imported := dependenciesloader.Dependencies{
+		Nodes: []dependenciesloader.Node{node(cli, "v1.14.0", "SELF"), node(spinner, "v1.11.1", "DIRECT")},
+		Edges: []dependenciesloader.Edge{{FromNode: 0, ToNode: 1}},
+	}
+	if _, err := updater.ImportDependencies(imported); err != nil {
+		t.Fatal("failed to import dependencies:", err)
+	}
+	if stored, err := db.GetDependencyGraph(); err != nil || len(stored.Edges) != 1 {
+		t.Fatalf("want the edges of the imported graph, got %+v, %v", stored, err)
+	}
+
+	if _, err := updater.UpdateDependencies(); err != nil {
+		t.Fatal("failed to update dependencies:", err)
+	}
+	if stored, err := db.GetDependencyGraph(); err != nil || len(stored.Edges) != 2 {
+		t.Fatalf("want the edges of the source after the import, got %+v, %v", stored, err)
+	}
+}
+
+// getTestDatabase stores cli -> spinner, cli -> survey with projects of spinner and survey.
+func getTestDatabase(t *testing.T) *database.SQLiteDB {
+	db, err := database.NewSQLiteDB(path.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	t.Cleanup(db.CloseDbConnection)
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	graph := dependenciesloader.Dependencies{
+		Nodes: []dependenciesloader.Node{node(cli, "v1.14.0", "SELF"), node(spinner, "v1.11.1", "DIRECT"), node(survey, "v2.2.14", "DIRECT")},
+		Edges: []dependenciesloader.Edge{{FromNode: 0, ToNode: 1}, {FromNode: 0, ToNode: 2}},
+	}
+	if err := db.LoadDependencies(graph.Nodes); err != nil {
+		t.Fatal("failed to load versions:", err)
+	}
+	if err := db.LoadDependencyEdges(graph); err != nil {
+		t.Fatal("failed to load edges:", err)
+	}
+	if err := db.LoadDetailedDependencies([]dependenciesloader.DependencyDetails{
+		project(spinner, "MIT", 6, 10),
+		project("github.com/alecaivazis/survey", "MIT", 5.5, 10),
+	}); err != nil {
+		t.Fatal("failed to load projects:", err)
+	}
+	if err := db.LinkVersionKeys(map[string]string{spinner: spinner, survey: "github.com/alecaivazis/survey"}); err != nil {
+		t.Fatal("failed to link versions:", err)
+	}
+	return db
+}
+
+// getTestUpdater returns an updater of the graph with details and advisories served by the stub.
+func getTestUpdater(t *testing.T, db *database.SQLiteDB, stub *depsDevStub, graph dependenciesloader.Dependencies) *Updater {
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	loader := dependenciesloader.NewDependenciesLoader("")
+	loader.SetSource(dependenciesloader.StaticSource(graph))
+	loader.SetApiUrl(server.URL)
+	return NewDependenciesUpdater(loader, db, 0, nil, alerts.NewEvaluator(alerts.DefaultConfig))
+}
+
+func node(name, version, relation string) dependenciesloader.Node {
+	return dependenciesloader.Node{VersionKey: dependenciesloader.VersionKey{System: "GO", Name: name, Version: version}, Relation: relation}
+}
+
+func project(id, license string, score float64, maintained int) dependenciesloader.DependencyDetails {
+	return dependenciesloader.DependencyDetails{
+		ProjectKey: dependenciesloader.ProjectKey{ID: id},
+		License:    license,
+		Scorecard: dependenciesloader.Scorecard{
+			Date:         "2024-01-01T00:00:00Z",
+			OverallScore: score,
+			Checks:       []dependenciesloader.Check{{Name: "Maintained", Score: maintained}},
+		},
+	}
+}
+
+// depsDevStub serves projects, versions and advisories like the deps.dev v3 API. Projects are keyed
+// by the package names they are requested for, advisory IDs of versions by name@version.
+type depsDevStub struct {
+	projects   map[string]dependenciesloader.DependencyDetails
+	versions   map[string][]string
+	advisories map[string]dependenciesloader.Advisory
+}
+
+func (s *depsDevStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var response any
+	var ok bool
+	if name, found := strings.CutPrefix(r.URL.Path, "/projects/"); found {
+		response, ok = s.projects[name]
+	} else if id, found := strings.CutPrefix(r.URL.Path, "/advisories/"); found {
+		response, ok = s.advisories[id]
+	} else if versionKey, found := strings.CutPrefix(r.URL.Path, "/systems/GO/packages/"); found {
+		name, version, _ := strings.Cut(versionKey, "/versions/")
+		var ids []string
+		ids, ok = s.versions[name+"@"+version]
+		details := dependenciesloader.VersionDetails{AdvisoryKeys: []dependenciesloader.AdvisoryKey{}}
+		for _, id := range ids {
+			details.AdvisoryKeys = append(details.AdvisoryKeys, dependenciesloader.AdvisoryKey{ID: id})
+		}
+		response = details
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(response)
+}
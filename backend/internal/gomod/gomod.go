@@ -0,0 +1,237 @@
+// Package gomod builds the dependency graph of a local Go module, for modules which are not published
+// and can't be resolved by deps.dev.
+package gomod
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	system           = "GO"
+	relationSelf     = "SELF"
+	relationDirect   = "DIRECT"
+	relationIndirect = "INDIRECT"
+)
+
+// Parse builds the graph of a go.mod file. The module is the SELF node and requirements are DIRECT,
+// or INDIRECT if marked with the // indirect comment, with edges from the module to DIRECT ones.
+// Replacements by other module versions are applied, replacements by local directories are not.
+// The optional go.sum adds modules missing from go.mod, as go.mod files before Go 1.17 list only
+// a part of the build list, with the highest version found in go.sum.
+func Parse(goMod, goSum []byte) (dependenciesloader.Dependencies, error) {
+	file, err := modfile.Parse("go.mod", goMod, nil)
+	if err != nil {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	if file.Module == nil {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("go.mod has no module directive")
+	}
+
+	replacements := map[string]modfile.Replace{}
+	for _, replace := range file.Replace {
+		if replace.New.Version != "" {
+			replacements[replace.Old.Path+"@"+replace.Old.Version] = *replace
+		}
+	}
+	replaced := func(path, version string) (string, string) {
+		if replace, ok := replacements[path+"@"+version]; ok {
+			return replace.New.Path, replace.New.Version
+		}
+		if replace, ok := replacements[path+"@"]; ok {
+			return replace.New.Path, replace.New.Version
+		}
+		return path, version
+	}
+
+	g := newGraph(file.Module.Mod.Path)
+	required := map[string]bool{}
+	for _, require := range file.Require {
+		required[require.Mod.Path] = true
+		relation := relationDirect
+		if require.Indirect {
+			relation = relationIndirect
+		}
+		path, version := replaced(require.Mod.Path, require.Mod.Version)
+		g.add(path, version, relation)
+	}
+
+	if goSum != nil {
+		sums, err := parseSum(goSum)
+		if err != nil {
+			return dependenciesloader.Dependencies{}, err
+		}
+		for _, path := range sortedKeys(sums) {
+			if required[path] {
+				continue
+			}
+			replacedPath, version := replaced(path, sums[path])
+			g.add(replacedPath, version, relationIndirect)
+		}
+	}
+
+	return g.dependencies, nil
+}
+
+// parseSum returns the highest version of every module whose content, not only go.mod, is in go.sum.
+func parseSum(goSum []byte) (map[string]string, error) {
+	versions := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(goSum))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid go.sum line %d: %q", line, scanner.Text())
+		}
+		path, version := fields[0], fields[1]
+		if strings.HasSuffix(version, "/go.mod") {
+			continue
+		}
+		if current, ok := versions[path]; !ok || semver.Compare(version, current) > 0 {
+			versions[path] = version
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read go.sum: %w", err)
+	}
+	return versions, nil
+}
+
+// listedModule is a module printed by go list -m -json.
+type listedModule struct {
+	Path     string
+	Version  string
+	Main     bool
+	Indirect bool
+	Replace  *listedModule
+}
+
+// ParseList builds the graph from the output of go list -m -json all, which holds the complete build list.
+// The main module is the SELF node, other modules are DIRECT or INDIRECT as reported by go list,
+// with edges from the main module to DIRECT ones. Replacements by other module versions are applied.
+func ParseList(data []byte) (dependenciesloader.Dependencies, error) {
+	var modules []listedModule
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var module listedModule
+		if err := decoder.Decode(&module); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return dependenciesloader.Dependencies{}, fmt.Errorf("failed to decode go list output: %w", err)
+		}
+		modules = append(modules, module)
+	}
+
+	var g *graph
+	for _, module := range modules {
+		if module.Main {
+			g = newGraph(module.Path)
+			break
+		}
+	}
+	if g == nil {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("go list output has no main module")
+	}
+
+	for _, module := range modules {
+		if module.Main {
+			continue
+		}
+		relation := relationDirect
+		if module.Indirect {
+			relation = relationIndirect
+		}
+		path, version := module.Path, module.Version
+		if module.Replace != nil && module.Replace.Version != "" {
+			path, version = module.Replace.Path, module.Replace.Version
+		}
+		g.add(path, version, relation)
+	}
+
+	return g.dependencies, nil
+}
+
+type graph struct {
+	dependencies dependenciesloader.Dependencies
+	indexes      map[string]int
+}
+
+// newGraph starts a graph of the module, which has no version as it is not published.
+func newGraph(module string) *graph {
+	g := &graph{
+		dependencies: dependenciesloader.Dependencies{Nodes: []dependenciesloader.Node{}, Edges: []dependenciesloader.Edge{}},
+		indexes:      map[string]int{},
+	}
+	g.add(module, "", relationSelf)
+	return g
+}
+
+func (g *graph) add(path, version, relation string) {
+	if _, ok := g.indexes[path]; ok {
+		return
+	}
+	g.indexes[path] = len(g.dependencies.Nodes)
+	g.dependencies.Nodes = append(g.dependencies.Nodes, dependenciesloader.Node{
+		VersionKey: dependenciesloader.VersionKey{System: system, Name: path, Version: version},
+		Relation:   relation,
+		Errors:     []string{},
+	})
+	if relation == relationDirect {
+		g.dependencies.Edges = append(g.dependencies.Edges, dependenciesloader.Edge{
+			FromNode:    0,
+			ToNode:      len(g.dependencies.Nodes) - 1,
+			Requirement: version,
+		})
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ModSource reads the graph from a go.mod file, and go.sum next to it if present,
+// every time it is asked for it.
+type ModSource string
+
+func (s ModSource) Dependencies() (dependenciesloader.Dependencies, error) {
+	goMod, err := os.ReadFile(string(s))
+	if err != nil {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	goSum, err := os.ReadFile(filepath.Join(filepath.Dir(string(s)), "go.sum"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("failed to read go.sum: %w", err)
+	}
+	return Parse(goMod, goSum)
+}
+
+// ListSource reads the graph from a file with the output of go list -m -json all.
+type ListSource string
+
+func (s ListSource) Dependencies() (dependenciesloader.Dependencies, error) {
+	data, err := os.ReadFile(string(s))
+	if err != nil {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("failed to read go list output: %w", err)
+	}
+	return ParseList(data)
+}
@@ -0,0 +1,134 @@
+package gomod
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+const goMod = `module github.com/acme/service
+
+go 1.22
+
+require (
+	github.com/gorilla/mux v1.8.1
+	github.com/mattn/go-sqlite3 v1.14.24
+	github.com/felixge/httpsnoop v1.0.3 // indirect
+)
+
+require example.com/local v0.0.0
+
+replace github.com/mattn/go-sqlite3 => github.com/acme/go-sqlite3 v1.14.25
+
+replace example.com/local => ../local
+`
+
+const goSum = `github.com/felixge/httpsnoop v1.0.3 h1:s/nj+GCswXYzN5v2DpNMuMQYe+0DDwt5WVCU6CWBdXk=
+github.com/felixge/httpsnoop v1.0.3/go.mod h1:m8KPJKqk1gH5J9DgRY2ASl2lWCfGKXixSwevea8zH2U=
+github.com/google/go-cmp v0.5.0/go.mod h1:v8dTdLbMG2kIc/vJvl+f65V22dbkXbowE6jgT/gNBxE=
+github.com/google/go-cmp v0.5.9 h1:O2Tfq5qg4qc4AmwVlvv0oLiVAGB7enBSJ2x2DqQFi38=
+github.com/google/go-cmp v0.6.0 h1:ofyhXvXcZhtuRZOSSP/TMJBM4ssmHDzFwCB3ZR2kY7Q=
+github.com/gorilla/mux v1.8.1 h1:TuBL49tXwgrFYWhqrNgrUNEY92u81SPhu7sTdzQEiWY=
+`
+
+func node(name, version, relation string) dependenciesloader.Node {
+	return dependenciesloader.Node{
+		VersionKey: dependenciesloader.VersionKey{System: "GO", Name: name, Version: version},
+		Relation:   relation,
+		Errors:     []string{},
+	}
+}
+
+func TestParse(t *testing.T) {
+	got, err := Parse([]byte(goMod), []byte(goSum))
+	if err != nil {
+		t.Fatal("failed to parse:", err)
+	}
+
+	want := dependenciesloader.Dependencies{
+		Nodes: []dependenciesloader.Node{
+			node("github.com/acme/service", "", "SELF"),
+			node("github.com/gorilla/mux", "v1.8.1", "DIRECT"),
+			node("github.com/acme/go-sqlite3", "v1.14.25", "DIRECT"),
+			node("github.com/felixge/httpsnoop", "v1.0.3", "INDIRECT"),
+			node("example.com/local", "v0.0.0", "DIRECT"),
+			node("github.com/google/go-cmp", "v0.6.0", "INDIRECT"),
+		},
+		Edges: []dependenciesloader.Edge{
+			{FromNode: 0, ToNode: 1, Requirement: "v1.8.1"},
+			{FromNode: 0, ToNode: 2, Requirement: "v1.14.25"},
+			{FromNode: 0, ToNode: 4, Requirement: "v0.0.0"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected graph (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := Parse([]byte("go 1.22\n"), nil); err == nil {
+		t.Error("expected an error for go.mod without module")
+	}
+	if _, err := Parse([]byte(goMod), []byte("github.com/gorilla/mux v1.8.1\n")); err == nil {
+		t.Error("expected an error for invalid go.sum")
+	}
+}
+
+func TestParseList(t *testing.T) {
+	// go list -m -json prints a stream of JSON objects
+	list := `{
+	"Path": "github.com/acme/service",
+	"Main": true,
+	"Dir": "/src/service",
+	"GoMod": "/src/service/go.mod",
+	"GoVersion": "1.22"
+}
+{
+	"Path": "github.com/gorilla/mux",
+	"Version": "v1.8.1",
+	"Time": "2023-10-18T03:44:03Z"
+}
+{
+	"Path": "github.com/felixge/httpsnoop",
+	"Version": "v1.0.3",
+	"Indirect": true
+}
+{
+	"Path": "github.com/mattn/go-sqlite3",
+	"Version": "v1.14.24",
+	"Replace": {"Path": "github.com/acme/go-sqlite3", "Version": "v1.14.25"}
+}
+{
+	"Path": "example.com/local",
+	"Version": "v0.0.0",
+	"Replace": {"Path": "../local", "Dir": "/src/local"}
+}
+`
+	got, err := ParseList([]byte(list))
+	if err != nil {
+		t.Fatal("failed to parse:", err)
+	}
+
+	want := dependenciesloader.Dependencies{
+		Nodes: []dependenciesloader.Node{
+			node("github.com/acme/service", "", "SELF"),
+			node("github.com/gorilla/mux", "v1.8.1", "DIRECT"),
+			node("github.com/felixge/httpsnoop", "v1.0.3", "INDIRECT"),
+			node("github.com/acme/go-sqlite3", "v1.14.25", "DIRECT"),
+			node("example.com/local", "v0.0.0", "DIRECT"),
+		},
+		Edges: []dependenciesloader.Edge{
+			{FromNode: 0, ToNode: 1, Requirement: "v1.8.1"},
+			{FromNode: 0, ToNode: 3, Requirement: "v1.14.25"},
+			{FromNode: 0, ToNode: 4, Requirement: "v0.0.0"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected graph (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseList([]byte(`{"Path": "github.com/gorilla/mux", "Version": "v1.8.1"}`)); err == nil {
+		t.Error("expected an error for output without the main module")
+	}
+}
@@ -0,0 +1,354 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/overrides"
+)
+
+const (
+	cli     = "github.com/cli/cli"
+	spinner = "github.com/briandowns/spinner"
+	survey  = "github.com/AlecAivazis/survey/v2"
+	color   = "github.com/fatih/color"
+	// surveyProject is the project deps.dev reports for the module survey.
+	surveyProject = "github.com/alecaivazis/survey"
+)
+
+func TestQueries(t *testing.T) {
+	handler := NewHandler(getTestDatabase(t), func(id string) (string, error) {
+		if id == "" {
+			return "", database.ErrInvalidInput
+		}
+		return id, nil
+	})
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name: "project",
+			query: `{
+				project(id: "github.com/cli/cli") {
+					id purl license
+					scorecard { overallScore checks(minScore: 5) { name score documentation { url } } }
+					version { version relation }
+					dependencies { name project { license overriddenFields } advisories { id source } }
+				}
+			}`,
+			want: `{"project": {
+				"id": "github.com/cli/cli", "purl": "pkg:golang/github.com/cli/cli@v1.14.0", "license": "MIT",
+				"scorecard": {"overallScore": 7.1, "checks": [{"name": "Maintained", "score": 10, "documentation": {"url": "https://example.com/maintained"}}]},
+				"version": {"version": "v1.14.0", "relation": "SELF"},
+				"dependencies": [
+					{"name": "github.com/AlecAivazis/survey/v2", "project": {"license": "Apache-2.0", "overriddenFields": ["/license"]}, "advisories": [{"id": "GHSA-yyyy-yyyy-yyyy", "source": "deps.dev"}]},
+					{"name": "github.com/briandowns/spinner", "project": {"license": "Apache-2.0", "overriddenFields": []}, "advisories": [{"id": "GHSA-xxxx-xxxx-xxxx", "source": "deps.dev"}]}
+				]
+			}}`,
+		},
+		{
+			name:  "unknown project",
+			query: `{ project(id: "github.com/unknown/unknown") { id } }`,
+			want:  `{"project": null}`,
+		},
+		{
+			name:  "first page",
+			query: `{ projects(first: 2) { totalCount pageInfo { hasNextPage endCursor } nodes { id } } }`,
+			want: `{"projects": {
+				"totalCount": 3,
+				"pageInfo": {"hasNextPage": true, "endCursor": "Z2l0aHViLmNvbS9icmlhbmRvd25zL3NwaW5uZXI"},
+				"nodes": [{"id": "github.com/alecaivazis/survey"}, {"id": "github.com/briandowns/spinner"}]
+			}}`,
+		},
+		{
+			name:  "next page",
+			query: `{ projects(first: 2, after: "Z2l0aHViLmNvbS9icmlhbmRvd25zL3NwaW5uZXI") { pageInfo { hasNextPage } nodes { id } } }`,
+			want:  `{"projects": {"pageInfo": {"hasNextPage": false}, "nodes": [{"id": "github.com/cli/cli"}]}}`,
+		},
+		{
+			name:  "projects filtered by overridden license",
+			query: `{ projects(filter: {license: "apache-2.0", minScore: 5}) { totalCount nodes { id } } }`,
+			want:  `{"projects": {"totalCount": 1, "nodes": [{"id": "github.com/alecaivazis/survey"}]}}`,
+		},
+		{
+			name:  "vulnerable projects",
+			query: `{ projects(filter: {vulnerable: true}) { nodes { id advisories { id title aliases cvss3Score } } } }`,
+			want: `{"projects": {"nodes": [
+				{"id": "github.com/alecaivazis/survey", "advisories": [{"id": "GHSA-yyyy-yyyy-yyyy", "title": "Survey advisory", "aliases": [], "cvss3Score": 5.3}]},
+				{"id": "github.com/briandowns/spinner", "advisories": [
+					{"id": "GHSA-xxxx-xxxx-xxxx", "title": "Test advisory", "aliases": ["CVE-2024-0001"], "cvss3Score": 7.5}
+				]}
+			]}}`,
+		},
+		{
+			name:  "not vulnerable projects",
+			query: `{ projects(filter: {vulnerable: false}) { nodes { id } } }`,
+			want:  `{"projects": {"nodes": [{"id": "github.com/cli/cli"}]}}`,
+		},
+		{
+			name: "project named after its repository",
+			query: `{
+				project(id: "github.com/alecaivazis/survey") {
+					version { name version project { id } }
+					dependents { name }
+				}
+			}`,
+			want: `{"project": {
+				"version": {"name": "github.com/AlecAivazis/survey/v2", "version": "v2.2.14", "project": {"id": "github.com/alecaivazis/survey"}},
+				"dependents": [{"name": "github.com/cli/cli"}]
+			}}`,
+		},
+		{
+			name:  "versions",
+			query: `{ versions(filter: {relation: "indirect"}) { totalCount nodes { name purl project { id } dependents { name dependents { name } } } } }`,
+			want: `{"versions": {"totalCount": 1, "nodes": [{
+				"name": "github.com/fatih/color", "purl": "pkg:golang/github.com/fatih/color@v1.7.0", "project": null,
+				"dependents": [{"name": "github.com/briandowns/spinner", "dependents": [{"name": "github.com/cli/cli"}]}]
+			}]}}`,
+		},
+		{
+			name:  "version",
+			query: `{ version(name: "github.com/briandowns/spinner") { system dependencies { name dependencies { name } } project { starsCount } } }`,
+			want:  `{"version": {"system": "GO", "dependencies": [{"name": "github.com/fatih/color", "dependencies": []}], "project": {"starsCount": 42}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := post(t, handler, Request{Query: tt.query})
+			if len(response.Errors) > 0 {
+				t.Fatalf("unexpected errors: %s", response.Errors)
+			}
+			var want any
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal("failed to decode want:", err)
+			}
+			if diff := cmp.Diff(want, response.Data); diff != "" {
+				t.Fatalf("unexpected data (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	handler := NewHandler(getTestDatabase(t), func(id string) (string, error) {
+		return "", database.ErrInvalidInput
+	})
+
+	for query, want := range map[string]string{
+		`{ project(id: "pkg:x") { id } }`:                               "invalid input",
+		`{ projects(first: 1000) { totalCount } }`:                      "first must be between 0 and 100",
+		`{ projects(after: "!") { totalCount } }`:                       "invalid cursor",
+		`{ versions { nodes { unknown } } }`:                            `Cannot query field "unknown"`,
+		`{ version(name: "` + cli + `") { ` + nested(maxDepth) + ` } }`: "exceeds max depth",
+	} {
+		response := post(t, handler, Request{Query: query})
+		if len(response.Errors) == 0 || !strings.Contains(response.Errors[0].Message, want) {
+			t.Errorf("want an error containing %q for %s, got: %v", want, query, response.Errors)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/graphql", strings.NewReader("{")))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("want status %d for an invalid request, got %d", http.StatusBadRequest, recorder.Code)
+	}
+}
+
+func TestLoader(t *testing.T) {
+	var mu sync.Mutex
+	batches := [][]string{}
+	loader := NewLoader(func(keys []string) (map[string]int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, keys)
+		values := map[string]int{}
+		for _, key := range keys {
+			if key != "missing" {
+				values[key] = len(key)
+			}
+		}
+		return values, nil
+	})
+
+	keys := []string{"a", "bb", "ccc", "missing"}
+	loader.Queue(keys...)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, key := range keys {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				want := len(key)
+				if key == "missing" {
+					want = 0
+				}
+				if value, err := loader.Load(key); err != nil || value != want {
+					t.Errorf("want %d for %s, got %d, %v", want, key, value, err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	loader.Prime("primed", 7)
+	if value, _ := loader.Load("primed"); value != 7 {
+		t.Fatalf("want the primed value 7, got %d", value)
+	}
+	if values, _ := loader.LoadMany([]string{"a", "dddd", "eeeee"}); !cmp.Equal(values, []int{1, 4, 5}) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+	if diff := cmp.Diff([][]string{keys, {"dddd", "eeeee"}}, batches); diff != "" {
+		t.Fatalf("unexpected batches (-want +got):\n%s", diff)
+	}
+
+	failing := NewLoader(func(keys []string) (map[string]int, error) {
+		return nil, errors.New("unavailable")
+	})
+	failing.Queue("a", "b")
+	if _, err := failing.Load("b"); err == nil {
+		t.Fatal("want the error of the fetch")
+	}
+
+	// a panicking fetch fails every key of its batch, loads waiting for it included
+	release := make(chan struct{})
+	panicking := NewLoader(func(keys []string) (map[string]int, error) {
+		<-release
+		panic("broken")
+	})
+	panicking.Queue("a", "b")
+	errs := make(chan error, 2)
+	for _, key := range []string{"a", "b"} {
+		go func() {
+			_, err := panicking.Load(key)
+			errs <- err
+		}()
+	}
+	close(release)
+	for range 2 {
+		if err := <-errs; err == nil || !strings.Contains(err.Error(), "panicked: broken") {
+			t.Fatalf("want the panic of the fetch as the error, got %v", err)
+		}
+	}
+}
+
+// getTestDatabase stores the graph cli -> spinner -> color, cli -> survey. Every version but color
+// has a project, survey's is named after its repository like deps.dev does. The license of survey is
+// overridden, spinner and survey have an advisory.
+func getTestDatabase(t *testing.T) *database.SQLiteDB {
+	db, err := database.NewSQLiteDB(path.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	t.Cleanup(db.CloseDbConnection)
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	node := func(name, version, relation string) dependenciesloader.Node {
+		return dependenciesloader.Node{VersionKey: dependenciesloader.VersionKey{System: "GO", Name: name, Version: version}, Relation: relation}
+	}
+	graph := dependenciesloader.Dependencies{
+		Nodes: []dependenciesloader.Node{
+			node(cli, "v1.14.0", "SELF"),
+			node(spinner, "v1.11.1", "DIRECT"),
+			node(survey, "v2.2.14", "DIRECT"),
+			node(color, "v1.7.0", "INDIRECT"),
+		},
+		Edges: []dependenciesloader.Edge{{FromNode: 0, ToNode: 1}, {FromNode: 0, ToNode: 2}, {FromNode: 1, ToNode: 3}},
+	}
+	if err := db.LoadDependencies(graph.Nodes); err != nil {
+		t.Fatal("failed to load versions:", err)
+	}
+	if err := db.LoadDependencyEdges(graph); err != nil {
+		t.Fatal("failed to load edges:", err)
+	}
+
+	project := func(id, license string, stars int, score float64) dependenciesloader.DependencyDetails {
+		return dependenciesloader.DependencyDetails{
+			ProjectKey: dependenciesloader.ProjectKey{ID: id},
+			License:    license,
+			StarsCount: stars,
+			Scorecard: dependenciesloader.Scorecard{
+				Date:         "2024-01-01T00:00:00Z",
+				OverallScore: score,
+				Checks: []dependenciesloader.Check{
+					{Name: "Maintained", Score: 10, Documentation: dependenciesloader.Documentation{ShortDescription: "Maintained", URL: "https://example.com/maintained"}},
+					{Name: "Fuzzing", Score: 0, Documentation: dependenciesloader.Documentation{ShortDescription: "Fuzzing", URL: "https://example.com/fuzzing"}},
+				},
+			},
+		}
+	}
+	if err := db.LoadDetailedDependencies([]dependenciesloader.DependencyDetails{
+		project(cli, "MIT", 1000, 7.1),
+		project(spinner, "Apache-2.0", 42, 4.2),
+		project(surveyProject, "MIT", 100, 5.5),
+	}); err != nil {
+		t.Fatal("failed to load projects:", err)
+	}
+	if err := db.LinkVersionKeys(map[string]string{cli: cli, spinner: spinner, survey: surveyProject}); err != nil {
+		t.Fatal("failed to link versions:", err)
+	}
+	if _, err := db.SetOverride(overrides.Override{ProjectKeyID: surveyProject, Field: "/license", Value: json.RawMessage(`"Apache-2.0"`), Reason: "relicensed", Author: "jane"}); err != nil {
+		t.Fatal("failed to set override:", err)
+	}
+
+	advisories := []dependenciesloader.VersionAdvisories{{
+		VersionKey: graph.Nodes[1].VersionKey,
+		Advisories: []dependenciesloader.Advisory{{
+			AdvisoryKey: dependenciesloader.AdvisoryKey{ID: "GHSA-xxxx-xxxx-xxxx"},
+			Title:       "Test advisory",
+			Aliases:     []string{"CVE-2024-0001"},
+			CVSS3Score:  7.5,
+		}},
+	}, {
+		VersionKey: graph.Nodes[2].VersionKey,
+		Advisories: []dependenciesloader.Advisory{{
+			AdvisoryKey: dependenciesloader.AdvisoryKey{ID: "GHSA-yyyy-yyyy-yyyy"},
+			Title:       "Survey advisory",
+			CVSS3Score:  5.3,
+		}},
+	}}
+	if err := db.LoadAdvisories(advisories, database.SourceDepsDev); err != nil {
+		t.Fatal("failed to load advisories:", err)
+	}
+	return db
+}
+
+type response struct {
+	Data   any `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func post(t *testing.T, handler http.Handler, request Request) response {
+	body, _ := json.Marshal(request)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body))))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", recorder.Code, recorder.Body)
+	}
+	var result response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+		t.Fatal("failed to decode response:", err)
+	}
+	return result
+}
+
+// nested selects dependencies of dependencies depth times.
+func nested(depth int) string {
+	return strings.Repeat("dependencies { ", depth) + "name" + strings.Repeat(" }", depth)
+}
@@ -0,0 +1,117 @@
+package graphql
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Loader batches loads of values by key within a request, in the style of dataloader. Resolvers queue
+// the keys they will need as soon as they know them, e.g. the IDs of every project of a page, and the
+// first load of a queued key fetches all queued keys at once. Fetched values are cached for the rest
+// of the request, so a key is fetched once however often it is loaded.
+type Loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	entries map[K]*entry[V]
+	queue   []K
+}
+
+type entry[V any] struct {
+	// done is closed once value and err are set.
+	done     chan struct{}
+	value    V
+	err      error
+	fetching bool
+}
+
+// NewLoader returns a loader fetching batches of keys with fetch. Keys missing from the map returned
+// by fetch are loaded as the zero value.
+func NewLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{fetch: fetch, entries: map[K]*entry[V]{}}
+}
+
+// Queue adds keys to the next batch without fetching them.
+func (l *Loader[K, V]) Queue(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		l.entry(key)
+	}
+}
+
+// Prime caches the value of a key fetched some other way, keys which are already loaded are kept.
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.entries[key]; ok {
+		return
+	}
+	e := &entry[V]{done: make(chan struct{}), value: value, fetching: true}
+	close(e.done)
+	l.entries[key] = e
+}
+
+// Load returns the value of key. A key which isn't fetched yet is fetched together with every queued key,
+// a key which is being fetched by another load is waited for.
+func (l *Loader[K, V]) Load(key K) (V, error) {
+	l.mu.Lock()
+	e := l.entry(key)
+	if e.fetching {
+		l.mu.Unlock()
+		<-e.done
+		return e.value, e.err
+	}
+
+	batch := l.queue
+	l.queue = nil
+	entries := make([]*entry[V], len(batch))
+	for i, k := range batch {
+		entries[i] = l.entries[k]
+		entries[i].fetching = true
+	}
+	l.mu.Unlock()
+
+	values, err := l.fetchBatch(batch)
+	for i, k := range batch {
+		entries[i].value, entries[i].err = values[k], err
+		close(entries[i].done)
+	}
+	return e.value, e.err
+}
+
+// fetchBatch fetches batch, a panic of fetch is returned as the error of every key of the batch,
+// so loads waiting for them don't block forever.
+func (l *Loader[K, V]) fetchBatch(batch []K) (values map[K]V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			values, err = nil, fmt.Errorf("fetching %d keys panicked: %v", len(batch), r)
+		}
+	}()
+	return l.fetch(batch)
+}
+
+// LoadMany loads the values of keys in a single batch.
+func (l *Loader[K, V]) LoadMany(keys []K) ([]V, error) {
+	l.Queue(keys...)
+	values := make([]V, len(keys))
+	for i, key := range keys {
+		value, err := l.Load(key)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// entry returns the entry of key, queueing keys seen for the first time. l.mu must be held.
+func (l *Loader[K, V]) entry(key K) *entry[V] {
+	e, ok := l.entries[key]
+	if !ok {
+		e = &entry[V]{done: make(chan struct{})}
+		l.entries[key] = e
+		l.queue = append(l.queue, key)
+	}
+	return e
+}
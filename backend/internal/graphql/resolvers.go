@@ -0,0 +1,568 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/purl"
+)
+
+// maxPageSize bounds first of connections, pages have 20 nodes by default.
+const maxPageSize = 100
+
+type loadersKey struct{}
+
+// loaders of a request. Resolvers read everything through them, so the nodes of a list are loaded
+// with one query per kind of data however deeply the query nests.
+type loaders struct {
+	db        *database.SQLiteDB
+	resolveID func(id string) (string, error)
+	projects  *Loader[string, *dependenciesloader.DependencyDetails]
+	versions  *Loader[string, *dependenciesloader.Node]
+	// projectKeyIDs of versions and versionNames of projects, deps.dev names projects after their
+	// repositories, so neither can be derived from the other.
+	projectKeyIDs     *Loader[string, string]
+	versionNames      *Loader[string, string]
+	advisories        *Loader[string, []database.AdvisoryFinding]
+	projectAdvisories *Loader[string, []database.AdvisoryFinding]
+	edges             *Loader[string, *edges]
+}
+
+// edges of a version, the names of its direct dependencies and of the versions depending on it.
+type edges struct {
+	dependencies []string
+	dependents   []string
+}
+
+func newLoaders(db *database.SQLiteDB, resolveID func(id string) (string, error)) *loaders {
+	return &loaders{
+		db:        db,
+		resolveID: resolveID,
+		projects:  NewLoader(db.GetDependencyDetailsByIDs),
+		versions: NewLoader(func(names []string) (map[string]*dependenciesloader.Node, error) {
+			nodes, err := db.GetNodesByNames(names)
+			if err != nil {
+				return nil, err
+			}
+			result := map[string]*dependenciesloader.Node{}
+			for name, node := range nodes {
+				result[name] = &node
+			}
+			return result, nil
+		}),
+		projectKeyIDs:     NewLoader(db.GetProjectKeyIDsByNames),
+		versionNames:      NewLoader(db.GetVersionNamesByProjectKeyIDs),
+		advisories:        NewLoader(db.GetAdvisoriesByNames),
+		projectAdvisories: NewLoader(db.GetAdvisoriesByIDs),
+		edges: NewLoader(func(names []string) (map[string]*edges, error) {
+			stored, err := db.GetDependencyEdgesByNames(names)
+			if err != nil {
+				return nil, err
+			}
+			result := map[string]*edges{}
+			for _, name := range names {
+				result[name] = &edges{}
+			}
+			for _, edge := range stored {
+				if e, ok := result[edge.From]; ok {
+					e.dependencies = append(e.dependencies, edge.To)
+				}
+				if e, ok := result[edge.To]; ok {
+					e.dependents = append(e.dependents, edge.From)
+				}
+			}
+			return result, nil
+		}),
+	}
+}
+
+func loadersOf(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// queueProjects queues the IDs of projects about to be resolved for every loader their fields use.
+func (l *loaders) queueProjects(ids []string) {
+	l.projects.Queue(ids...)
+	l.versionNames.Queue(ids...)
+	l.projectAdvisories.Queue(ids...)
+}
+
+// queueVersions queues the names of versions about to be resolved for every loader their fields use.
+func (l *loaders) queueVersions(names []string) {
+	l.versions.Queue(names...)
+	l.projectKeyIDs.Queue(names...)
+	l.advisories.Queue(names...)
+	l.edges.Queue(names...)
+}
+
+func (l *loaders) project(id string) (*projectResolver, error) {
+	details, err := l.projects.Load(id)
+	if err != nil || details == nil {
+		return nil, err
+	}
+	return &projectResolver{l, details}, nil
+}
+
+// projectsOf resolves the stored projects of ids.
+func (l *loaders) projectsOf(ids []string) ([]*projectResolver, error) {
+	l.queueProjects(ids)
+	details, err := l.projects.LoadMany(ids)
+	if err != nil {
+		return nil, err
+	}
+	result := []*projectResolver{}
+	for _, d := range details {
+		if d != nil {
+			result = append(result, &projectResolver{l, d})
+		}
+	}
+	return result, nil
+}
+
+func (l *loaders) version(name string) (*versionResolver, error) {
+	node, err := l.versions.Load(name)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	return &versionResolver{l, node}, nil
+}
+
+// versionsOf resolves the stored versions of names.
+func (l *loaders) versionsOf(names []string) ([]*versionResolver, error) {
+	l.queueVersions(names)
+	nodes, err := l.versions.LoadMany(names)
+	if err != nil {
+		return nil, err
+	}
+	result := []*versionResolver{}
+	for _, node := range nodes {
+		if node != nil {
+			result = append(result, &versionResolver{l, node})
+		}
+	}
+	return result, nil
+}
+
+func (l *loaders) advisoriesOf(name string) ([]*advisoryResolver, error) {
+	return resolveAdvisories(l.advisories.Load(name))
+}
+
+func (l *loaders) advisoriesOfProject(id string) ([]*advisoryResolver, error) {
+	return resolveAdvisories(l.projectAdvisories.Load(id))
+}
+
+func resolveAdvisories(findings []database.AdvisoryFinding, err error) ([]*advisoryResolver, error) {
+	if err != nil {
+		return nil, err
+	}
+	result := []*advisoryResolver{}
+	for _, finding := range findings {
+		result = append(result, &advisoryResolver{finding})
+	}
+	return result, nil
+}
+
+func (l *loaders) dependenciesOf(name string) ([]*versionResolver, error) {
+	e, err := l.edges.Load(name)
+	if err != nil || e == nil {
+		return []*versionResolver{}, err
+	}
+	return l.versionsOf(e.dependencies)
+}
+
+func (l *loaders) dependentsOf(name string) ([]*versionResolver, error) {
+	e, err := l.edges.Load(name)
+	if err != nil || e == nil {
+		return []*versionResolver{}, err
+	}
+	return l.versionsOf(e.dependents)
+}
+
+func (l *loaders) vulnerable(name string) (bool, error) {
+	findings, err := l.advisories.Load(name)
+	return len(findings) > 0, err
+}
+
+// query is the root resolver, state of a request is kept by its loaders in the context.
+type query struct{}
+
+func (q *query) Project(ctx context.Context, args struct{ ID graphqlgo.ID }) (*projectResolver, error) {
+	l := loadersOf(ctx)
+	id, err := l.resolveID(string(args.ID))
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l.project(id)
+}
+
+func (q *query) Projects(ctx context.Context, args struct {
+	Filter *projectFilter
+	First  int32
+	After  *string
+}) (*projectConnection, error) {
+	l := loadersOf(ctx)
+	details, err := l.db.FindDependencies(args.Filter.database())
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]string, len(details))
+	for i, d := range details {
+		matching[i] = d.ProjectKey.ID
+		l.projects.Prime(d.ProjectKey.ID, d)
+	}
+
+	ids, info, err := page(matching, args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := l.projectsOf(ids)
+	if err != nil {
+		return nil, err
+	}
+	return &projectConnection{len(matching), info, nodes}, nil
+}
+
+func (q *query) Version(ctx context.Context, args struct{ Name string }) (*versionResolver, error) {
+	return loadersOf(ctx).version(args.Name)
+}
+
+func (q *query) Versions(ctx context.Context, args struct {
+	Filter *versionFilter
+	First  int32
+	After  *string
+}) (*versionConnection, error) {
+	l := loadersOf(ctx)
+	graph, err := l.db.GetDependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	// the whole graph is read, so the edges of every version are known as well
+	names := []string{}
+	graphEdges := map[string]*edges{}
+	for i := range graph.Nodes {
+		name := graph.Nodes[i].VersionKey.Name
+		l.versions.Prime(name, &graph.Nodes[i])
+		names = append(names, name)
+		graphEdges[name] = &edges{}
+	}
+	for _, edge := range graph.Edges {
+		from, to := graph.Nodes[edge.FromNode].VersionKey.Name, graph.Nodes[edge.ToNode].VersionKey.Name
+		graphEdges[from].dependencies = append(graphEdges[from].dependencies, to)
+		graphEdges[to].dependents = append(graphEdges[to].dependents, from)
+	}
+	for name, e := range graphEdges {
+		l.edges.Prime(name, e)
+	}
+	slices.Sort(names)
+	if args.Filter != nil && args.Filter.Vulnerable != nil {
+		l.advisories.Queue(names...)
+	}
+
+	matching := []string{}
+	for _, name := range names {
+		node, _ := l.versions.Load(name)
+		match, err := args.Filter.matches(l, node)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			matching = append(matching, name)
+		}
+	}
+
+	names, info, err := page(matching, args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := l.versionsOf(names)
+	if err != nil {
+		return nil, err
+	}
+	return &versionConnection{len(matching), info, nodes}, nil
+}
+
+type projectFilter struct {
+	Search     *string
+	License    *string
+	MinScore   *float64
+	MaxScore   *float64
+	Vulnerable *bool
+}
+
+// database returns the filter of the database, a nil filter matches everything.
+func (f *projectFilter) database() database.DependencyFilter {
+	if f == nil {
+		return database.DependencyFilter{}
+	}
+	filter := database.DependencyFilter{MinScore: f.MinScore, MaxScore: f.MaxScore, Vulnerable: f.Vulnerable}
+	if f.Search != nil {
+		filter.Search = *f.Search
+	}
+	if f.License != nil {
+		filter.License = *f.License
+	}
+	return filter
+}
+
+type versionFilter struct {
+	Search     *string
+	System     *string
+	Relation   *string
+	Vulnerable *bool
+}
+
+// matches reports whether node matches every field of the filter, a nil filter matches everything.
+func (f *versionFilter) matches(l *loaders, node *dependenciesloader.Node) (bool, error) {
+	switch {
+	case f == nil:
+		return true, nil
+	case f.Search != nil && !containsFold(node.VersionKey.Name, *f.Search),
+		f.System != nil && !strings.EqualFold(node.VersionKey.System, *f.System),
+		f.Relation != nil && !strings.EqualFold(node.Relation, *f.Relation):
+		return false, nil
+	case f.Vulnerable != nil:
+		vulnerable, err := l.vulnerable(node.VersionKey.Name)
+		return vulnerable == *f.Vulnerable, err
+	}
+	return true, nil
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// page returns the keys of the requested page of sorted keys. Cursors are the keys themselves,
+// encoded to be opaque.
+func page(keys []string, first int32, after *string) ([]string, *pageInfo, error) {
+	if first < 0 || first > maxPageSize {
+		return nil, nil, fmt.Errorf("first must be between 0 and %d, got %d", maxPageSize, first)
+	}
+
+	start := 0
+	if after != nil {
+		key, err := base64.RawURLEncoding.DecodeString(*after)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid cursor %q", *after)
+		}
+		start = sort.SearchStrings(keys, string(key))
+		if start < len(keys) && keys[start] == string(key) {
+			start++
+		}
+	}
+
+	end := min(start+int(first), len(keys))
+	info := &pageInfo{hasNextPage: end < len(keys)}
+	if end > start {
+		cursor := base64.RawURLEncoding.EncodeToString([]byte(keys[end-1]))
+		info.endCursor = &cursor
+	}
+	return keys[start:end], info, nil
+}
+
+type pageInfo struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (p *pageInfo) HasNextPage() bool  { return p.hasNextPage }
+func (p *pageInfo) EndCursor() *string { return p.endCursor }
+
+type projectConnection struct {
+	totalCount int
+	pageInfo   *pageInfo
+	nodes      []*projectResolver
+}
+
+func (c *projectConnection) TotalCount() int32         { return int32(c.totalCount) }
+func (c *projectConnection) PageInfo() *pageInfo       { return c.pageInfo }
+func (c *projectConnection) Nodes() []*projectResolver { return c.nodes }
+
+type versionConnection struct {
+	totalCount int
+	pageInfo   *pageInfo
+	nodes      []*versionResolver
+}
+
+func (c *versionConnection) TotalCount() int32         { return int32(c.totalCount) }
+func (c *versionConnection) PageInfo() *pageInfo       { return c.pageInfo }
+func (c *versionConnection) Nodes() []*versionResolver { return c.nodes }
+
+type projectResolver struct {
+	l       *loaders
+	details *dependenciesloader.DependencyDetails
+}
+
+func (p *projectResolver) ID() graphqlgo.ID { return graphqlgo.ID(p.details.ProjectKey.ID) }
+
+func (p *projectResolver) Purl() *string {
+	if p.details.Purl == "" {
+		return nil
+	}
+	return &p.details.Purl
+}
+
+func (p *projectResolver) OpenIssuesCount() int32 { return int32(p.details.OpenIssuesCount) }
+func (p *projectResolver) StarsCount() int32      { return int32(p.details.StarsCount) }
+func (p *projectResolver) ForksCount() int32      { return int32(p.details.ForksCount) }
+func (p *projectResolver) License() string        { return p.details.License }
+func (p *projectResolver) Description() string    { return p.details.Description }
+func (p *projectResolver) Homepage() string       { return p.details.Homepage }
+
+func (p *projectResolver) OverriddenFields() []string {
+	fields := []string{}
+	for _, override := range p.details.Overrides {
+		fields = append(fields, override.Field)
+	}
+	return fields
+}
+
+func (p *projectResolver) Scorecard() *scorecardResolver {
+	return &scorecardResolver{&p.details.Scorecard}
+}
+
+func (p *projectResolver) Version() (*versionResolver, error) {
+	name, err := p.l.versionNames.Load(p.details.ProjectKey.ID)
+	if err != nil || name == "" {
+		return nil, err
+	}
+	return p.l.version(name)
+}
+
+func (p *projectResolver) Advisories() ([]*advisoryResolver, error) {
+	return p.l.advisoriesOfProject(p.details.ProjectKey.ID)
+}
+
+func (p *projectResolver) Dependencies() ([]*versionResolver, error) {
+	name, err := p.l.versionNames.Load(p.details.ProjectKey.ID)
+	if err != nil || name == "" {
+		return []*versionResolver{}, err
+	}
+	return p.l.dependenciesOf(name)
+}
+
+func (p *projectResolver) Dependents() ([]*versionResolver, error) {
+	name, err := p.l.versionNames.Load(p.details.ProjectKey.ID)
+	if err != nil || name == "" {
+		return []*versionResolver{}, err
+	}
+	return p.l.dependentsOf(name)
+}
+
+type versionResolver struct {
+	l    *loaders
+	node *dependenciesloader.Node
+}
+
+func (v *versionResolver) Name() string     { return v.node.VersionKey.Name }
+func (v *versionResolver) System() string   { return v.node.VersionKey.System }
+func (v *versionResolver) Version() string  { return v.node.VersionKey.Version }
+func (v *versionResolver) Relation() string { return v.node.Relation }
+func (v *versionResolver) Purl() string     { return purl.FromVersionKey(v.node.VersionKey) }
+
+// Project resolves the project the version is linked to, a version which isn't linked is looked up by its name.
+func (v *versionResolver) Project() (*projectResolver, error) {
+	id, err := v.l.projectKeyIDs.Load(v.node.VersionKey.Name)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		id = v.node.VersionKey.Name
+	}
+	return v.l.project(id)
+}
+
+func (v *versionResolver) Advisories() ([]*advisoryResolver, error) {
+	return v.l.advisoriesOf(v.node.VersionKey.Name)
+}
+
+func (v *versionResolver) Dependencies() ([]*versionResolver, error) {
+	return v.l.dependenciesOf(v.node.VersionKey.Name)
+}
+
+func (v *versionResolver) Dependents() ([]*versionResolver, error) {
+	return v.l.dependentsOf(v.node.VersionKey.Name)
+}
+
+type scorecardResolver struct {
+	scorecard *dependenciesloader.Scorecard
+}
+
+func (s *scorecardResolver) Date() string             { return s.scorecard.Date }
+func (s *scorecardResolver) RepositoryName() string   { return s.scorecard.Repository.Name }
+func (s *scorecardResolver) RepositoryCommit() string { return s.scorecard.Repository.Commit }
+func (s *scorecardResolver) ScorecardVersion() string { return s.scorecard.Scorecard.Version }
+func (s *scorecardResolver) ScorecardCommit() string  { return s.scorecard.Scorecard.Commit }
+func (s *scorecardResolver) OverallScore() float64    { return s.scorecard.OverallScore }
+
+func (s *scorecardResolver) Metadata() []string {
+	if s.scorecard.Metadata == nil {
+		return []string{}
+	}
+	return s.scorecard.Metadata
+}
+
+func (s *scorecardResolver) Checks(args struct {
+	Name     *string
+	MinScore *int32
+	MaxScore *int32
+}) []*checkResolver {
+	checks := []*checkResolver{}
+	for _, check := range s.scorecard.Checks {
+		if args.Name != nil && !strings.EqualFold(check.Name, *args.Name) ||
+			args.MinScore != nil && int32(check.Score) < *args.MinScore ||
+			args.MaxScore != nil && int32(check.Score) > *args.MaxScore {
+			continue
+		}
+		checks = append(checks, &checkResolver{check})
+	}
+	return checks
+}
+
+type checkResolver struct {
+	check dependenciesloader.Check
+}
+
+func (c *checkResolver) Name() string   { return c.check.Name }
+func (c *checkResolver) Score() int32   { return int32(c.check.Score) }
+func (c *checkResolver) Reason() string { return c.check.Reason }
+
+func (c *checkResolver) Documentation() *documentationResolver {
+	return &documentationResolver{c.check.Documentation}
+}
+
+type documentationResolver struct {
+	documentation dependenciesloader.Documentation
+}
+
+func (d *documentationResolver) ShortDescription() string { return d.documentation.ShortDescription }
+func (d *documentationResolver) URL() string              { return d.documentation.URL }
+
+type advisoryResolver struct {
+	finding database.AdvisoryFinding
+}
+
+func (a *advisoryResolver) ID() graphqlgo.ID    { return graphqlgo.ID(a.finding.AdvisoryKey.ID) }
+func (a *advisoryResolver) URL() string         { return a.finding.URL }
+func (a *advisoryResolver) Title() string       { return a.finding.Title }
+func (a *advisoryResolver) Cvss3Score() float64 { return a.finding.CVSS3Score }
+func (a *advisoryResolver) Cvss3Vector() string { return a.finding.CVSS3Vector }
+func (a *advisoryResolver) Source() string      { return a.finding.Source }
+
+func (a *advisoryResolver) Aliases() []string {
+	if a.finding.Aliases == nil {
+		return []string{}
+	}
+	return a.finding.Aliases
+}
@@ -0,0 +1,182 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+)
+
+// Schema of the GraphQL API. Projects are the dependencies with deps.dev details, versions are the nodes
+// of the dependency graph. Versions are linked to the project deps.dev reports for them, which is named after
+// the repository rather than the package, e.g. the version github.com/AlecAivazis/survey/v2 belongs to the
+// project github.com/alecaivazis/survey.
+const Schema = `
+schema {
+	query: Query
+}
+
+type Query {
+	"A project by its ID or package URL, null if it isn't stored."
+	project(id: ID!): Project
+	projects(filter: ProjectFilter, first: Int = 20, after: String): ProjectConnection!
+	"A version of the dependency graph by its name, null if it isn't stored."
+	version(name: String!): Version
+	versions(filter: VersionFilter, first: Int = 20, after: String): VersionConnection!
+}
+
+"Projects matching every given field."
+input ProjectFilter {
+	"Substring of the ID, case insensitive."
+	search: String
+	license: String
+	minScore: Float
+	maxScore: Float
+	"Whether the stored version of the project has advisories."
+	vulnerable: Boolean
+}
+
+"Versions matching every given field."
+input VersionFilter {
+	"Substring of the name, case insensitive."
+	search: String
+	system: String
+	"SELF, DIRECT or INDIRECT."
+	relation: String
+	vulnerable: Boolean
+}
+
+type PageInfo {
+	hasNextPage: Boolean!
+	"Cursor of the last node, pass it as after to get the next page."
+	endCursor: String
+}
+
+type ProjectConnection {
+	totalCount: Int!
+	pageInfo: PageInfo!
+	nodes: [Project!]!
+}
+
+type VersionConnection {
+	totalCount: Int!
+	pageInfo: PageInfo!
+	nodes: [Version!]!
+}
+
+"A dependency with its deps.dev details, manual overrides applied."
+type Project {
+	id: ID!
+	purl: String
+	openIssuesCount: Int!
+	starsCount: Int!
+	forksCount: Int!
+	license: String!
+	description: String!
+	homepage: String!
+	"Fields corrected by hand, as JSON Pointers."
+	overriddenFields: [String!]!
+	scorecard: Scorecard!
+	"The stored version of the project, null for projects added through the API."
+	version: Version
+	"Advisories of the stored versions of the project."
+	advisories: [Advisory!]!
+	"Direct dependencies of the stored version."
+	dependencies: [Version!]!
+	"Versions depending directly on the stored version."
+	dependents: [Version!]!
+}
+
+type Version {
+	name: String!
+	system: String!
+	version: String!
+	relation: String!
+	purl: String!
+	"The project of the version, null if it has no deps.dev details."
+	project: Project
+	advisories: [Advisory!]!
+	dependencies: [Version!]!
+	dependents: [Version!]!
+}
+
+type Scorecard {
+	date: String!
+	repositoryName: String!
+	repositoryCommit: String!
+	scorecardVersion: String!
+	scorecardCommit: String!
+	overallScore: Float!
+	metadata: [String!]!
+	"Checks matching every given argument."
+	checks(name: String, minScore: Int, maxScore: Int): [Check!]!
+}
+
+type Check {
+	name: String!
+	score: Int!
+	reason: String!
+	documentation: Documentation!
+}
+
+type Documentation {
+	shortDescription: String!
+	url: String!
+}
+
+type Advisory {
+	id: ID!
+	url: String!
+	title: String!
+	aliases: [String!]!
+	cvss3Score: Float!
+	cvss3Vector: String!
+	"Source which reported the advisory for the version, deps.dev or osv."
+	source: String!
+}
+`
+
+// maxDepth bounds the nesting of queries, traversals of the dependency graph included.
+const maxDepth = 15
+
+var schema = graphqlgo.MustParseSchema(Schema, &query{}, graphqlgo.MaxDepth(maxDepth))
+
+// Request is a GraphQL query sent over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Handler serves GraphQL queries sent by POST. Each request gets its own loaders, so values are
+// cached for a single query only.
+type Handler struct {
+	db        *database.SQLiteDB
+	resolveID func(id string) (string, error)
+}
+
+// NewHandler returns a handler reading from db. resolveID maps IDs of projects given to the project
+// query, e.g. package URLs, to project key IDs, it returns database.ErrNotFound for unknown projects.
+func NewHandler(db *database.SQLiteDB, resolveID func(id string) (string, error)) *Handler {
+	return &Handler{db, resolveID}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request Request
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeResponse(w, http.StatusBadRequest, &graphqlgo.Response{Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("invalid request: %v", err)}})
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), loadersKey{}, newLoaders(h.db, h.resolveID))
+	writeResponse(w, http.StatusOK, schema.Exec(ctx, request.Query, request.OperationName, request.Variables))
+}
+
+func writeResponse(w http.ResponseWriter, status int, response *graphqlgo.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
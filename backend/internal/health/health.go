@@ -0,0 +1,269 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+// Fields a rule can be evaluated against. Scores of single checks are addressed as "check:<name>",
+// e.g. "check:Maintained".
+const (
+	FieldOverallScore       = "overallScore"
+	FieldStars              = "stars"
+	FieldForks              = "forks"
+	FieldOpenIssues         = "openIssues"
+	FieldAdvisories         = "advisories"
+	FieldCriticalAdvisories = "advisories.critical"
+	FieldHighAdvisories     = "advisories.high"
+	FieldMaxCVSS3Score      = "advisories.maxCvss3Score"
+	checkFieldPrefix        = "check:"
+)
+
+// CVSS v3 qualitative severity ratings used for counting advisories.
+const (
+	criticalCVSS3Score = 9.0
+	highCVSS3Score     = 7.0
+)
+
+var operators = map[string]func(a, b float64) bool{
+	">=": func(a, b float64) bool { return a >= b },
+	">":  func(a, b float64) bool { return a > b },
+	"<=": func(a, b float64) bool { return a <= b },
+	"<":  func(a, b float64) bool { return a < b },
+	"==": func(a, b float64) bool { return a == b },
+	"!=": func(a, b float64) bool { return a != b },
+}
+
+// Rule passes when the value of Field compared with Value using Operator is true.
+// Missing values, e.g. a check the Scorecard does not have, fail the rule unless SkipIfMissing is set.
+type Rule struct {
+	Name          string  `json:"name"`
+	Field         string  `json:"field"`
+	Operator      string  `json:"operator"`
+	Value         float64 `json:"value"`
+	SkipIfMissing bool    `json:"skipIfMissing"`
+}
+
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// DefaultPolicy is used when no policy file is given.
+var DefaultPolicy = Policy{
+	Rules: []Rule{
+		{Name: "overall-score", Field: FieldOverallScore, Operator: ">=", Value: 5},
+		{Name: "maintained", Field: checkFieldPrefix + "Maintained", Operator: ">", Value: 0},
+		{Name: "no-critical-advisories", Field: FieldCriticalAdvisories, Operator: "==", Value: 0},
+		{Name: "popular", Field: FieldStars, Operator: ">", Value: 50},
+	},
+}
+
+// LoadPolicy reads health rules from a JSON file. An empty path means DefaultPolicy.
+func LoadPolicy(path string) (Policy, error) {
+	if path == "" {
+		return DefaultPolicy, nil
+	}
+
+	var policy Policy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, fmt.Errorf("failed to read health policy: %w", err)
+	}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse health policy: %w", err)
+	}
+
+	for _, rule := range policy.Rules {
+		if err := rule.validate(); err != nil {
+			return policy, err
+		}
+	}
+
+	return policy, nil
+}
+
+func (r Rule) validate() error {
+	if _, ok := operators[r.Operator]; !ok {
+		return fmt.Errorf("health rule %s: unknown operator: %s", r.Name, r.Operator)
+	}
+	switch r.Field {
+	case FieldOverallScore, FieldStars, FieldForks, FieldOpenIssues, FieldAdvisories,
+		FieldCriticalAdvisories, FieldHighAdvisories, FieldMaxCVSS3Score:
+		return nil
+	}
+	if strings.HasPrefix(r.Field, checkFieldPrefix) && len(r.Field) > len(checkFieldPrefix) {
+		return nil
+	}
+	return fmt.Errorf("health rule %s: unknown field: %s", r.Name, r.Field)
+}
+
+type Failure struct {
+	Rule     string   `json:"rule"`
+	Field    string   `json:"field"`
+	Operator string   `json:"operator"`
+	Expected float64  `json:"expected"`
+	Actual   *float64 `json:"actual"`
+	Message  string   `json:"message"`
+}
+
+type Verdict struct {
+	ProjectKeyID string    `json:"projectKeyId"`
+	Purl         string    `json:"purl,omitempty"`
+	Passed       bool      `json:"passed"`
+	Failures     []Failure `json:"failures"`
+}
+
+type Report struct {
+	Passed   bool      `json:"passed"`
+	Failed   int       `json:"failed"`
+	Verdicts []Verdict `json:"verdicts"`
+}
+
+// Evaluate applies the rules to every dependency. Advisories are looked up by project key ID.
+// The gate passes only if every dependency passes every rule.
+func (p Policy) Evaluate(dependencies []dependenciesloader.DependencyDetails, advisories map[string][]database.AdvisoryFinding) Report {
+	report := Report{Passed: true, Verdicts: []Verdict{}}
+	for _, dependency := range dependencies {
+		verdict := p.evaluateDependency(dependency, advisories[dependency.ProjectKey.ID])
+		if !verdict.Passed {
+			report.Passed = false
+			report.Failed++
+		}
+		report.Verdicts = append(report.Verdicts, verdict)
+	}
+	return report
+}
+
+func (p Policy) evaluateDependency(dependency dependenciesloader.DependencyDetails, advisories []database.AdvisoryFinding) Verdict {
+	verdict := Verdict{ProjectKeyID: dependency.ProjectKey.ID, Purl: dependency.Purl, Passed: true, Failures: []Failure{}}
+	for _, rule := range p.Rules {
+		actual, ok := valueOf(rule.Field, dependency, advisories)
+		if !ok && rule.SkipIfMissing {
+			continue
+		}
+
+		failure := Failure{Rule: rule.Name, Field: rule.Field, Operator: rule.Operator, Expected: rule.Value}
+		switch {
+		case !ok:
+			failure.Message = fmt.Sprintf("%s is missing", rule.Field)
+		case !operators[rule.Operator](actual, rule.Value):
+			failure.Actual = &actual
+			failure.Message = fmt.Sprintf("%s is %g, expected %s %g", rule.Field, actual, rule.Operator, rule.Value)
+		default:
+			continue
+		}
+
+		verdict.Passed = false
+		verdict.Failures = append(verdict.Failures, failure)
+	}
+	return verdict
+}
+
+func valueOf(field string, dependency dependenciesloader.DependencyDetails, advisories []database.AdvisoryFinding) (float64, bool) {
+	switch field {
+	case FieldOverallScore:
+		return dependency.Scorecard.OverallScore, true
+	case FieldStars:
+		return float64(dependency.StarsCount), true
+	case FieldForks:
+		return float64(dependency.ForksCount), true
+	case FieldOpenIssues:
+		return float64(dependency.OpenIssuesCount), true
+	case FieldAdvisories:
+		return float64(len(uniqueAdvisories(advisories))), true
+	case FieldCriticalAdvisories:
+		return countAtLeast(advisories, criticalCVSS3Score), true
+	case FieldHighAdvisories:
+		return countAtLeast(advisories, highCVSS3Score), true
+	case FieldMaxCVSS3Score:
+		max := 0.0
+		for _, advisory := range advisories {
+			if advisory.CVSS3Score > max {
+				max = advisory.CVSS3Score
+			}
+		}
+		return max, true
+	}
+
+	if name, ok := strings.CutPrefix(field, checkFieldPrefix); ok {
+		for _, check := range dependency.Scorecard.Checks {
+			// Checks scored -1 are not applicable to the project.
+			if check.Name == name && check.Score >= 0 {
+				return float64(check.Score), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// uniqueAdvisories drops duplicates of a vulnerability reported by more than one source. Advisories sharing
+// an ID or an alias are the same vulnerability, e.g. GHSA-… of deps.dev and GO-… of OSV aliasing it, of which
+// the highest scored one is kept.
+func uniqueAdvisories(advisories []database.AdvisoryFinding) []database.AdvisoryFinding {
+	// groups of advisories are merged whenever an advisory shares an identifier with an earlier one
+	group := make([]int, len(advisories))
+	var find func(i int) int
+	find = func(i int) int {
+		if group[i] != i {
+			group[i] = find(group[i])
+		}
+		return group[i]
+	}
+	byIdentifier := map[string]int{}
+	for i, advisory := range advisories {
+		group[i] = i
+		for _, identifier := range append([]string{advisory.AdvisoryKey.ID}, advisory.Aliases...) {
+			if j, ok := byIdentifier[identifier]; ok {
+				group[find(i)] = find(j)
+			} else {
+				byIdentifier[identifier] = i
+			}
+		}
+	}
+
+	highest := map[int]int{}
+	order := []int{}
+	for i, advisory := range advisories {
+		g := find(i)
+		j, ok := highest[g]
+		if !ok {
+			order = append(order, g)
+		}
+		if !ok || advisory.CVSS3Score > advisories[j].CVSS3Score {
+			highest[g] = i
+		}
+	}
+	unique := []database.AdvisoryFinding{}
+	for _, g := range order {
+		unique = append(unique, advisories[highest[g]])
+	}
+	return unique
+}
+
+func countAtLeast(advisories []database.AdvisoryFinding, score float64) float64 {
+	count := 0.0
+	for _, advisory := range uniqueAdvisories(advisories) {
+		if advisory.CVSS3Score >= score {
+			count++
+		}
+	}
+	return count
+}
+
+// AdvisoriesByID indexes vulnerable dependencies by the project they belong to, to be passed to Evaluate.
+// Versions which belong to no project have no details to evaluate and are left out.
+func AdvisoriesByID(vulnerable []database.VulnerableDependency) map[string][]database.AdvisoryFinding {
+	advisories := map[string][]database.AdvisoryFinding{}
+	for _, dependency := range vulnerable {
+		if dependency.ProjectKeyID == "" {
+			continue
+		}
+		advisories[dependency.ProjectKeyID] = append(advisories[dependency.ProjectKeyID], dependency.Advisories...)
+	}
+	return advisories
+}
@@ -0,0 +1,175 @@
+package health
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+func TestEvaluate(t *testing.T) {
+	healthy := dependenciesloader.DependencyDetails{
+		ProjectKey: dependenciesloader.ProjectKey{ID: "github.com/cli/cli"},
+		StarsCount: 38228,
+		Scorecard: dependenciesloader.Scorecard{
+			OverallScore: 7.1,
+			Checks:       []dependenciesloader.Check{{Name: "Maintained", Score: 10}},
+		},
+	}
+	unhealthy := dependenciesloader.DependencyDetails{
+		ProjectKey: dependenciesloader.ProjectKey{ID: "github.com/briandowns/spinner"},
+		StarsCount: 20,
+		Scorecard: dependenciesloader.Scorecard{
+			OverallScore: 4.2,
+			Checks:       []dependenciesloader.Check{{Name: "Maintained", Score: -1}},
+		},
+	}
+	advisories := AdvisoriesByID([]database.VulnerableDependency{
+		{
+			VersionKey:   dependenciesloader.VersionKey{Name: "github.com/briandowns/spinner"},
+			ProjectKeyID: "github.com/briandowns/spinner",
+			Advisories: []database.AdvisoryFinding{
+				{Advisory: dependenciesloader.Advisory{AdvisoryKey: dependenciesloader.AdvisoryKey{ID: "GHSA-1"}, CVSS3Score: 9.8}, Source: database.SourceDepsDev},
+				{Advisory: dependenciesloader.Advisory{AdvisoryKey: dependenciesloader.AdvisoryKey{ID: "GHSA-1"}, CVSS3Score: 9.8}, Source: database.SourceOsv},
+			},
+		},
+	})
+
+	report := DefaultPolicy.Evaluate([]dependenciesloader.DependencyDetails{healthy, unhealthy}, advisories)
+
+	if report.Passed || report.Failed != 1 {
+		t.Fatalf("unexpected gate result: %+v", report)
+	}
+	if !report.Verdicts[0].Passed {
+		t.Fatalf("healthy dependency failed: %+v", report.Verdicts[0])
+	}
+
+	failed := map[string]bool{}
+	for _, failure := range report.Verdicts[1].Failures {
+		failed[failure.Rule] = true
+	}
+	for _, rule := range []string{"overall-score", "maintained", "no-critical-advisories", "popular"} {
+		if !failed[rule] {
+			t.Fatalf("rule %s should fail, got failures: %+v", rule, report.Verdicts[1].Failures)
+		}
+	}
+
+	skipping := Policy{Rules: []Rule{{Name: "maintained", Field: "check:Maintained", Operator: ">", Value: 0, SkipIfMissing: true}}}
+	if report := skipping.Evaluate([]dependenciesloader.DependencyDetails{unhealthy}, nil); !report.Passed {
+		t.Fatalf("missing check should be skipped: %+v", report)
+	}
+}
+
+func TestEvaluateStoredAdvisories(t *testing.T) {
+	db, err := database.NewSQLiteDB(path.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	defer db.CloseDbConnection()
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+
+	var dependencies dependenciesloader.Dependencies
+	readMock(t, "dependencies_mock.json", &dependencies)
+	var details struct {
+		Dependencies []dependenciesloader.DependencyDetails `json:"dependencies"`
+	}
+	readMock(t, "dependencies_details_mock.json", &details)
+	if err := db.LoadDependencies(dependencies.Nodes); err != nil {
+		t.Fatal("failed to load dependencies:", err)
+	}
+	if err := db.LoadDetailedDependencies(details.Dependencies[:5]); err != nil {
+		t.Fatal("failed to load details:", err)
+	}
+	// as returned by deps.dev for the modules
+	if err := db.LinkVersionKeys(map[string]string{
+		"github.com/cli/cli":               "github.com/cli/cli",
+		"github.com/AlecAivazis/survey/v2": "github.com/alecaivazis/survey",
+	}); err != nil {
+		t.Fatal("failed to link version keys:", err)
+	}
+	advisory := dependenciesloader.Advisory{AdvisoryKey: dependenciesloader.AdvisoryKey{ID: "GHSA-1"}, CVSS3Score: 9.8}
+	versionAdvisories := []dependenciesloader.VersionAdvisories{{VersionKey: dependencies.Nodes[1].VersionKey, Advisories: []dependenciesloader.Advisory{advisory}}}
+	if err := db.LoadAdvisories(versionAdvisories, database.SourceDepsDev); err != nil {
+		t.Fatal("failed to load advisories:", err)
+	}
+
+	vulnerable, err := db.GetVulnerableDependencies()
+	if err != nil {
+		t.Fatal("failed to get vulnerable dependencies:", err)
+	}
+	policy := Policy{Rules: []Rule{{Name: "no-critical-advisories", Field: FieldCriticalAdvisories, Operator: "==", Value: 0}}}
+	report := policy.Evaluate(details.Dependencies[:2], AdvisoriesByID(vulnerable))
+
+	if report.Failed != 1 || report.Verdicts[1].ProjectKeyID != "github.com/alecaivazis/survey" || report.Verdicts[1].Passed {
+		t.Fatalf("want the project of github.com/AlecAivazis/survey/v2 failing, got: %+v", report)
+	}
+}
+
+func readMock(t *testing.T, filename string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(path.Join("..", "database", "test_data", filename))
+	if err != nil {
+		t.Fatal("failed to read mock data:", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal("failed to parse mock data:", err)
+	}
+}
+
+func TestUniqueAdvisories(t *testing.T) {
+	finding := func(id string, score float64, source string, aliases ...string) database.AdvisoryFinding {
+		return database.AdvisoryFinding{
+			Advisory: dependenciesloader.Advisory{AdvisoryKey: dependenciesloader.AdvisoryKey{ID: id}, Aliases: aliases, CVSS3Score: score},
+			Source:   source,
+		}
+	}
+	advisories := []database.AdvisoryFinding{
+		finding("GHSA-1", 7.5, database.SourceDepsDev, "CVE-1"),
+		finding("GO-1", 9.8, database.SourceOsv, "GHSA-1", "CVE-1"),
+		finding("GO-2", 8.1, database.SourceOsv, "CVE-2"),
+		finding("GHSA-3", 5.3, database.SourceDepsDev, "CVE-3"),
+		finding("GHSA-2", 8.1, database.SourceDepsDev, "CVE-2"),
+	}
+
+	got := []string{}
+	for _, advisory := range uniqueAdvisories(advisories) {
+		got = append(got, advisory.AdvisoryKey.ID)
+	}
+	if diff := cmp.Diff([]string{"GO-1", "GO-2", "GHSA-3"}, got); diff != "" {
+		t.Fatalf("unexpected advisories (-want +got):\n%s", diff)
+	}
+	for field, want := range map[string]float64{FieldAdvisories: 3, FieldCriticalAdvisories: 1, FieldHighAdvisories: 2} {
+		if got, _ := valueOf(field, dependenciesloader.DependencyDetails{}, advisories); got != want {
+			t.Fatalf("want %s %g, got %g", field, want, got)
+		}
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	valid := []Rule{
+		{Field: FieldOverallScore, Operator: ">="},
+		{Field: "check:Code-Review", Operator: "!="},
+	}
+	for _, rule := range valid {
+		if err := rule.validate(); err != nil {
+			t.Fatalf("unexpected error for %+v: %v", rule, err)
+		}
+	}
+
+	invalid := []Rule{
+		{Field: FieldOverallScore, Operator: "=>"},
+		{Field: "check:", Operator: ">"},
+		{Field: "downloads", Operator: ">"},
+	}
+	for _, rule := range invalid {
+		if err := rule.validate(); err == nil {
+			t.Fatalf("expected an error for %+v", rule)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package licenses
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expression, want string
+	}{
+		{"MIT", "MIT"},
+		{"MIT OR Apache-2.0", "MIT OR Apache-2.0"},
+		{"(MIT OR Apache-2.0) AND BSD-3-Clause", "(MIT OR Apache-2.0) AND BSD-3-Clause"},
+		{"MIT OR Apache-2.0 AND BSD-3-Clause", "MIT OR Apache-2.0 AND BSD-3-Clause"},
+		{"GPL-2.0+ with Classpath-exception-2.0", "GPL-2.0+ WITH Classpath-exception-2.0"},
+		{"LicenseRef-custom", "LicenseRef-custom"},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.expression)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", tt.expression, err)
+		}
+		if got.String() != tt.want {
+			t.Fatalf("unexpected expression parsed from %q, want: %s, got: %s", tt.expression, tt.want, got)
+		}
+	}
+
+	if _, ok := mustParse(t, "MIT OR Apache-2.0 AND BSD-3-Clause").(Or); !ok {
+		t.Fatal("AND should bind stronger than OR")
+	}
+
+	for _, invalid := range []string{"", "MIT OR", "(MIT", "MIT AND AND ISC", "(MIT OR ISC) WITH exception", "MIT ISC"} {
+		if _, err := Parse(invalid); err == nil {
+			t.Fatalf("expected an error for invalid expression %q", invalid)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	policy := Policy{
+		Allowed:        []string{"MIT", "Apache-2.0", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		ReviewRequired: []string{"MPL-2.0"},
+		Denied:         []string{"AGPL-*", "GPL-2.0-only"},
+		Default:        StatusReview,
+	}
+
+	tests := []struct {
+		license, want string
+	}{
+		{"MIT", StatusAllowed},
+		{"mit", StatusAllowed},
+		{"AGPL-3.0-only", StatusDenied},
+		{"MIT OR AGPL-3.0-only", StatusAllowed},
+		{"MIT AND AGPL-3.0-only", StatusDenied},
+		{"MIT AND MPL-2.0", StatusReview},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", StatusAllowed},
+		{"GPL-2.0-only", StatusDenied},
+		{"BSD-3-Clause", StatusReview},
+		{"non-standard", StatusReview},
+		{"", StatusReview},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Evaluate("github.com/cli/cli", tt.license); got.Status != tt.want {
+			t.Fatalf("unexpected status of %q, want: %s, got: %s (%s)", tt.license, tt.want, got.Status, got.Reason)
+		}
+	}
+
+	report := policy.EvaluateAll([]dependenciesloader.DependencyDetails{
+		{ProjectKey: dependenciesloader.ProjectKey{ID: "a"}, License: "MIT"},
+		{ProjectKey: dependenciesloader.ProjectKey{ID: "b"}, License: "AGPL-3.0-or-later"},
+	})
+	if report.Passed || len(report.Violations) != 1 || len(report.Allowed) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestEvaluateExactBeforeWildcard(t *testing.T) {
+	policy := Policy{
+		Allowed:        []string{"MIT", "GPL-2.0-only WITH Classpath-exception-2.0", "LGPL-2.1-only"},
+		ReviewRequired: []string{"GPL-*"},
+		Denied:         []string{"LGPL-*"},
+		Default:        StatusReview,
+	}
+
+	tests := []struct {
+		license, want string
+	}{
+		{"GPL-2.0-only WITH Classpath-exception-2.0", StatusAllowed},
+		{"GPL-2.0-only", StatusReview},
+		{"GPL-3.0-or-later", StatusReview},
+		{"LGPL-2.1-only", StatusAllowed},
+		{"LGPL-3.0-only", StatusDenied},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Evaluate("github.com/cli/cli", tt.license); got.Status != tt.want {
+			t.Fatalf("unexpected status of %q, want: %s, got: %s (%s)", tt.license, tt.want, got.Status, got.Reason)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	want := Policy{
+		Allowed:        []string{"MIT", "Apache-2.0"},
+		ReviewRequired: []string{"LGPL-*"},
+		Denied:         []string{"AGPL-*"},
+		Default:        StatusDenied,
+	}
+	const yamlPolicy = `# licenses of the company policy
+allowed: [MIT, Apache-2.0]
+reviewRequired:
+  - LGPL-*
+denied:
+  - AGPL-*
+default: denied
+`
+	const jsonPolicy = `{"allowed": ["MIT", "Apache-2.0"], "reviewRequired": ["LGPL-*"], "denied": ["AGPL-*"], "default": "denied"}`
+
+	tests := []struct {
+		name, content string
+	}{
+		{"policy.yaml", yamlPolicy},
+		{"policy.YML", yamlPolicy},
+		{"policy.json", jsonPolicy},
+		{"policy", yamlPolicy},
+		{"policy", jsonPolicy},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := LoadPolicy(path)
+		if err != nil {
+			t.Fatalf("failed to load %s: %v", tt.name, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("unexpected policy loaded from %s (-want +got):\n%s", tt.name, diff)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte("default: forbidden\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(path); err == nil {
+		t.Fatal("expected an error for an invalid default status")
+	}
+}
+
+func mustParse(t *testing.T, expression string) Expression {
+	e, err := Parse(expression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
@@ -0,0 +1,212 @@
+package licenses
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StatusAllowed = "allowed"
+	StatusReview  = "review"
+	StatusDenied  = "denied"
+)
+
+// Policy lists SPDX license identifiers, e.g. "MIT" or "GPL-2.0-only WITH Classpath-exception-2.0".
+// An identifier ending with "*" matches every license starting with it, e.g. "AGPL-*". Exact identifiers
+// take precedence over wildcards whichever list they are on, e.g. an allowed
+// "GPL-2.0-only WITH Classpath-exception-2.0" beats "GPL-*" requiring review. Licenses on none of the
+// lists get the Default status.
+type Policy struct {
+	Allowed        []string `json:"allowed" yaml:"allowed"`
+	Denied         []string `json:"denied" yaml:"denied"`
+	ReviewRequired []string `json:"reviewRequired" yaml:"reviewRequired"`
+	Default        string   `json:"default" yaml:"default"`
+}
+
+// DefaultPolicy is used when no policy file is given.
+var DefaultPolicy = Policy{
+	Allowed: []string{
+		"MIT", "Apache-2.0", "BSD-2-Clause", "BSD-3-Clause", "ISC", "0BSD", "Unlicense", "Zlib", "CC0-1.0",
+	},
+	ReviewRequired: []string{
+		"MPL-2.0", "LGPL-*", "EPL-*", "GPL-*",
+	},
+	Denied: []string{
+		"AGPL-*", "SSPL-1.0",
+	},
+	Default: StatusReview,
+}
+
+// LoadPolicy reads a license policy from a JSON or YAML file. Files named *.yaml or *.yml are read
+// as YAML, other files as JSON unless they don't start with "{". An empty path means DefaultPolicy.
+func LoadPolicy(path string) (Policy, error) {
+	if path == "" {
+		return DefaultPolicy, nil
+	}
+
+	var policy Policy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, fmt.Errorf("failed to read license policy: %w", err)
+	}
+	if err := unmarshalPolicy(path, data, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse license policy: %w", err)
+	}
+
+	switch policy.Default {
+	case "":
+		policy.Default = StatusReview
+	case StatusAllowed, StatusReview, StatusDenied:
+	default:
+		return policy, fmt.Errorf("invalid default license status: %s", policy.Default)
+	}
+
+	return policy, nil
+}
+
+func unmarshalPolicy(path string, data []byte, policy *Policy) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(data, policy)
+	case ".json":
+		return json.Unmarshal(data, policy)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return json.Unmarshal(data, policy)
+	}
+	return yaml.Unmarshal(data, policy)
+}
+
+type Result struct {
+	ProjectKeyID string `json:"projectKeyId"`
+	Purl         string `json:"purl,omitempty"`
+	License      string `json:"license"`
+	Status       string `json:"status"`
+	Reason       string `json:"reason"`
+}
+
+type Report struct {
+	Passed         bool     `json:"passed"`
+	Violations     []Result `json:"violations"`
+	ReviewRequired []Result `json:"reviewRequired"`
+	Allowed        []Result `json:"allowed"`
+}
+
+// EvaluateAll evaluates every dependency. The report passes when no license is denied.
+func (p Policy) EvaluateAll(dependencies []dependenciesloader.DependencyDetails) Report {
+	report := Report{Passed: true, Violations: []Result{}, ReviewRequired: []Result{}, Allowed: []Result{}}
+	for _, dependency := range dependencies {
+		result := p.Evaluate(dependency.ProjectKey.ID, dependency.License)
+		result.Purl = dependency.Purl
+		switch result.Status {
+		case StatusDenied:
+			report.Passed = false
+			report.Violations = append(report.Violations, result)
+		case StatusReview:
+			report.ReviewRequired = append(report.ReviewRequired, result)
+		default:
+			report.Allowed = append(report.Allowed, result)
+		}
+	}
+	return report
+}
+
+// Evaluate checks a license expression against the policy. For "A OR B" the most permissive
+// choice counts, for "A AND B" the most restrictive one.
+func (p Policy) Evaluate(projectKeyID, license string) Result {
+	result := Result{ProjectKeyID: projectKeyID, License: license}
+
+	expression, err := Parse(license)
+	if err != nil {
+		result.Status = p.defaultStatus()
+		result.Reason = fmt.Sprintf("license is not a valid SPDX expression: %v", err)
+		return result
+	}
+
+	result.Status, result.Reason = p.evaluate(expression)
+	return result
+}
+
+func (p Policy) evaluate(e Expression) (string, string) {
+	switch e := e.(type) {
+	case And:
+		leftStatus, leftReason := p.evaluate(e.Left)
+		rightStatus, rightReason := p.evaluate(e.Right)
+		if rank(leftStatus) <= rank(rightStatus) {
+			return leftStatus, leftReason
+		}
+		return rightStatus, rightReason
+	case Or:
+		leftStatus, leftReason := p.evaluate(e.Left)
+		rightStatus, rightReason := p.evaluate(e.Right)
+		if rank(leftStatus) >= rank(rightStatus) {
+			return leftStatus, leftReason
+		}
+		return rightStatus, rightReason
+	case License:
+		return p.evaluateLicense(e)
+	}
+	return p.defaultStatus(), "unsupported expression"
+}
+
+func (p Policy) evaluateLicense(l License) (string, string) {
+	candidates := []string{l.String()}
+	if l.Exception != "" || l.OrLater {
+		candidates = append(candidates, l.ID)
+	}
+
+	for _, wildcard := range []bool{false, true} {
+		for _, candidate := range candidates {
+			if matches(p.Denied, candidate, wildcard) {
+				return StatusDenied, fmt.Sprintf("%s is denied", l)
+			}
+			if matches(p.ReviewRequired, candidate, wildcard) {
+				return StatusReview, fmt.Sprintf("%s requires review", l)
+			}
+			if matches(p.Allowed, candidate, wildcard) {
+				return StatusAllowed, fmt.Sprintf("%s is allowed", l)
+			}
+		}
+	}
+	return p.defaultStatus(), fmt.Sprintf("%s is not listed in the policy", l)
+}
+
+func (p Policy) defaultStatus() string {
+	if p.Default == "" {
+		return StatusReview
+	}
+	return p.Default
+}
+
+// matches reports whether one of the patterns matches the license, only wildcard patterns if wildcard
+// is set and only exact ones otherwise.
+func matches(patterns []string, license string, wildcard bool) bool {
+	for _, pattern := range patterns {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		switch {
+		case ok != wildcard:
+			continue
+		case ok && strings.HasPrefix(strings.ToLower(license), strings.ToLower(prefix)),
+			!ok && strings.EqualFold(pattern, license):
+			return true
+		}
+	}
+	return false
+}
+
+func rank(status string) int {
+	switch status {
+	case StatusAllowed:
+		return 2
+	case StatusReview:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,203 @@
+package licenses
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Expression is a parsed SPDX license expression, see https://spdx.github.io/spdx-spec/v2.3/SPDX-license-expressions/.
+type Expression interface {
+	String() string
+}
+
+// License is a single license identifier, optionally with the "+" (or later) suffix and a WITH exception.
+type License struct {
+	ID        string
+	OrLater   bool
+	Exception string
+}
+
+type And struct {
+	Left, Right Expression
+}
+
+type Or struct {
+	Left, Right Expression
+}
+
+func (l License) String() string {
+	s := l.ID
+	if l.OrLater {
+		s += "+"
+	}
+	if l.Exception != "" {
+		s += " WITH " + l.Exception
+	}
+	return s
+}
+
+func (a And) String() string {
+	return wrap(a.Left, true) + " AND " + wrap(a.Right, true)
+}
+
+func (o Or) String() string {
+	return wrap(o.Left, false) + " OR " + wrap(o.Right, false)
+}
+
+// wrap adds parentheses where precedence requires them: OR binds weaker than AND.
+func wrap(e Expression, inAnd bool) string {
+	if _, ok := e.(Or); ok && inAnd {
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+// Parse parses an SPDX license expression. Operators are accepted in any case.
+func Parse(expression string) (Expression, error) {
+	p := &parser{tokens: tokenize(expression)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid license expression %q: %w", expression, err)
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("invalid license expression %q: unexpected %q", expression, p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+func tokenize(expression string) []string {
+	tokens := []string{}
+	current := strings.Builder{}
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range expression {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peekOperator(operator string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], operator)
+}
+
+func (p *parser) parseOr() (Expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("OR") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expression, error) {
+	left, err := p.parseWith()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("AND") {
+		p.pos++
+		right, err := p.parseWith()
+		if err != nil {
+			return nil, err
+		}
+		left = And{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseWith() (Expression, error) {
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.peekOperator("WITH") {
+		return e, nil
+	}
+	license, ok := e.(License)
+	if !ok {
+		return nil, fmt.Errorf("WITH must follow a license identifier")
+	}
+	p.pos++
+	if p.pos >= len(p.tokens) || !isIdentifier(p.tokens[p.pos]) {
+		return nil, fmt.Errorf("missing exception identifier after WITH")
+	}
+	license.Exception = p.tokens[p.pos]
+	p.pos++
+	return license, nil
+}
+
+func (p *parser) parsePrimary() (Expression, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+
+	if token == "(" {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return e, nil
+	}
+
+	if strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH") {
+		return nil, fmt.Errorf("unexpected operator %s", token)
+	}
+
+	license := License{ID: token}
+	if strings.HasSuffix(token, "+") {
+		license = License{ID: strings.TrimSuffix(token, "+"), OrLater: true}
+	}
+	if !isIdentifier(license.ID) {
+		return nil, fmt.Errorf("invalid license identifier %q", token)
+	}
+	return license, nil
+}
+
+// isIdentifier checks idstring from the SPDX grammar: letters, digits, "." and "-",
+// with an optional "DocumentRef-...:" prefix for license references.
+func isIdentifier(s string) bool {
+	if s == "" || s == "(" || s == ")" {
+		return false
+	}
+	for _, r := range s {
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == ':') {
+			return false
+		}
+	}
+	return true
+}
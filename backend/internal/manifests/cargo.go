@@ -0,0 +1,186 @@
+package manifests
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/versions"
+)
+
+// CargoLockParser reads Cargo.lock files. The local package no other package depends on
+// is the SELF node, of crates locked in several versions the highest one is stored.
+type CargoLockParser struct{}
+
+type cargoPackage struct {
+	name         string
+	version      string
+	source       string
+	dependencies []string
+}
+
+func (CargoLockParser) Matches(filename string) bool {
+	return filename == "Cargo.lock"
+}
+
+func (CargoLockParser) Parse(data []byte) (dependenciesloader.Dependencies, error) {
+	packages, err := parseCargoLock(data)
+	if err != nil {
+		return dependenciesloader.Dependencies{}, err
+	}
+
+	// dependencies refer to packages by name, or name and version if several versions are locked
+	byName := map[string][]int{}
+	for i, pkg := range packages {
+		byName[pkg.name] = append(byName[pkg.name], i)
+	}
+	resolve := func(reference string) (int, bool) {
+		fields := strings.Fields(reference)
+		if len(fields) == 0 {
+			return 0, false
+		}
+		candidates := byName[fields[0]]
+		if len(fields) == 1 && len(candidates) == 1 {
+			return candidates[0], true
+		}
+		for _, i := range candidates {
+			if len(fields) > 1 && packages[i].version == fields[1] {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	dependedOn := map[int]bool{}
+	for _, pkg := range packages {
+		for _, reference := range pkg.dependencies {
+			if i, ok := resolve(reference); ok {
+				dependedOn[i] = true
+			}
+		}
+	}
+	root := -1
+	for i, pkg := range packages {
+		if pkg.source == "" && !dependedOn[i] {
+			if root >= 0 {
+				// a workspace with several members has no single root
+				root = -1
+				break
+			}
+			root = i
+		}
+	}
+
+	g := newGraph("CARGO")
+	if root >= 0 {
+		g.add(packages[root].name, packages[root].version, relationSelf)
+	}
+	nodes := make([]int, len(packages))
+	for i := range packages {
+		// every version of a crate is added when it first appears, the highest one first
+		versions := slices.Clone(byName[packages[i].name])
+		slices.SortStableFunc(versions, func(a, b int) int {
+			return compareCargoVersions(packages[b].version, packages[a].version)
+		})
+		for _, j := range versions {
+			nodes[j] = g.add(packages[j].name, packages[j].version, "")
+		}
+	}
+	for from, pkg := range packages {
+		for _, reference := range pkg.dependencies {
+			if i, ok := resolve(reference); ok {
+				g.addEdge(nodes[from], nodes[i], packages[i].version)
+			}
+		}
+	}
+
+	g.setRelations()
+	return g.dependencies, nil
+}
+
+func compareCargoVersions(a, b string) int {
+	va, errA := versions.Parse("CARGO", a)
+	vb, errB := versions.Parse("CARGO", b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return va.Compare(vb)
+}
+
+// parseCargoLock reads [[package]] tables of the lockfile. Cargo.lock is TOML written by Cargo,
+// which uses only strings and arrays of strings in them, so a full TOML parser is not needed.
+func parseCargoLock(data []byte) ([]cargoPackage, error) {
+	var packages []cargoPackage
+	var pkg *cargoPackage
+	var array *[]string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if array != nil {
+			if text == "]" {
+				array = nil
+				continue
+			}
+			value, err := strconv.Unquote(strings.TrimSuffix(text, ","))
+			if err != nil {
+				return nil, fmt.Errorf("invalid Cargo.lock line %d: %q", line, text)
+			}
+			*array = append(*array, value)
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			pkg = nil
+			if text == "[[package]]" {
+				packages = append(packages, cargoPackage{})
+				pkg = &packages[len(packages)-1]
+			}
+			continue
+		}
+		if pkg == nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid Cargo.lock line %d: %q", line, text)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if key == "dependencies" {
+			switch value {
+			case "[":
+				array = &pkg.dependencies
+			case "[]":
+			default:
+				return nil, fmt.Errorf("invalid Cargo.lock line %d: %q", line, text)
+			}
+			continue
+		}
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "name":
+			pkg.name = unquoted
+		case "version":
+			pkg.version = unquoted
+		case "source":
+			pkg.source = unquoted
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Cargo.lock: %w", err)
+	}
+
+	return packages, nil
+}
@@ -0,0 +1,138 @@
+// Package manifests builds dependency graphs of projects from their manifests and lockfiles,
+// for projects which are not published and can't be resolved by deps.dev.
+package manifests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+const (
+	relationSelf     = "SELF"
+	relationDirect   = "DIRECT"
+	relationIndirect = "INDIRECT"
+)
+
+// ManifestParser reads the dependency graph of a project from a manifest or lockfile of its package system.
+// Nodes carry the deps.dev system of the parser. The project itself is the SELF node if the file names it.
+type ManifestParser interface {
+	// Matches reports whether the parser reads files of the name, e.g. package-lock.json.
+	Matches(filename string) bool
+	Parse(data []byte) (dependenciesloader.Dependencies, error)
+}
+
+// Parsers are the available parsers, ParserFor picks one of them by the file name.
+var Parsers = []ManifestParser{
+	NpmLockParser{},
+	RequirementsParser{},
+	CargoLockParser{},
+	PomParser{},
+}
+
+func ParserFor(path string) (ManifestParser, error) {
+	filename := filepath.Base(path)
+	for _, parser := range Parsers {
+		if parser.Matches(filename) {
+			return parser, nil
+		}
+	}
+	return nil, fmt.Errorf("no parser for %s, expected package-lock.json, requirements.txt, Cargo.lock or pom.xml", filename)
+}
+
+// FileSource reads the graph from a manifest file, every time it is asked for it.
+type FileSource struct {
+	path   string
+	parser ManifestParser
+}
+
+// NewFileSource returns a source of the manifest, with the parser picked by the file name.
+func NewFileSource(path string) (FileSource, error) {
+	parser, err := ParserFor(path)
+	if err != nil {
+		return FileSource{}, err
+	}
+	return FileSource{path, parser}, nil
+}
+
+func (s FileSource) Dependencies() (dependenciesloader.Dependencies, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return s.parser.Parse(data)
+}
+
+// graph collects nodes and edges. A package locked in several versions has a node of every version,
+// so that edges point at the version which was resolved. VersionKeys are stored by name, so only the
+// first node of a name is stored; parsers add the version to keep first.
+type graph struct {
+	system       string
+	dependencies dependenciesloader.Dependencies
+	indexes      map[string]int
+	edges        map[[2]int]bool
+}
+
+func newGraph(system string) *graph {
+	return &graph{
+		system:       system,
+		dependencies: dependenciesloader.Dependencies{Nodes: []dependenciesloader.Node{}, Edges: []dependenciesloader.Edge{}},
+		indexes:      map[string]int{},
+		edges:        map[[2]int]bool{},
+	}
+}
+
+// add adds the version of the package if it is not in the graph yet and returns its node.
+func (g *graph) add(name, version, relation string) int {
+	key := name + "@" + version
+	if index, ok := g.indexes[key]; ok {
+		return index
+	}
+	index := len(g.dependencies.Nodes)
+	g.indexes[key] = index
+	g.dependencies.Nodes = append(g.dependencies.Nodes, dependenciesloader.Node{
+		VersionKey: dependenciesloader.VersionKey{System: g.system, Name: name, Version: version},
+		Relation:   relation,
+		Errors:     []string{},
+	})
+	return index
+}
+
+func (g *graph) addEdge(from, to int, requirement string) {
+	if from == to || g.edges[[2]int{from, to}] {
+		return
+	}
+	g.edges[[2]int{from, to}] = true
+	g.dependencies.Edges = append(g.dependencies.Edges, dependenciesloader.Edge{FromNode: from, ToNode: to, Requirement: requirement})
+}
+
+// setRelations marks nodes depended on by the SELF node, or by no other node if there is none, as DIRECT
+// and all other nodes, except SELF, as INDIRECT.
+func (g *graph) setRelations() {
+	root := -1
+	for i, node := range g.dependencies.Nodes {
+		if node.Relation == relationSelf {
+			root = i
+		}
+	}
+	direct := map[int]bool{}
+	dependedOn := map[int]bool{}
+	for _, edge := range g.dependencies.Edges {
+		if edge.FromNode == root {
+			direct[edge.ToNode] = true
+		}
+		dependedOn[edge.ToNode] = true
+	}
+	for i := range g.dependencies.Nodes {
+		node := &g.dependencies.Nodes[i]
+		switch {
+		case node.Relation == relationSelf:
+		case direct[i] || (root < 0 && !dependedOn[i]):
+			node.Relation = relationDirect
+		default:
+			node.Relation = relationIndirect
+		}
+	}
+}
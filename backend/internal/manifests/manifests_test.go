@@ -0,0 +1,140 @@
+package manifests
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+type parsedNode struct {
+	System, Name, Version, Relation string
+}
+
+// summarize makes graphs readable in test expectations: nodes in order and edges by names and
+// versions of the nodes.
+func summarize(dependencies dependenciesloader.Dependencies) ([]parsedNode, [][2]string) {
+	nodes := []parsedNode{}
+	for _, node := range dependencies.Nodes {
+		nodes = append(nodes, parsedNode{node.VersionKey.System, node.VersionKey.Name, node.VersionKey.Version, node.Relation})
+	}
+	edges := [][2]string{}
+	for _, edge := range dependencies.Edges {
+		from, to := dependencies.Nodes[edge.FromNode].VersionKey, dependencies.Nodes[edge.ToNode].VersionKey
+		edges = append(edges, [2]string{from.Name + "@" + from.Version, to.Name + "@" + to.Version})
+	}
+	return nodes, edges
+}
+
+func TestParsers(t *testing.T) {
+	tests := []struct {
+		file      string
+		wantNodes []parsedNode
+		wantEdges [][2]string
+	}{
+		{
+			file: "package-lock.json",
+			wantNodes: []parsedNode{
+				{"NPM", "internal-app", "1.0.0", "SELF"},
+				{"NPM", "@angular/core", "17.0.0", "DIRECT"},
+				{"NPM", "debug", "4.3.4", "DIRECT"},
+				{"NPM", "ms", "2.1.3", "DIRECT"},
+				{"NPM", "tslib", "2.6.2", "INDIRECT"},
+				{"NPM", "ms", "2.1.2", "INDIRECT"},
+			},
+			wantEdges: [][2]string{
+				{"internal-app@1.0.0", "@angular/core@17.0.0"},
+				{"internal-app@1.0.0", "debug@4.3.4"},
+				{"internal-app@1.0.0", "ms@2.1.3"},
+				{"@angular/core@17.0.0", "tslib@2.6.2"},
+				{"debug@4.3.4", "ms@2.1.2"},
+			},
+		},
+		{
+			file: "requirements.txt",
+			wantNodes: []parsedNode{
+				{"PYPI", "django", "4.2.7", "DIRECT"},
+				{"PYPI", "requests", "", "DIRECT"},
+				{"PYPI", "zope-interface", "6.1", "DIRECT"},
+				{"PYPI", "python-dateutil", "2.8.2", "DIRECT"},
+				{"PYPI", "numpy", "1.26.2", "DIRECT"},
+			},
+			wantEdges: [][2]string{},
+		},
+		{
+			file: "Cargo.lock",
+			wantNodes: []parsedNode{
+				{"CARGO", "internal-service", "0.1.0", "SELF"},
+				{"CARGO", "bitflags", "2.4.1", "DIRECT"},
+				{"CARGO", "bitflags", "1.3.2", "INDIRECT"},
+				{"CARGO", "serde", "1.0.193", "DIRECT"},
+			},
+			wantEdges: [][2]string{
+				{"internal-service@0.1.0", "bitflags@2.4.1"},
+				{"internal-service@0.1.0", "serde@1.0.193"},
+				{"serde@1.0.193", "bitflags@1.3.2"},
+			},
+		},
+		{
+			file: "pom.xml",
+			wantNodes: []parsedNode{
+				{"MAVEN", "com.acme:internal-service", "2.0.0", "SELF"},
+				{"MAVEN", "com.google.guava:guava", "32.1.2-jre", "DIRECT"},
+				{"MAVEN", "com.fasterxml.jackson.core:jackson-databind", "2.16.0", "DIRECT"},
+				{"MAVEN", "com.acme:internal-common", "2.0.0", "DIRECT"},
+			},
+			wantEdges: [][2]string{
+				{"com.acme:internal-service@2.0.0", "com.google.guava:guava@32.1.2-jre"},
+				{"com.acme:internal-service@2.0.0", "com.fasterxml.jackson.core:jackson-databind@2.16.0"},
+				{"com.acme:internal-service@2.0.0", "com.acme:internal-common@2.0.0"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			cwd, _ := os.Getwd()
+			source, err := NewFileSource(path.Join(cwd, "test_data", test.file))
+			if err != nil {
+				t.Fatal("failed to create source:", err)
+			}
+			dependencies, err := source.Dependencies()
+			if err != nil {
+				t.Fatal("failed to parse manifest:", err)
+			}
+			gotNodes, gotEdges := summarize(dependencies)
+			if diff := cmp.Diff(test.wantNodes, gotNodes); diff != "" {
+				t.Errorf("unexpected nodes (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantEdges, gotEdges); diff != "" {
+				t.Errorf("unexpected edges (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParserFor(t *testing.T) {
+	for file, want := range map[string]ManifestParser{
+		"app/package-lock.json":   NpmLockParser{},
+		"npm-shrinkwrap.json":     NpmLockParser{},
+		"requirements-dev.txt":    RequirementsParser{},
+		"/src/service/Cargo.lock": CargoLockParser{},
+		"pom.xml":                 PomParser{},
+	} {
+		got, err := ParserFor(file)
+		if err != nil || got != want {
+			t.Errorf("ParserFor(%s) = %T, %v, want %T", file, got, err, want)
+		}
+	}
+	if _, err := ParserFor("package.json"); err == nil {
+		t.Error("expected an error for package.json")
+	}
+}
+
+func TestNpmLockParserVersion1(t *testing.T) {
+	if _, err := (NpmLockParser{}).Parse([]byte(`{"lockfileVersion": 1, "dependencies": {}}`)); err == nil {
+		t.Error("expected an error for lockfile version 1")
+	}
+}
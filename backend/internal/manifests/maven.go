@@ -0,0 +1,130 @@
+package manifests
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+// PomParser reads Maven pom.xml files. The project is the SELF node and its dependencies are DIRECT,
+// a POM lists no transitive dependencies. Properties of the POM and versions from its
+// dependencyManagement section are resolved, parent POMs and profiles are not read.
+type PomParser struct{}
+
+type pom struct {
+	GroupID              string          `xml:"groupId"`
+	ArtifactID           string          `xml:"artifactId"`
+	Version              string          `xml:"version"`
+	Parent               pomArtifact     `xml:"parent"`
+	Properties           pomProperties   `xml:"properties"`
+	DependencyManagement []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+	Dependencies         []pomDependency `xml:"dependencies>dependency"`
+}
+
+type pomArtifact struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+}
+
+// pomProperties holds the elements of <properties>, whose names are arbitrary.
+type pomProperties map[string]string
+
+func (p *pomProperties) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*p = pomProperties{}
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			var value string
+			if err := d.DecodeElement(&value, &t); err != nil {
+				return err
+			}
+			(*p)[t.Name.Local] = strings.TrimSpace(value)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+var propertyPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+func (PomParser) Matches(filename string) bool {
+	return filename == "pom.xml"
+}
+
+func (PomParser) Parse(data []byte) (dependenciesloader.Dependencies, error) {
+	var project pom
+	if err := xml.Unmarshal(data, &project); err != nil {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("failed to decode pom.xml: %w", err)
+	}
+
+	// groupId and version are inherited from the parent if not set
+	if project.GroupID == "" {
+		project.GroupID = project.Parent.GroupID
+	}
+	if project.Version == "" {
+		project.Version = project.Parent.Version
+	}
+	if project.GroupID == "" || project.ArtifactID == "" {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("pom.xml has no groupId or artifactId")
+	}
+
+	properties := map[string]string{
+		"project.groupId":        project.GroupID,
+		"project.artifactId":     project.ArtifactID,
+		"project.version":        project.Version,
+		"project.parent.version": project.Parent.Version,
+		"pom.version":            project.Version,
+	}
+	for name, value := range project.Properties {
+		properties[name] = value
+	}
+	resolve := func(value string) string {
+		// properties may refer to other properties, the depth is limited to stop cycles
+		for i := 0; i < 10 && strings.Contains(value, "${"); i++ {
+			value = propertyPattern.ReplaceAllStringFunc(value, func(reference string) string {
+				if resolved, ok := properties[reference[2:len(reference)-1]]; ok {
+					return resolved
+				}
+				return reference
+			})
+		}
+		return strings.TrimSpace(value)
+	}
+
+	managed := map[string]string{}
+	for _, dependency := range project.DependencyManagement {
+		managed[resolve(dependency.GroupID)+":"+resolve(dependency.ArtifactID)] = resolve(dependency.Version)
+	}
+
+	g := newGraph("MAVEN")
+	root := g.add(resolve(project.GroupID)+":"+resolve(project.ArtifactID), resolve(project.Version), relationSelf)
+	for _, dependency := range project.Dependencies {
+		if dependency.Scope == "system" {
+			// system dependencies are local jars
+			continue
+		}
+		name := resolve(dependency.GroupID) + ":" + resolve(dependency.ArtifactID)
+		version := resolve(dependency.Version)
+		if version == "" {
+			version = managed[name]
+		}
+		g.addEdge(root, g.add(name, version, relationDirect), version)
+	}
+
+	return g.dependencies, nil
+}
@@ -0,0 +1,125 @@
+package manifests
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+// NpmLockParser reads package-lock.json and npm-shrinkwrap.json files of lockfile version 2 or 3.
+// Of packages installed in several versions, the one closest to the root of node_modules is stored.
+type NpmLockParser struct{}
+
+type npmLock struct {
+	Name            string                `json:"name"`
+	Version         string                `json:"version"`
+	LockfileVersion int                   `json:"lockfileVersion"`
+	Packages        map[string]npmPackage `json:"packages"`
+}
+
+type npmPackage struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Link                 bool              `json:"link"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+func (NpmLockParser) Matches(filename string) bool {
+	return filename == "package-lock.json" || filename == "npm-shrinkwrap.json"
+}
+
+func (NpmLockParser) Parse(data []byte) (dependenciesloader.Dependencies, error) {
+	var lock npmLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("failed to decode package-lock.json: %w", err)
+	}
+	if lock.Packages == nil {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("package-lock.json version %d is not supported, regenerate it with npm 7 or newer", lock.LockfileVersion)
+	}
+
+	g := newGraph("NPM")
+	nodes := map[string]int{}
+	if lock.Name != "" {
+		nodes[""] = g.add(lock.Name, lock.Version, relationSelf)
+	}
+
+	// Shallower paths first, so that hoisted versions are kept.
+	paths := make([]string, 0, len(lock.Packages))
+	for path := range lock.Packages {
+		if path != "" && strings.Contains(path, "node_modules/") && !lock.Packages[path].Link {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		di, dj := strings.Count(paths[i], "node_modules/"), strings.Count(paths[j], "node_modules/")
+		if di != dj {
+			return di < dj
+		}
+		return paths[i] < paths[j]
+	})
+	for _, path := range paths {
+		nodes[path] = g.add(npmPackageName(path), lock.Packages[path].Version, "")
+	}
+
+	for _, path := range append([]string{""}, paths...) {
+		from, ok := nodes[path]
+		if !ok {
+			continue
+		}
+		pkg := lock.Packages[path]
+		for _, requirements := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.OptionalDependencies, pkg.PeerDependencies} {
+			for _, name := range sortedNames(requirements) {
+				if to, ok := nodes[resolveNpmPackage(lock.Packages, path, name)]; ok {
+					g.addEdge(from, to, requirements[name])
+				}
+			}
+		}
+	}
+
+	g.setRelations()
+	return g.dependencies, nil
+}
+
+// npmPackageName returns the name of the package installed at the path, e.g. @scope/name for
+// node_modules/a/node_modules/@scope/name.
+func npmPackageName(path string) string {
+	return path[strings.LastIndex(path, "node_modules/")+len("node_modules/"):]
+}
+
+// resolveNpmPackage finds the path of the package required from the package at path the way Node.js does,
+// looking into node_modules of the package and then of its ancestors.
+func resolveNpmPackage(packages map[string]npmPackage, path, name string) string {
+	dir := path
+	for {
+		candidate := "node_modules/" + name
+		if dir != "" {
+			candidate = dir + "/" + candidate
+		}
+		if _, ok := packages[candidate]; ok {
+			return candidate
+		}
+		if dir == "" {
+			return ""
+		}
+		if i := strings.LastIndex(dir, "/node_modules/"); i >= 0 {
+			dir = dir[:i]
+		} else {
+			dir = ""
+		}
+	}
+}
+
+func sortedNames(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,78 @@
+package manifests
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+// RequirementsParser reads pip requirements files. Requirements files don't name the project
+// and hold no edges, so all requirements are DIRECT nodes of a graph without a SELF node.
+// Only versions pinned with == or === are kept, other requirements have no version.
+// Options, e.g. -r includes, editable installs and URLs are skipped.
+type RequirementsParser struct{}
+
+var (
+	requirementPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*(.*)$`)
+	pinnedPattern      = regexp.MustCompile(`^===?\s*([^,\s]+)$`)
+	separatorsPattern  = regexp.MustCompile(`[-_.]+`)
+)
+
+func (RequirementsParser) Matches(filename string) bool {
+	return strings.HasPrefix(filename, "requirements") && strings.HasSuffix(filename, ".txt")
+}
+
+func (RequirementsParser) Parse(data []byte) (dependenciesloader.Dependencies, error) {
+	g := newGraph("PYPI")
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var continued string
+	for line := 1; scanner.Scan(); line++ {
+		text := continued + scanner.Text()
+		if strings.HasSuffix(text, "\\") {
+			continued = strings.TrimSuffix(text, "\\")
+			continue
+		}
+		continued = ""
+
+		if i := strings.Index(text, " #"); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "-") || strings.Contains(text, "://") {
+			continue
+		}
+		if i := strings.Index(text, " --"); i >= 0 {
+			// per-requirement options, e.g. --hash
+			text = strings.TrimSpace(text[:i])
+		}
+		if i := strings.Index(text, ";"); i >= 0 {
+			// environment markers
+			text = strings.TrimSpace(text[:i])
+		}
+
+		match := requirementPattern.FindStringSubmatch(text)
+		if match == nil {
+			return dependenciesloader.Dependencies{}, fmt.Errorf("invalid requirement on line %d: %q", line, text)
+		}
+		version := ""
+		if pinned := pinnedPattern.FindStringSubmatch(strings.TrimSpace(match[3])); pinned != nil {
+			version = pinned[1]
+		}
+		g.add(normalizePythonName(match[1]), version, relationDirect)
+	}
+	if err := scanner.Err(); err != nil {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("failed to read requirements: %w", err)
+	}
+
+	return g.dependencies, nil
+}
+
+// normalizePythonName normalizes the package name as PEP 503 does.
+func normalizePythonName(name string) string {
+	return strings.ToLower(separatorsPattern.ReplaceAllString(name, "-"))
+}
@@ -0,0 +1,96 @@
+package osv
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+var cvss3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// CVSS3BaseScore calculates the base score of a CVSS v3.0 or v3.1 vector, such as
+// CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H, following the CVSS v3.1 specification.
+func CVSS3BaseScore(vector string) (float64, error) {
+	parts := strings.Split(vector, "/")
+	if len(parts) == 0 || !strings.HasPrefix(parts[0], "CVSS:3.") {
+		return 0, fmt.Errorf("invalid CVSS v3 vector: %s", vector)
+	}
+
+	metrics := map[string]string{}
+	for _, part := range parts[1:] {
+		metric, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return 0, fmt.Errorf("invalid CVSS v3 vector: %s", vector)
+		}
+		metrics[metric] = value
+	}
+
+	scopeChanged := false
+	switch metrics["S"] {
+	case "U":
+	case "C":
+		scopeChanged = true
+	default:
+		return 0, fmt.Errorf("invalid CVSS v3 vector: %s", vector)
+	}
+
+	weights := map[string]float64{}
+	for metric, values := range cvss3Weights {
+		weight, ok := values[metrics[metric]]
+		if !ok {
+			return 0, fmt.Errorf("invalid CVSS v3 vector: %s", vector)
+		}
+		weights[metric] = weight
+	}
+
+	switch metrics["PR"] {
+	case "N":
+		weights["PR"] = 0.85
+	case "L":
+		weights["PR"] = 0.62
+		if scopeChanged {
+			weights["PR"] = 0.68
+		}
+	case "H":
+		weights["PR"] = 0.27
+		if scopeChanged {
+			weights["PR"] = 0.5
+		}
+	default:
+		return 0, fmt.Errorf("invalid CVSS v3 vector: %s", vector)
+	}
+
+	iss := 1 - (1-weights["C"])*(1-weights["I"])*(1-weights["A"])
+	var impact float64
+	if scopeChanged {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	exploitability := 8.22 * weights["AV"] * weights["AC"] * weights["PR"] * weights["UI"]
+
+	if impact <= 0 {
+		return 0, nil
+	}
+	if scopeChanged {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), nil
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), nil
+}
+
+// roundUp returns the smallest number with one decimal place equal to or higher than x,
+// avoiding floating point artifacts as described in the CVSS v3.1 specification.
+func roundUp(x float64) float64 {
+	i := int(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
@@ -0,0 +1,297 @@
+package osv
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/versions"
+)
+
+// Vulnerability is an entry of the OSV schema, see https://ossf.github.io/osv-schema/.
+type Vulnerability struct {
+	ID         string      `json:"id"`
+	Modified   string      `json:"modified"`
+	Summary    string      `json:"summary"`
+	Details    string      `json:"details"`
+	Aliases    []string    `json:"aliases"`
+	Severity   []Severity  `json:"severity"`
+	Affected   []Affected  `json:"affected"`
+	References []Reference `json:"references"`
+}
+
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type Affected struct {
+	Package  Package  `json:"package"`
+	Ranges   []Range  `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+type Reference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// ecosystems maps deps.dev systems to OSV ecosystems supported by the importer.
+var ecosystems = map[string]string{
+	"GO": "Go",
+}
+
+// Ecosystem returns the OSV ecosystem of a deps.dev system.
+func Ecosystem(system string) (string, bool) {
+	ecosystem, ok := ecosystems[strings.ToUpper(system)]
+	return ecosystem, ok
+}
+
+// ReadDump reads vulnerabilities from an OSV dump, either a zip file such as
+// https://osv-vulnerabilities.storage.googleapis.com/Go/all.zip or a directory of JSON files.
+func ReadDump(path string) ([]Vulnerability, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OSV dump: %w", err)
+	}
+	if info.IsDir() {
+		return readDirectory(path)
+	}
+	return readZip(path)
+}
+
+func readZip(path string) ([]Vulnerability, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OSV zip: %w", err)
+	}
+	defer archive.Close()
+
+	vulnerabilities := []Vulnerability{}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || !strings.HasSuffix(file.Name, ".json") {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+		}
+		vulnerability, err := decode(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", file.Name, err)
+		}
+		vulnerabilities = append(vulnerabilities, vulnerability)
+	}
+
+	return vulnerabilities, nil
+}
+
+func readDirectory(path string) ([]Vulnerability, error) {
+	vulnerabilities := []Vulnerability{}
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ".json") {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", p, err)
+		}
+		defer f.Close()
+		vulnerability, err := decode(f)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", p, err)
+		}
+		vulnerabilities = append(vulnerabilities, vulnerability)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vulnerabilities, nil
+}
+
+func decode(r io.Reader) (Vulnerability, error) {
+	var vulnerability Vulnerability
+	if err := json.NewDecoder(r).Decode(&vulnerability); err != nil {
+		return Vulnerability{}, err
+	}
+	if vulnerability.ID == "" {
+		return Vulnerability{}, fmt.Errorf("missing vulnerability id")
+	}
+	return vulnerability, nil
+}
+
+// Affects tells whether the version of a package from the deps.dev system is affected by the vulnerability.
+func (v Vulnerability) Affects(versionKey dependenciesloader.VersionKey) bool {
+	ecosystem, ok := Ecosystem(versionKey.System)
+	if !ok {
+		return false
+	}
+	for _, affected := range v.Affected {
+		if affected.Package.Ecosystem != ecosystem || affected.Package.Name != versionKey.Name {
+			continue
+		}
+		if affected.affects(versionKey.System, versionKey.Version) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Affected) affects(system, version string) bool {
+	for _, affectedVersion := range a.Versions {
+		if sameVersion(system, affectedVersion, version) {
+			return true
+		}
+	}
+
+	current, err := parse(system, version)
+	if err != nil {
+		return false
+	}
+	for _, r := range a.Ranges {
+		if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+			continue
+		}
+		if r.affects(system, current) {
+			return true
+		}
+	}
+	return false
+}
+
+// affects walks the range events in order; every "introduced" event opens an affected interval
+// which is closed by the following "fixed", "last_affected" or "limit" event.
+func (r Range) affects(system string, current versions.Version) bool {
+	affected := false
+	for _, event := range r.Events {
+		switch {
+		case event.Introduced != "":
+			if event.Introduced == "0" {
+				affected = true
+				continue
+			}
+			introduced, err := parse(system, event.Introduced)
+			if err != nil {
+				continue
+			}
+			if current.Compare(introduced) >= 0 {
+				affected = true
+			}
+		case event.Fixed != "" || event.Limit != "":
+			end := event.Fixed
+			if end == "" {
+				end = event.Limit
+			}
+			fixed, err := parse(system, end)
+			if err != nil {
+				continue
+			}
+			if affected && current.Compare(fixed) < 0 {
+				return true
+			}
+			affected = false
+		case event.LastAffected != "":
+			lastAffected, err := parse(system, event.LastAffected)
+			if err != nil {
+				continue
+			}
+			if affected && current.Compare(lastAffected) <= 0 {
+				return true
+			}
+			affected = false
+		}
+	}
+	return affected
+}
+
+// parse accepts OSV versions of the Go ecosystem, which come without the "v" prefix.
+func parse(system, version string) (versions.Version, error) {
+	if strings.EqualFold(system, "GO") && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return versions.Parse(system, version)
+}
+
+func sameVersion(system, a, b string) bool {
+	av, err := parse(system, a)
+	if err != nil {
+		return a == b
+	}
+	bv, err := parse(system, b)
+	if err != nil {
+		return a == b
+	}
+	return av.Compare(bv) == 0
+}
+
+// Advisory converts the vulnerability to the advisory format used for deps.dev advisories.
+func (v Vulnerability) Advisory() dependenciesloader.Advisory {
+	advisory := dependenciesloader.Advisory{
+		AdvisoryKey: dependenciesloader.AdvisoryKey{ID: v.ID},
+		Title:       v.Summary,
+		Aliases:     v.Aliases,
+		URL:         "https://osv.dev/vulnerability/" + v.ID,
+	}
+	if advisory.Title == "" {
+		advisory.Title, _, _ = strings.Cut(v.Details, "\n")
+	}
+	for _, severity := range v.Severity {
+		if severity.Type != "CVSS_V3" {
+			continue
+		}
+		if score, err := CVSS3BaseScore(severity.Score); err == nil {
+			advisory.CVSS3Vector = severity.Score
+			advisory.CVSS3Score = score
+			break
+		}
+	}
+	return advisory
+}
+
+// Match returns advisories of the vulnerabilities affecting each of the versionKeys.
+// Version keys which are not affected are returned with no advisories, so that stale matches can be replaced.
+func Match(vulnerabilities []Vulnerability, versionKeys []dependenciesloader.VersionKey) []dependenciesloader.VersionAdvisories {
+	matches := []dependenciesloader.VersionAdvisories{}
+	for _, versionKey := range versionKeys {
+		if _, ok := Ecosystem(versionKey.System); !ok {
+			continue
+		}
+		match := dependenciesloader.VersionAdvisories{VersionKey: versionKey, Advisories: []dependenciesloader.Advisory{}}
+		for _, vulnerability := range vulnerabilities {
+			if vulnerability.Affects(versionKey) {
+				match.Advisories = append(match.Advisories, vulnerability.Advisory())
+			}
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
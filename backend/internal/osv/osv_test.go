@@ -0,0 +1,115 @@
+package osv
+
+import (
+	"archive/zip"
+	"os"
+	"path"
+	"testing"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+const vulnerabilityMock = `{
+    "id": "GO-2021-0001",
+    "modified": "2024-01-01T00:00:00Z",
+    "summary": "Test vulnerability",
+    "aliases": ["CVE-2021-0001", "GHSA-xxxx-xxxx-xxxx"],
+    "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}],
+    "affected": [{
+        "package": {"ecosystem": "Go", "name": "github.com/briandowns/spinner"},
+        "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.12.0"}, {"introduced": "1.15.0"}, {"last_affected": "1.16.0"}]}]
+    }]
+}`
+
+func TestReadDump(t *testing.T) {
+	dir := writeMock(t)
+
+	zipPath := path.Join(t.TempDir(), "all.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	entry, _ := w.Create("GO-2021-0001.json")
+	entry.Write([]byte(vulnerabilityMock))
+	w.Close()
+	f.Close()
+
+	for _, dumpPath := range []string{dir, zipPath} {
+		vulnerabilities, err := ReadDump(dumpPath)
+		if err != nil {
+			t.Fatalf("failed to read dump %s: %v", dumpPath, err)
+		}
+		if len(vulnerabilities) != 1 || vulnerabilities[0].ID != "GO-2021-0001" {
+			t.Fatalf("unexpected vulnerabilities read from %s: %+v", dumpPath, vulnerabilities)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	vulnerabilities, err := ReadDump(writeMock(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"v1.11.1", true},
+		{"v1.12.0", false},
+		{"v1.14.9", false},
+		{"v1.15.0", true},
+		{"v1.16.0", true},
+		{"v1.16.1", false},
+		{"v0.0.0-20191109021931-daa7c04131f5", true},
+	}
+
+	for _, tt := range tests {
+		versionKey := dependenciesloader.VersionKey{System: "GO", Name: "github.com/briandowns/spinner", Version: tt.version}
+		matches := Match(vulnerabilities, []dependenciesloader.VersionKey{versionKey})
+		if got := len(matches[0].Advisories) == 1; got != tt.want {
+			t.Fatalf("unexpected match for %s, want: %t, got: %t", tt.version, tt.want, got)
+		}
+	}
+
+	advisory := vulnerabilities[0].Advisory()
+	if advisory.CVSS3Score != 9.8 || advisory.Title != "Test vulnerability" {
+		t.Fatalf("unexpected advisory: %+v", advisory)
+	}
+}
+
+func TestCVSS3BaseScore(t *testing.T) {
+	tests := []struct {
+		vector string
+		want   float64
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N", 5.5},
+		{"CVSS:3.0/AV:N/AC:H/PR:H/UI:R/S:C/C:H/I:H/A:H", 7.6},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0},
+	}
+
+	for _, tt := range tests {
+		got, err := CVSS3BaseScore(tt.vector)
+		if err != nil {
+			t.Fatalf("failed to calculate score of %s: %v", tt.vector, err)
+		}
+		if got != tt.want {
+			t.Fatalf("unexpected score of %s, want: %g, got: %g", tt.vector, tt.want, got)
+		}
+	}
+
+	if _, err := CVSS3BaseScore("CVSS:2.0/AV:N"); err == nil {
+		t.Fatal("expected an error for invalid vector")
+	}
+}
+
+func writeMock(t *testing.T) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(path.Join(dir, "GO-2021-0001.json"), []byte(vulnerabilityMock), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
@@ -0,0 +1,127 @@
+// Package overrides applies manual corrections of dependency details on top of the data fetched from deps.dev.
+package overrides
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+// Override replaces the value of a single field of the details of a project. Field is a JSON Pointer
+// into the details, except that checks are addressed by their names, e.g. /scorecard/checks/Maintained/score.
+type Override struct {
+	ProjectKeyID string          `json:"projectKeyId"`
+	Field        string          `json:"field"`
+	Value        json.RawMessage `json:"value"`
+	Reason       string          `json:"reason"`
+	Author       string          `json:"author"`
+	CreatedAt    string          `json:"createdAt"`
+}
+
+// Fields lists the overridable fields, {name} stands for the name of a check.
+var Fields = []string{
+	"/openIssuesCount",
+	"/starsCount",
+	"/forksCount",
+	"/license",
+	"/description",
+	"/homepage",
+	"/scorecard/overallScore",
+	"/scorecard/checks/{name}/score",
+	"/scorecard/checks/{name}/reason",
+}
+
+// ErrUnknownField is returned for fields which can't be overridden.
+var ErrUnknownField = errors.New("unknown field")
+
+// ErrCheckNotFound is returned for fields of checks which aren't a part of the scorecard.
+var ErrCheckNotFound = errors.New("check not found")
+
+// Apply sets the overridden fields of details and lists them in details.Overrides along with the
+// values they replace. Overrides of checks which aren't reported anymore are skipped, they are
+// kept in case the check comes back.
+func Apply(details *dependenciesloader.DependencyDetails, overrides []Override) error {
+	for _, override := range overrides {
+		err := Set(details, override)
+		if errors.Is(err, ErrCheckNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set sets a single overridden field of details and appends it to details.Overrides.
+func Set(details *dependenciesloader.DependencyDetails, override Override) error {
+	target, err := fieldOf(details, override.Field)
+	if err != nil {
+		return err
+	}
+	if value := bytes.TrimSpace(override.Value); len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		return fmt.Errorf("value of %s is required", override.Field)
+	}
+
+	upstream, err := json.Marshal(target.Interface())
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", override.Field, err)
+	}
+	value := reflect.New(target.Type())
+	if err := json.Unmarshal(override.Value, value.Interface()); err != nil {
+		return fmt.Errorf("invalid value of %s: %w", override.Field, err)
+	}
+	target.Set(value.Elem())
+
+	details.Overrides = append(details.Overrides, dependenciesloader.FieldOverride{
+		Field:     override.Field,
+		Value:     override.Value,
+		Upstream:  upstream,
+		Reason:    override.Reason,
+		Author:    override.Author,
+		CreatedAt: override.CreatedAt,
+	})
+	return nil
+}
+
+// fieldOf returns the settable field of details the path points at.
+func fieldOf(details *dependenciesloader.DependencyDetails, path string) (reflect.Value, error) {
+	var field any
+	switch path {
+	case "/openIssuesCount":
+		field = &details.OpenIssuesCount
+	case "/starsCount":
+		field = &details.StarsCount
+	case "/forksCount":
+		field = &details.ForksCount
+	case "/license":
+		field = &details.License
+	case "/description":
+		field = &details.Description
+	case "/homepage":
+		field = &details.Homepage
+	case "/scorecard/overallScore":
+		field = &details.Scorecard.OverallScore
+	default:
+		name, member, ok := strings.Cut(strings.TrimPrefix(path, "/scorecard/checks/"), "/")
+		if !strings.HasPrefix(path, "/scorecard/checks/") || !ok || name == "" || (member != "score" && member != "reason") {
+			return reflect.Value{}, fmt.Errorf("%w %s, expected one of: %s", ErrUnknownField, path, strings.Join(Fields, ", "))
+		}
+		i := slices.IndexFunc(details.Scorecard.Checks, func(c dependenciesloader.Check) bool { return c.Name == name })
+		if i < 0 {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrCheckNotFound, name)
+		}
+		if member == "score" {
+			field = &details.Scorecard.Checks[i].Score
+		} else {
+			field = &details.Scorecard.Checks[i].Reason
+		}
+	}
+	return reflect.ValueOf(field).Elem(), nil
+}
@@ -0,0 +1,72 @@
+package overrides
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+func TestApply(t *testing.T) {
+	details := dependenciesloader.DependencyDetails{
+		ProjectKey: dependenciesloader.ProjectKey{ID: "github.com/cli/cli"},
+		License:    "NOASSERTION",
+		StarsCount: 10,
+		Scorecard: dependenciesloader.Scorecard{
+			OverallScore: 5.2,
+			Checks:       []dependenciesloader.Check{{Name: "Maintained", Score: 0, Reason: "archived"}},
+		},
+	}
+
+	err := Apply(&details, []Override{
+		{Field: "/license", Value: json.RawMessage(`"MIT"`), Reason: "checked LICENSE", Author: "jane"},
+		{Field: "/scorecard/overallScore", Value: json.RawMessage(`6.5`)},
+		{Field: "/scorecard/checks/Maintained/reason", Value: json.RawMessage(`"active fork"`)},
+		{Field: "/scorecard/checks/Fuzzing/score", Value: json.RawMessage(`10`)},
+	})
+	if err != nil {
+		t.Fatal("failed to apply overrides:", err)
+	}
+
+	if details.License != "MIT" || details.Scorecard.OverallScore != 6.5 || details.Scorecard.Checks[0].Reason != "active fork" {
+		t.Fatalf("overrides were not applied: %+v", details)
+	}
+	want := []dependenciesloader.FieldOverride{
+		{Field: "/license", Value: json.RawMessage(`"MIT"`), Upstream: json.RawMessage(`"NOASSERTION"`), Reason: "checked LICENSE", Author: "jane"},
+		{Field: "/scorecard/overallScore", Value: json.RawMessage(`6.5`), Upstream: json.RawMessage(`5.2`)},
+		{Field: "/scorecard/checks/Maintained/reason", Value: json.RawMessage(`"active fork"`), Upstream: json.RawMessage(`"archived"`)},
+	}
+	if diff := cmp.Diff(want, details.Overrides); diff != "" {
+		t.Fatalf("unexpected overrides, an override of a missing check should be skipped: %s", diff)
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		override Override
+		want     error
+	}{
+		{Override{Field: "/purl", Value: json.RawMessage(`"pkg:golang/x"`)}, ErrUnknownField},
+		{Override{Field: "/scorecard/checks/Maintained/documentation", Value: json.RawMessage(`{}`)}, ErrUnknownField},
+		{Override{Field: "/scorecard/checks/Fuzzing/score", Value: json.RawMessage(`10`)}, ErrCheckNotFound},
+		{Override{Field: "/starsCount", Value: json.RawMessage(`1.5`)}, nil},
+		{Override{Field: "/license", Value: json.RawMessage(`null`)}, nil},
+		{Override{Field: "/license"}, nil},
+	}
+
+	for _, tt := range tests {
+		details := dependenciesloader.DependencyDetails{
+			License:   "MIT",
+			Scorecard: dependenciesloader.Scorecard{Checks: []dependenciesloader.Check{{Name: "Maintained"}}},
+		}
+		err := Set(&details, tt.override)
+		if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+			t.Fatalf("want %v for override of %s with %s, got: %v", tt.want, tt.override.Field, tt.override.Value, err)
+		}
+		if details.License != "MIT" || details.StarsCount != 0 || details.Overrides != nil {
+			t.Fatalf("failed override of %s should leave details unchanged: %+v", tt.override.Field, details)
+		}
+	}
+}
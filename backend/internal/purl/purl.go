@@ -0,0 +1,199 @@
+package purl
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+// types maps deps.dev systems to package URL types, see https://github.com/package-url/purl-spec.
+var types = map[string]string{
+	"GO":    "golang",
+	"NPM":   "npm",
+	"CARGO": "cargo",
+	"MAVEN": "maven",
+	"PYPI":  "pypi",
+	"NUGET": "nuget",
+}
+
+// FromVersionKey returns the package URL of a version, e.g. pkg:golang/github.com/cli/cli@v1.14.0.
+// The version is omitted if empty.
+func FromVersionKey(versionKey dependenciesloader.VersionKey) string {
+	purlType, ok := types[strings.ToUpper(versionKey.System)]
+	if !ok {
+		purlType = "generic"
+	}
+
+	name := versionKey.Name
+	switch purlType {
+	case "maven":
+		// deps.dev names Maven packages as group:artifact, purl uses group as the namespace.
+		name = strings.Replace(name, ":", "/", 1)
+	case "pypi":
+		name = normalizePyPI(name)
+	}
+
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+
+	s := "pkg:" + purlType + "/" + strings.Join(segments, "/")
+	if versionKey.Version != "" {
+		s += "@" + escape(versionKey.Version)
+	}
+	return s
+}
+
+// normalizePyPI normalizes a PyPI package name as PEP 503 does, e.g. Foo_Bar to foo-bar.
+func normalizePyPI(name string) string {
+	return strings.ToLower(pypiSeparatorsPattern.ReplaceAllString(name, "-"))
+}
+
+// escape percent-encodes a segment, including characters which are valid in paths but separate purl components.
+func escape(s string) string {
+	return strings.NewReplacer("+", "%2B", "@", "%40").Replace(url.PathEscape(s))
+}
+
+// PackageURL is a parsed package URL, pkg:type/namespace/name@version?qualifiers#subpath.
+type PackageURL struct {
+	Type       string
+	Namespace  string
+	Name       string
+	Version    string
+	Qualifiers map[string]string
+	Subpath    string
+}
+
+// Parse parses a package URL. Components are unescaped and the type is lowercased.
+func Parse(s string) (PackageURL, error) {
+	rest, ok := strings.CutPrefix(s, "pkg:")
+	if !ok {
+		return PackageURL{}, fmt.Errorf("invalid purl %q: missing pkg: scheme", s)
+	}
+	rest = strings.TrimLeft(rest, "/")
+
+	var p PackageURL
+	if i := strings.LastIndex(rest, "#"); i >= 0 {
+		subpath, err := url.PathUnescape(strings.Trim(rest[i+1:], "/"))
+		if err != nil {
+			return PackageURL{}, fmt.Errorf("invalid purl %q: %w", s, err)
+		}
+		p.Subpath = subpath
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "?"); i >= 0 {
+		qualifiers, err := url.ParseQuery(rest[i+1:])
+		if err != nil {
+			return PackageURL{}, fmt.Errorf("invalid purl %q: %w", s, err)
+		}
+		p.Qualifiers = map[string]string{}
+		for key, values := range qualifiers {
+			p.Qualifiers[strings.ToLower(key)] = values[0]
+		}
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 && i > strings.LastIndex(rest, "/") {
+		version, err := url.PathUnescape(rest[i+1:])
+		if err != nil {
+			return PackageURL{}, fmt.Errorf("invalid purl %q: %w", s, err)
+		}
+		p.Version = version
+		rest = rest[:i]
+	}
+
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(segments) < 2 || segments[0] == "" {
+		return PackageURL{}, fmt.Errorf("invalid purl %q: type and name are required", s)
+	}
+	p.Type = strings.ToLower(segments[0])
+	for i, segment := range segments[1:] {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return PackageURL{}, fmt.Errorf("invalid purl %q: %w", s, err)
+		}
+		segments[i+1] = unescaped
+	}
+	p.Name = segments[len(segments)-1]
+	p.Namespace = strings.Join(segments[1:len(segments)-1], "/")
+	if p.Name == "" {
+		return PackageURL{}, fmt.Errorf("invalid purl %q: name is required", s)
+	}
+	if err := p.validate(); err != nil {
+		return PackageURL{}, fmt.Errorf("invalid purl %q: %w", s, err)
+	}
+
+	return p, nil
+}
+
+var (
+	typePattern           = regexp.MustCompile(`^[a-z.+-][a-z0-9.+-]*$`)
+	qualifierKeyPattern   = regexp.MustCompile(`^[a-z.\-_][a-z0-9.\-_]*$`)
+	pypiSeparatorsPattern = regexp.MustCompile(`[-_.]+`)
+)
+
+// validate checks the rules of the purl specification, including rules of the types deps.dev supports.
+func (p PackageURL) validate() error {
+	if !typePattern.MatchString(p.Type) {
+		return fmt.Errorf("type %q may contain only ASCII letters, digits, '.', '+' and '-' and must not start with a digit", p.Type)
+	}
+	for key := range p.Qualifiers {
+		if !qualifierKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid qualifier key %q", key)
+		}
+	}
+
+	switch p.Type {
+	case "maven":
+		if p.Namespace == "" {
+			return fmt.Errorf("maven purls require the group id as namespace")
+		}
+	case "npm":
+		if p.Namespace != "" && !strings.HasPrefix(p.Namespace, "@") {
+			return fmt.Errorf("npm namespace must be a scope starting with @")
+		}
+	case "cargo", "nuget", "pypi":
+		if p.Namespace != "" {
+			return fmt.Errorf("%s purls have no namespace", p.Type)
+		}
+	}
+	return nil
+}
+
+// Supported reports whether deps.dev knows the package system of the type.
+func (p PackageURL) Supported() bool {
+	for _, purlType := range types {
+		if purlType == p.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// VersionKey maps the package URL to the deps.dev naming of its system. Types unknown to deps.dev
+// keep their uppercased type as the system.
+func (p PackageURL) VersionKey() dependenciesloader.VersionKey {
+	system := strings.ToUpper(p.Type)
+	for s, purlType := range types {
+		if purlType == p.Type {
+			system = s
+		}
+	}
+
+	name := p.Name
+	if p.Namespace != "" {
+		separator := "/"
+		if p.Type == "maven" {
+			separator = ":"
+		}
+		name = p.Namespace + separator + p.Name
+	}
+	if p.Type == "pypi" {
+		name = normalizePyPI(name)
+	}
+
+	return dependenciesloader.VersionKey{System: system, Name: name, Version: p.Version}
+}
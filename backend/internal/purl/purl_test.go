@@ -0,0 +1,105 @@
+package purl
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		versionKey dependenciesloader.VersionKey
+		purl       string
+	}{
+		{dependenciesloader.VersionKey{System: "GO", Name: "github.com/cli/cli", Version: "v1.14.0"}, "pkg:golang/github.com/cli/cli@v1.14.0"},
+		{dependenciesloader.VersionKey{System: "GO", Name: "github.com/kr/pty", Version: "v1.1.4+incompatible"}, "pkg:golang/github.com/kr/pty@v1.1.4%2Bincompatible"},
+		{dependenciesloader.VersionKey{System: "NPM", Name: "@angular/core", Version: "17.0.0"}, "pkg:npm/%40angular/core@17.0.0"},
+		{dependenciesloader.VersionKey{System: "MAVEN", Name: "com.google.guava:guava", Version: "32.1.2-jre"}, "pkg:maven/com.google.guava/guava@32.1.2-jre"},
+		{dependenciesloader.VersionKey{System: "PYPI", Name: "django-rest", Version: "1.0"}, "pkg:pypi/django-rest@1.0"},
+		{dependenciesloader.VersionKey{System: "CARGO", Name: "serde"}, "pkg:cargo/serde"},
+	} {
+		if got := FromVersionKey(test.versionKey); got != test.purl {
+			t.Errorf("FromVersionKey(%+v) = %s, want %s", test.versionKey, got, test.purl)
+		}
+		p, err := Parse(test.purl)
+		if err != nil {
+			t.Errorf("Parse(%s) failed: %v", test.purl, err)
+			continue
+		}
+		if diff := cmp.Diff(test.versionKey, p.VersionKey()); diff != "" {
+			t.Errorf("Parse(%s).VersionKey() (-want +got):\n%s", test.purl, diff)
+		}
+	}
+}
+
+func TestPyPINames(t *testing.T) {
+	versionKey := dependenciesloader.VersionKey{System: "PYPI", Name: "Foo_Bar", Version: "1.0"}
+	want := dependenciesloader.VersionKey{System: "PYPI", Name: "foo-bar", Version: "1.0"}
+
+	s := FromVersionKey(versionKey)
+	if s != "pkg:pypi/foo-bar@1.0" {
+		t.Fatalf("FromVersionKey(%+v) = %s, want pkg:pypi/foo-bar@1.0", versionKey, s)
+	}
+	for _, s := range []string{s, "pkg:pypi/Foo_Bar@1.0", "pkg:pypi/foo.bar@1.0"} {
+		p, err := Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%s) failed: %v", s, err)
+		}
+		if diff := cmp.Diff(want, p.VersionKey()); diff != "" {
+			t.Errorf("Parse(%s).VersionKey() (-want +got):\n%s", s, diff)
+		}
+		if got := FromVersionKey(p.VersionKey()); got != "pkg:pypi/foo-bar@1.0" {
+			t.Errorf("FromVersionKey(Parse(%s).VersionKey()) = %s, want pkg:pypi/foo-bar@1.0", s, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	got, err := Parse("pkg:maven/org.apache.xmlgraphics/batik-anim@1.9.1?Classifier=sources&type=zip#src/main")
+	if err != nil {
+		t.Fatal("failed to parse:", err)
+	}
+	want := PackageURL{
+		Type:       "maven",
+		Namespace:  "org.apache.xmlgraphics",
+		Name:       "batik-anim",
+		Version:    "1.9.1",
+		Qualifiers: map[string]string{"classifier": "sources", "type": "zip"},
+		Subpath:    "src/main",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected purl (-want +got):\n%s", diff)
+	}
+
+	for _, invalid := range []string{"", "github.com/cli/cli", "pkg:golang", "pkg:/cli", "pkg:npm/"} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestParseValidation(t *testing.T) {
+	for _, invalid := range []string{
+		"pkg:1golang/github.com/cli/cli",
+		"pkg:maven/guava@32.1.2-jre",
+		"pkg:npm/angular/core@17.0.0",
+		"pkg:pypi/acme/django@4.2.7",
+		"pkg:golang/github.com/cli/cli?1type=zip",
+	} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+
+	p, err := Parse("pkg:GOLANG/github.com/cli/cli@v1.14.0")
+	if err != nil {
+		t.Fatal("failed to parse:", err)
+	}
+	if !p.Supported() {
+		t.Errorf("golang purls should be supported")
+	}
+	if p, _ := Parse("pkg:deb/debian/curl@7.50.3-1"); p.Supported() {
+		t.Errorf("deb purls should not be supported")
+	}
+}
@@ -0,0 +1,300 @@
+package sbom
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/purl"
+)
+
+const (
+	cycloneDXSpecVersion = "1.5"
+	cycloneDXNamespace   = "http://cyclonedx.org/schema/bom/1.5"
+)
+
+// Bom is a CycloneDX 1.5 document, see https://cyclonedx.org/docs/1.5/json/.
+// Only the parts filled from the stored graph are modelled.
+type Bom struct {
+	BomFormat    string       `json:"bomFormat"`
+	SpecVersion  string       `json:"specVersion"`
+	SerialNumber string       `json:"serialNumber"`
+	Version      int          `json:"version"`
+	Metadata     Metadata     `json:"metadata"`
+	Components   []Component  `json:"components"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+type Metadata struct {
+	Timestamp string     `json:"timestamp"`
+	Tools     Tools      `json:"tools"`
+	Component *Component `json:"component,omitempty"`
+}
+
+type Tools struct {
+	Components []Component `json:"components"`
+}
+
+type Component struct {
+	Type               string              `json:"type"`
+	BomRef             string              `json:"bom-ref,omitempty"`
+	Name               string              `json:"name"`
+	Version            string              `json:"version,omitempty"`
+	Description        string              `json:"description,omitempty"`
+	Licenses           []LicenseChoice     `json:"licenses,omitempty"`
+	Purl               string              `json:"purl,omitempty"`
+	ExternalReferences []ExternalReference `json:"externalReferences,omitempty"`
+	Properties         []Property          `json:"properties,omitempty"`
+	Components         []Component         `json:"components,omitempty"`
+}
+
+// LicenseChoice holds either a single license or an SPDX expression.
+type LicenseChoice struct {
+	License    *License `json:"license,omitempty"`
+	Expression string   `json:"expression,omitempty"`
+}
+
+type License struct {
+	Name string `json:"name" xml:"name"`
+}
+
+type ExternalReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type Property struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Dependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// CycloneDX builds a BOM of the dependency graph. The SELF node becomes the described component
+// and all other nodes become library components with licenses and Scorecard results from the details
+// of their projects, given by projectKeyIDs.
+func CycloneDX(dependencies dependenciesloader.Dependencies, details []dependenciesloader.DependencyDetails, projectKeyIDs map[string]string) (Bom, error) {
+	serialNumber, err := newUUID()
+	if err != nil {
+		return Bom{}, err
+	}
+
+	bom := Bom{
+		BomFormat:    "CycloneDX",
+		SpecVersion:  cycloneDXSpecVersion,
+		SerialNumber: "urn:uuid:" + serialNumber,
+		Version:      1,
+		Metadata: Metadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Tools:     Tools{Components: []Component{{Type: "application", Name: toolName}}},
+		},
+		Components:   []Component{},
+		Dependencies: []Dependency{},
+	}
+
+	byName := detailsByName(dependencies, details, projectKeyIDs)
+	refs := make([]string, len(dependencies.Nodes))
+	for i, node := range dependencies.Nodes {
+		component := componentOf(node, byName)
+		refs[i] = component.BomRef
+		if node.Relation == "SELF" && bom.Metadata.Component == nil {
+			component.Type = "application"
+			bom.Metadata.Component = &component
+			continue
+		}
+		bom.Components = append(bom.Components, component)
+	}
+
+	edges := dependsOn(dependencies)
+	for i := range dependencies.Nodes {
+		dependency := Dependency{Ref: refs[i], DependsOn: []string{}}
+		for _, to := range edges[i] {
+			if to >= 0 && to < len(refs) {
+				dependency.DependsOn = append(dependency.DependsOn, refs[to])
+			}
+		}
+		sort.Strings(dependency.DependsOn)
+		bom.Dependencies = append(bom.Dependencies, dependency)
+	}
+
+	return bom, nil
+}
+
+func componentOf(node dependenciesloader.Node, details map[string]dependenciesloader.DependencyDetails) Component {
+	ref := purl.FromVersionKey(node.VersionKey)
+	component := Component{
+		Type:    "library",
+		BomRef:  ref,
+		Name:    node.VersionKey.Name,
+		Version: node.VersionKey.Version,
+		Purl:    ref,
+	}
+
+	detail, ok := details[node.VersionKey.Name]
+	if !ok {
+		return component
+	}
+
+	component.Description = detail.Description
+	if expression, ok := spdxExpression(detail.License); ok {
+		component.Licenses = []LicenseChoice{{Expression: expression}}
+	} else if detail.License != "" {
+		component.Licenses = []LicenseChoice{{License: &License{Name: detail.License}}}
+	}
+	if detail.Homepage != "" {
+		component.ExternalReferences = []ExternalReference{{Type: "website", URL: detail.Homepage}}
+	}
+
+	scorecard := detail.Scorecard
+	if scorecard.Date != "" {
+		component.Properties = append(component.Properties,
+			Property{Name: "deps.dev:scorecard:date", Value: scorecard.Date},
+			Property{Name: "deps.dev:scorecard:overallScore", Value: strconv.FormatFloat(scorecard.OverallScore, 'f', -1, 64)},
+		)
+		for _, check := range scorecard.Checks {
+			component.Properties = append(component.Properties,
+				Property{Name: "deps.dev:scorecard:check:" + check.Name, Value: strconv.Itoa(check.Score)})
+		}
+	}
+
+	return component
+}
+
+// WriteCycloneDX encodes the BOM in the CycloneDX JSON or XML format.
+func WriteCycloneDX(w io.Writer, bom Bom, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(bom)
+	case FormatXML:
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		encoder := xml.NewEncoder(w)
+		encoder.Indent("", "  ")
+		if err := encoder.Encode(toXML(bom)); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	default:
+		return fmt.Errorf("unsupported CycloneDX format: %q", format)
+	}
+}
+
+// The XML schema differs from the JSON one in element names and nesting,
+// so BOMs are converted to the types below before encoding.
+
+type xmlBom struct {
+	XMLName      xml.Name        `xml:"bom"`
+	Namespace    string          `xml:"xmlns,attr"`
+	SerialNumber string          `xml:"serialNumber,attr"`
+	Version      int             `xml:"version,attr"`
+	Metadata     xmlMetadata     `xml:"metadata"`
+	Components   []xmlComponent  `xml:"components>component"`
+	Dependencies []xmlDependency `xml:"dependencies>dependency"`
+}
+
+type xmlMetadata struct {
+	Timestamp string         `xml:"timestamp"`
+	Tools     []xmlComponent `xml:"tools>components>component"`
+	Component *xmlComponent  `xml:"component"`
+}
+
+type xmlComponent struct {
+	Type               string                 `xml:"type,attr"`
+	BomRef             string                 `xml:"bom-ref,attr,omitempty"`
+	Name               string                 `xml:"name"`
+	Version            string                 `xml:"version,omitempty"`
+	Description        string                 `xml:"description,omitempty"`
+	Licenses           *xmlLicenses           `xml:"licenses"`
+	Purl               string                 `xml:"purl,omitempty"`
+	ExternalReferences []xmlExternalReference `xml:"externalReferences>reference"`
+	Properties         []xmlProperty          `xml:"properties>property"`
+	Components         []xmlComponent         `xml:"components>component"`
+}
+
+type xmlLicenses struct {
+	Licenses   []License `xml:"license"`
+	Expression string    `xml:"expression,omitempty"`
+}
+
+type xmlExternalReference struct {
+	Type string `xml:"type,attr"`
+	URL  string `xml:"url"`
+}
+
+type xmlProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDependency struct {
+	Ref          string          `xml:"ref,attr"`
+	Dependencies []xmlDependency `xml:"dependency"`
+}
+
+func toXML(bom Bom) xmlBom {
+	result := xmlBom{
+		Namespace:    cycloneDXNamespace,
+		SerialNumber: bom.SerialNumber,
+		Version:      bom.Version,
+		Metadata:     xmlMetadata{Timestamp: bom.Metadata.Timestamp},
+	}
+	for _, tool := range bom.Metadata.Tools.Components {
+		result.Metadata.Tools = append(result.Metadata.Tools, toXMLComponent(tool))
+	}
+	if bom.Metadata.Component != nil {
+		component := toXMLComponent(*bom.Metadata.Component)
+		result.Metadata.Component = &component
+	}
+	for _, component := range bom.Components {
+		result.Components = append(result.Components, toXMLComponent(component))
+	}
+	for _, dependency := range bom.Dependencies {
+		d := xmlDependency{Ref: dependency.Ref}
+		for _, ref := range dependency.DependsOn {
+			d.Dependencies = append(d.Dependencies, xmlDependency{Ref: ref})
+		}
+		result.Dependencies = append(result.Dependencies, d)
+	}
+	return result
+}
+
+func toXMLComponent(component Component) xmlComponent {
+	result := xmlComponent{
+		Type:        component.Type,
+		BomRef:      component.BomRef,
+		Name:        component.Name,
+		Version:     component.Version,
+		Description: component.Description,
+		Purl:        component.Purl,
+	}
+	if len(component.Licenses) > 0 {
+		result.Licenses = &xmlLicenses{}
+		for _, choice := range component.Licenses {
+			if choice.License != nil {
+				result.Licenses.Licenses = append(result.Licenses.Licenses, *choice.License)
+			}
+			if choice.Expression != "" {
+				result.Licenses.Expression = choice.Expression
+			}
+		}
+	}
+	for _, reference := range component.ExternalReferences {
+		result.ExternalReferences = append(result.ExternalReferences, xmlExternalReference(reference))
+	}
+	for _, property := range component.Properties {
+		result.Properties = append(result.Properties, xmlProperty(property))
+	}
+	return result
+}
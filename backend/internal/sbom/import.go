@@ -0,0 +1,351 @@
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/purl"
+)
+
+const (
+	relationSelf     = "SELF"
+	relationDirect   = "DIRECT"
+	relationIndirect = "INDIRECT"
+)
+
+// Import reads a CycloneDX (JSON or XML) or SPDX (JSON or tag-value) document into a dependency graph.
+// Components are identified by their package URLs, components without one are skipped except the root,
+// which is named after the component. The described component becomes the SELF node,
+// its direct dependencies DIRECT and all other nodes INDIRECT.
+func Import(data []byte) (dependenciesloader.Dependencies, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var probe struct {
+			BomFormat   string `json:"bomFormat"`
+			SPDXVersion string `json:"spdxVersion"`
+		}
+		if err := json.Unmarshal(trimmed, &probe); err != nil {
+			return dependenciesloader.Dependencies{}, fmt.Errorf("failed to decode JSON: %w", err)
+		}
+		if probe.BomFormat == "CycloneDX" {
+			var bom Bom
+			if err := json.Unmarshal(trimmed, &bom); err != nil {
+				return dependenciesloader.Dependencies{}, fmt.Errorf("failed to decode CycloneDX BOM: %w", err)
+			}
+			return fromCycloneDX(bom)
+		}
+		if strings.HasPrefix(probe.SPDXVersion, "SPDX-2") {
+			var document spdxImport
+			if err := json.Unmarshal(trimmed, &document); err != nil {
+				return dependenciesloader.Dependencies{}, fmt.Errorf("failed to decode SPDX document: %w", err)
+			}
+			return fromSPDX(document)
+		}
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		var bom xmlBom
+		if err := xml.Unmarshal(trimmed, &bom); err != nil {
+			return dependenciesloader.Dependencies{}, fmt.Errorf("failed to decode CycloneDX BOM: %w", err)
+		}
+		return fromCycloneDX(fromXML(bom))
+	case bytes.Contains(trimmed, []byte("SPDXVersion:")):
+		document, err := parseTagValue(trimmed)
+		if err != nil {
+			return dependenciesloader.Dependencies{}, err
+		}
+		return fromSPDX(document)
+	}
+	return dependenciesloader.Dependencies{}, fmt.Errorf("unrecognized SBOM, expected a CycloneDX or SPDX 2 document")
+}
+
+// graphBuilder collects nodes identified by the references of the document and edges between them.
+// A package listed in several versions has a node of every version. VersionKeys are stored by name,
+// so of those the version listed first is stored.
+type graphBuilder struct {
+	dependencies dependenciesloader.Dependencies
+	indexes      map[string]int
+	byVersion    map[dependenciesloader.VersionKey]int
+	edges        map[[2]int]bool
+	root         int
+}
+
+func newGraphBuilder() *graphBuilder {
+	return &graphBuilder{
+		dependencies: dependenciesloader.Dependencies{Nodes: []dependenciesloader.Node{}, Edges: []dependenciesloader.Edge{}},
+		indexes:      map[string]int{},
+		byVersion:    map[dependenciesloader.VersionKey]int{},
+		edges:        map[[2]int]bool{},
+		root:         -1,
+	}
+}
+
+// addNode adds a node of the package URL under the reference. A version listed twice has a single node.
+func (g *graphBuilder) addNode(ref, packageURL string) error {
+	p, err := purl.Parse(packageURL)
+	if err != nil {
+		return err
+	}
+	return g.addVersionKey(ref, p.VersionKey())
+}
+
+func (g *graphBuilder) addVersionKey(ref string, versionKey dependenciesloader.VersionKey) error {
+	if index, ok := g.byVersion[versionKey]; ok {
+		if ref != "" {
+			g.indexes[ref] = index
+		}
+		return nil
+	}
+	index := len(g.dependencies.Nodes)
+	g.dependencies.Nodes = append(g.dependencies.Nodes, dependenciesloader.Node{VersionKey: versionKey, Errors: []string{}})
+	g.byVersion[versionKey] = index
+	if ref != "" {
+		g.indexes[ref] = index
+	}
+	return nil
+}
+
+// addEdge adds an edge between nodes of the references, edges of unknown references are ignored.
+func (g *graphBuilder) addEdge(from, to string) {
+	fromIndex, fromOk := g.indexes[from]
+	toIndex, toOk := g.indexes[to]
+	if !fromOk || !toOk || fromIndex == toIndex || g.edges[[2]int{fromIndex, toIndex}] {
+		return
+	}
+	g.edges[[2]int{fromIndex, toIndex}] = true
+	g.dependencies.Edges = append(g.dependencies.Edges, dependenciesloader.Edge{
+		FromNode:    fromIndex,
+		ToNode:      toIndex,
+		Requirement: g.dependencies.Nodes[toIndex].VersionKey.Version,
+	})
+}
+
+// build assigns relations: nodes depended on by the root, or by nothing if there is no root, are DIRECT.
+func (g *graphBuilder) build() dependenciesloader.Dependencies {
+	direct := map[int]bool{}
+	dependedOn := map[int]bool{}
+	for _, edge := range g.dependencies.Edges {
+		if edge.FromNode == g.root {
+			direct[edge.ToNode] = true
+		}
+		dependedOn[edge.ToNode] = true
+	}
+	for i := range g.dependencies.Nodes {
+		switch {
+		case i == g.root:
+			g.dependencies.Nodes[i].Relation = relationSelf
+		case direct[i] || (g.root < 0 && !dependedOn[i]):
+			g.dependencies.Nodes[i].Relation = relationDirect
+		default:
+			g.dependencies.Nodes[i].Relation = relationIndirect
+		}
+	}
+	return g.dependencies
+}
+
+func fromCycloneDX(bom Bom) (dependenciesloader.Dependencies, error) {
+	g := newGraphBuilder()
+
+	if root := bom.Metadata.Component; root != nil {
+		var err error
+		if root.Purl != "" {
+			err = g.addNode(root.BomRef, root.Purl)
+		} else {
+			err = g.addVersionKey(root.BomRef, dependenciesloader.VersionKey{Name: root.Name, Version: root.Version})
+		}
+		if err != nil {
+			return dependenciesloader.Dependencies{}, fmt.Errorf("invalid root component: %w", err)
+		}
+		g.root = 0
+	}
+
+	var addComponents func(components []Component) error
+	addComponents = func(components []Component) error {
+		for _, component := range components {
+			if component.Purl != "" {
+				if err := g.addNode(component.BomRef, component.Purl); err != nil {
+					return fmt.Errorf("invalid component %s: %w", component.Name, err)
+				}
+			}
+			if err := addComponents(component.Components); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := addComponents(bom.Components); err != nil {
+		return dependenciesloader.Dependencies{}, err
+	}
+
+	for _, dependency := range bom.Dependencies {
+		for _, ref := range dependency.DependsOn {
+			g.addEdge(dependency.Ref, ref)
+		}
+	}
+
+	return g.build(), nil
+}
+
+func fromXML(bom xmlBom) Bom {
+	result := Bom{Components: fromXMLComponents(bom.Components)}
+	if bom.Metadata.Component != nil {
+		root := fromXMLComponents([]xmlComponent{*bom.Metadata.Component})[0]
+		result.Metadata.Component = &root
+	}
+	for _, dependency := range bom.Dependencies {
+		d := Dependency{Ref: dependency.Ref}
+		for _, dependsOn := range dependency.Dependencies {
+			d.DependsOn = append(d.DependsOn, dependsOn.Ref)
+		}
+		result.Dependencies = append(result.Dependencies, d)
+	}
+	return result
+}
+
+func fromXMLComponents(components []xmlComponent) []Component {
+	result := []Component{}
+	for _, component := range components {
+		result = append(result, Component{
+			BomRef:     component.BomRef,
+			Name:       component.Name,
+			Version:    component.Version,
+			Purl:       component.Purl,
+			Components: fromXMLComponents(component.Components),
+		})
+	}
+	return result
+}
+
+// spdxImport holds the parts of SPDX documents needed to rebuild the graph.
+type spdxImport struct {
+	DocumentDescribes []string       `json:"documentDescribes"`
+	Packages          []Package      `json:"packages"`
+	Relationships     []Relationship `json:"relationships"`
+}
+
+func fromSPDX(document spdxImport) (dependenciesloader.Dependencies, error) {
+	g := newGraphBuilder()
+
+	described := document.DocumentDescribes
+	for _, relationship := range document.Relationships {
+		if relationship.SPDXElementID == spdxDocumentID && relationship.RelationshipType == RelationshipDescribes {
+			described = append(described, relationship.RelatedSPDXElement)
+		}
+	}
+	rootID := ""
+	if len(described) == 1 {
+		rootID = described[0]
+	}
+
+	for _, pkg := range document.Packages {
+		packageURL := ""
+		for _, ref := range pkg.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				packageURL = ref.ReferenceLocator
+				break
+			}
+		}
+		var err error
+		switch {
+		case packageURL != "":
+			err = g.addNode(pkg.SPDXID, packageURL)
+		case pkg.SPDXID == rootID:
+			err = g.addVersionKey(pkg.SPDXID, dependenciesloader.VersionKey{Name: pkg.Name, Version: pkg.VersionInfo})
+		default:
+			continue
+		}
+		if err != nil {
+			return dependenciesloader.Dependencies{}, fmt.Errorf("invalid package %s: %w", pkg.Name, err)
+		}
+	}
+	if index, ok := g.indexes[rootID]; ok {
+		g.root = index
+	}
+
+	for _, relationship := range document.Relationships {
+		switch relationship.RelationshipType {
+		case RelationshipDependsOn:
+			g.addEdge(relationship.SPDXElementID, relationship.RelatedSPDXElement)
+		case "DEPENDENCY_OF":
+			g.addEdge(relationship.RelatedSPDXElement, relationship.SPDXElementID)
+		}
+	}
+
+	return g.build(), nil
+}
+
+// parseTagValue reads packages and relationships of an SPDX tag-value document.
+func parseTagValue(data []byte) (spdxImport, error) {
+	var document spdxImport
+	var pkg *Package
+	inText := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inText {
+			inText = !strings.Contains(line, "</text>")
+			continue
+		}
+		tag, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "<text>") && !strings.Contains(value, "</text>") {
+			inText = true
+			continue
+		}
+
+		switch strings.TrimSpace(tag) {
+		case "PackageName":
+			document.Packages = append(document.Packages, Package{Name: value})
+			pkg = &document.Packages[len(document.Packages)-1]
+		case "SPDXID":
+			if pkg != nil {
+				pkg.SPDXID = value
+			}
+		case "PackageVersion":
+			if pkg != nil {
+				pkg.VersionInfo = value
+			}
+		case "ExternalRef":
+			fields := strings.Fields(value)
+			if pkg != nil && len(fields) == 3 {
+				pkg.ExternalRefs = append(pkg.ExternalRefs, ExternalRef{fields[0], fields[1], fields[2]})
+			}
+		case "Relationship":
+			fields := strings.Fields(value)
+			if len(fields) != 3 {
+				return spdxImport{}, fmt.Errorf("invalid relationship: %s", value)
+			}
+			document.Relationships = append(document.Relationships, Relationship{fields[0], fields[1], fields[2]})
+		case "FileName", "SnippetSPDXID", "LicenseID":
+			// Packages end where files, snippets or extracted licenses start.
+			pkg = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return spdxImport{}, fmt.Errorf("failed to read SPDX document: %w", err)
+	}
+
+	return document, nil
+}
+
+// FileSource reads the dependency graph from an SBOM file, every time it is asked for it,
+// so that updates pick up changes of the file.
+type FileSource string
+
+func (f FileSource) Dependencies() (dependenciesloader.Dependencies, error) {
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return dependenciesloader.Dependencies{}, fmt.Errorf("failed to read SBOM: %w", err)
+	}
+	return Import(data)
+}
@@ -0,0 +1,166 @@
+package sbom
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+type importedNode struct {
+	VersionKey dependenciesloader.VersionKey
+	Relation   string
+}
+
+// summarize makes graphs comparable regardless of the order of nodes. Nodes and edges are
+// identified by names and versions of the nodes.
+func summarize(dependencies dependenciesloader.Dependencies) (map[string]importedNode, [][2]string) {
+	nodes := map[string]importedNode{}
+	for _, node := range dependencies.Nodes {
+		nodes[keyOf(node.VersionKey)] = importedNode{node.VersionKey, node.Relation}
+	}
+	edges := [][2]string{}
+	for _, edge := range dependencies.Edges {
+		edges = append(edges, [2]string{keyOf(dependencies.Nodes[edge.FromNode].VersionKey), keyOf(dependencies.Nodes[edge.ToNode].VersionKey)})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i][0]+" "+edges[i][1] < edges[j][0]+" "+edges[j][1]
+	})
+	return nodes, edges
+}
+
+func keyOf(versionKey dependenciesloader.VersionKey) string {
+	return versionKey.Name + "@" + versionKey.Version
+}
+
+func TestImportExported(t *testing.T) {
+	dependencies, details, projectKeyIDs := testGraph()
+	_, wantEdges := summarize(dependencies)
+	wantNodes := map[string]importedNode{}
+	// the root depends on chroma too
+	for i, relation := range []string{"SELF", "DIRECT", "DIRECT", "INDIRECT"} {
+		wantNodes[keyOf(dependencies.Nodes[i].VersionKey)] = importedNode{dependencies.Nodes[i].VersionKey, relation}
+	}
+
+	for _, test := range []struct{ standard, format string }{
+		{StandardCycloneDX, FormatJSON},
+		{StandardCycloneDX, FormatXML},
+		{StandardSPDX, FormatJSON},
+		{StandardSPDX, FormatTagValue},
+	} {
+		t.Run(test.standard+" "+test.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Export(&buf, test.standard, test.format, dependencies, details, projectKeyIDs); err != nil {
+				t.Fatal("failed to export:", err)
+			}
+			imported, err := Import(buf.Bytes())
+			if err != nil {
+				t.Fatal("failed to import:", err)
+			}
+			gotNodes, gotEdges := summarize(imported)
+			if diff := cmp.Diff(wantNodes, gotNodes); diff != "" {
+				t.Errorf("unexpected nodes (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(wantEdges, gotEdges); diff != "" {
+				t.Errorf("unexpected edges (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestImportCycloneDX(t *testing.T) {
+	document := `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.4",
+		"metadata": {"component": {"type": "application", "bom-ref": "root", "name": "internal-service", "version": "1.0.0"}},
+		"components": [
+			{"type": "library", "bom-ref": "express", "name": "express", "version": "4.18.2", "purl": "pkg:npm/express@4.18.2",
+				"components": [{"type": "library", "bom-ref": "scoped", "name": "core", "purl": "pkg:npm/%40angular/core@17.0.0"}]},
+			{"type": "library", "bom-ref": "guava", "name": "guava", "purl": "pkg:maven/com.google.guava/guava@32.1.2-jre?type=jar"},
+			{"type": "file", "bom-ref": "file", "name": "README.md"}
+		],
+		"dependencies": [
+			{"ref": "root", "dependsOn": ["express", "file"]},
+			{"ref": "express", "dependsOn": ["scoped", "missing"]}
+		]
+	}`
+
+	imported, err := Import([]byte(document))
+	if err != nil {
+		t.Fatal("failed to import:", err)
+	}
+	gotNodes, gotEdges := summarize(imported)
+
+	wantNodes := map[string]importedNode{
+		"internal-service@1.0.0": {dependenciesloader.VersionKey{Name: "internal-service", Version: "1.0.0"}, "SELF"},
+		"express@4.18.2":         {dependenciesloader.VersionKey{System: "NPM", Name: "express", Version: "4.18.2"}, "DIRECT"},
+		"@angular/core@17.0.0":   {dependenciesloader.VersionKey{System: "NPM", Name: "@angular/core", Version: "17.0.0"}, "INDIRECT"},
+		"com.google.guava:guava@32.1.2-jre": {
+			dependenciesloader.VersionKey{System: "MAVEN", Name: "com.google.guava:guava", Version: "32.1.2-jre"}, "INDIRECT",
+		},
+	}
+	wantEdges := [][2]string{{"express@4.18.2", "@angular/core@17.0.0"}, {"internal-service@1.0.0", "express@4.18.2"}}
+	if diff := cmp.Diff(wantNodes, gotNodes); diff != "" {
+		t.Errorf("unexpected nodes (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantEdges, gotEdges); diff != "" {
+		t.Errorf("unexpected edges (-want +got):\n%s", diff)
+	}
+}
+
+func TestImportRepeatedPackage(t *testing.T) {
+	// ms is installed at 2.1.3 for the root and at 2.1.2 for debug, as npm does
+	document := `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"metadata": {"component": {"bom-ref": "root", "name": "internal-app", "version": "1.0.0"}},
+		"components": [
+			{"bom-ref": "ms@2.1.3", "name": "ms", "purl": "pkg:npm/ms@2.1.3"},
+			{"bom-ref": "debug@4.3.4", "name": "debug", "purl": "pkg:npm/debug@4.3.4"},
+			{"bom-ref": "ms@2.1.2", "name": "ms", "purl": "pkg:npm/ms@2.1.2"},
+			{"bom-ref": "ms-again", "name": "ms", "purl": "pkg:npm/ms@2.1.3"}
+		],
+		"dependencies": [
+			{"ref": "root", "dependsOn": ["ms-again", "debug@4.3.4"]},
+			{"ref": "debug@4.3.4", "dependsOn": ["ms@2.1.2"]}
+		]
+	}`
+
+	imported, err := Import([]byte(document))
+	if err != nil {
+		t.Fatal("failed to import:", err)
+	}
+	gotNodes, gotEdges := summarize(imported)
+
+	wantNodes := map[string]importedNode{
+		"internal-app@1.0.0": {dependenciesloader.VersionKey{Name: "internal-app", Version: "1.0.0"}, "SELF"},
+		"ms@2.1.3":           {dependenciesloader.VersionKey{System: "NPM", Name: "ms", Version: "2.1.3"}, "DIRECT"},
+		"debug@4.3.4":        {dependenciesloader.VersionKey{System: "NPM", Name: "debug", Version: "4.3.4"}, "DIRECT"},
+		"ms@2.1.2":           {dependenciesloader.VersionKey{System: "NPM", Name: "ms", Version: "2.1.2"}, "INDIRECT"},
+	}
+	wantEdges := [][2]string{{"debug@4.3.4", "ms@2.1.2"}, {"internal-app@1.0.0", "debug@4.3.4"}, {"internal-app@1.0.0", "ms@2.1.3"}}
+	if diff := cmp.Diff(wantNodes, gotNodes); diff != "" {
+		t.Errorf("unexpected nodes (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantEdges, gotEdges); diff != "" {
+		t.Errorf("unexpected edges (-want +got):\n%s", diff)
+	}
+	// the version listed first is the one stored
+	if imported.Nodes[1].VersionKey.Version != "2.1.3" {
+		t.Errorf("want ms 2.1.3 listed first, got %v", imported.Nodes[1].VersionKey)
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	for _, document := range []string{
+		``,
+		`{"name": "not an SBOM"}`,
+		`{"bomFormat": "CycloneDX", "components": [{"name": "x", "purl": "npm/x@1"}]}`,
+	} {
+		if _, err := Import([]byte(document)); err == nil {
+			t.Errorf("expected an error for %q", document)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package sbom exports the stored dependency graph as a Software Bill of Materials.
+package sbom
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"slices"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/licenses"
+)
+
+const (
+	StandardCycloneDX = "cyclonedx"
+	StandardSPDX      = "spdx"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatXML      = "xml"
+	FormatTagValue = "tag-value"
+)
+
+// Formats lists the formats supported by every standard, the first one is the default.
+var Formats = map[string][]string{
+	StandardCycloneDX: {FormatJSON, FormatXML},
+	StandardSPDX:      {FormatJSON, FormatTagValue},
+}
+
+var contentTypes = map[string]string{
+	StandardCycloneDX + FormatJSON: "application/vnd.cyclonedx+json; version=1.5",
+	StandardCycloneDX + FormatXML:  "application/vnd.cyclonedx+xml; version=1.5",
+	StandardSPDX + FormatJSON:      "application/spdx+json",
+	StandardSPDX + FormatTagValue:  "text/spdx; charset=utf-8",
+}
+
+// Validate checks that the standard is supported and returns the format, or the default one if empty.
+func Validate(standard, format string) (string, error) {
+	formats, ok := Formats[standard]
+	if !ok {
+		return "", fmt.Errorf("unsupported SBOM standard: %s, expected %s or %s", standard, StandardCycloneDX, StandardSPDX)
+	}
+	if format == "" {
+		return formats[0], nil
+	}
+	if !slices.Contains(formats, format) {
+		return "", fmt.Errorf("unsupported %s format: %s, expected one of %v", standard, format, formats)
+	}
+	return format, nil
+}
+
+// ContentType returns the media type of documents of the standard in the format.
+func ContentType(standard, format string) string {
+	return contentTypes[standard+format]
+}
+
+// Export builds an SBOM of the dependency graph in the standard and writes it in the format.
+// projectKeyIDs maps names of nodes to the projects of their details, see detailsByName.
+func Export(w io.Writer, standard, format string, dependencies dependenciesloader.Dependencies, details []dependenciesloader.DependencyDetails, projectKeyIDs map[string]string) error {
+	format, err := Validate(standard, format)
+	if err != nil {
+		return err
+	}
+
+	if standard == StandardSPDX {
+		document, err := SPDX(dependencies, details, projectKeyIDs)
+		if err != nil {
+			return err
+		}
+		return WriteSPDX(w, document, format)
+	}
+
+	bom, err := CycloneDX(dependencies, details, projectKeyIDs)
+	if err != nil {
+		return err
+	}
+	return WriteCycloneDX(w, bom, format)
+}
+
+const toolName = "deps-dev-assignment"
+
+// nonStandardLicense is reported by deps.dev for licenses which could not be mapped to SPDX identifiers.
+const nonStandardLicense = "non-standard"
+
+// spdxExpression returns the license as an SPDX expression, or false if it is not one.
+func spdxExpression(license string) (string, bool) {
+	if license == "" || license == nonStandardLicense {
+		return "", false
+	}
+	expression, err := licenses.Parse(license)
+	if err != nil {
+		return "", false
+	}
+	return expression.String(), true
+}
+
+// detailsByName indexes project details by the names of graph nodes. deps.dev names projects after
+// their repositories, e.g. github.com/alecaivazis/survey for the module github.com/AlecAivazis/survey/v2,
+// so nodes are mapped to their projects by projectKeyIDs. Nodes missing there are looked up by name.
+func detailsByName(dependencies dependenciesloader.Dependencies, details []dependenciesloader.DependencyDetails, projectKeyIDs map[string]string) map[string]dependenciesloader.DependencyDetails {
+	byID := make(map[string]dependenciesloader.DependencyDetails, len(details))
+	for _, detail := range details {
+		byID[detail.ProjectKey.ID] = detail
+	}
+	byName := make(map[string]dependenciesloader.DependencyDetails, len(dependencies.Nodes))
+	for _, node := range dependencies.Nodes {
+		id, ok := projectKeyIDs[node.VersionKey.Name]
+		if !ok {
+			id = node.VersionKey.Name
+		}
+		if detail, ok := byID[id]; ok {
+			byName[node.VersionKey.Name] = detail
+		}
+	}
+	return byName
+}
+
+// dependsOn returns the indexes of direct dependencies of every node, in the order of edges.
+func dependsOn(dependencies dependenciesloader.Dependencies) map[int][]int {
+	result := map[int][]int{}
+	seen := map[[2]int]bool{}
+	for _, edge := range dependencies.Edges {
+		key := [2]int{edge.FromNode, edge.ToNode}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result[edge.FromNode] = append(result[edge.FromNode], edge.ToNode)
+	}
+	return result
+}
+
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate UUID: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
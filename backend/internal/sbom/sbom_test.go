@@ -0,0 +1,331 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+)
+
+// testGraph returns a graph with details of the projects as returned by deps.dev, the project of
+// github.com/AlecAivazis/survey/v2 is github.com/alecaivazis/survey and github.com/kr/pty has none.
+func testGraph() (dependenciesloader.Dependencies, []dependenciesloader.DependencyDetails, map[string]string) {
+	dependencies := dependenciesloader.Dependencies{
+		Nodes: []dependenciesloader.Node{
+			{VersionKey: dependenciesloader.VersionKey{System: "GO", Name: "github.com/cli/cli", Version: "v1.14.0"}, Relation: "SELF"},
+			{VersionKey: dependenciesloader.VersionKey{System: "GO", Name: "github.com/AlecAivazis/survey/v2", Version: "v2.2.14"}, Relation: "DIRECT"},
+			{VersionKey: dependenciesloader.VersionKey{System: "GO", Name: "github.com/alecthomas/chroma", Version: "v0.8.2"}, Relation: "INDIRECT"},
+			{VersionKey: dependenciesloader.VersionKey{System: "GO", Name: "github.com/kr/pty", Version: "v1.1.4+incompatible"}, Relation: "INDIRECT"},
+		},
+		Edges: []dependenciesloader.Edge{
+			{FromNode: 0, ToNode: 1, Requirement: "v2.2.14"},
+			{FromNode: 1, ToNode: 3, Requirement: "v1.1.4"},
+			{FromNode: 0, ToNode: 2, Requirement: "v0.8.2"},
+			{FromNode: 1, ToNode: 2, Requirement: "v0.8.2"},
+		},
+	}
+	details := []dependenciesloader.DependencyDetails{
+		{
+			ProjectKey:  dependenciesloader.ProjectKey{ID: "github.com/cli/cli"},
+			License:     "MIT",
+			Description: "GitHub’s official command line tool",
+			Homepage:    "https://cli.github.com",
+			Scorecard: dependenciesloader.Scorecard{
+				Date:         "2025-02-03T00:00:00Z",
+				OverallScore: 8.4,
+				Checks:       []dependenciesloader.Check{{Name: "Code-Review", Score: 9}, {Name: "Packaging", Score: -1}},
+			},
+		},
+		{
+			ProjectKey: dependenciesloader.ProjectKey{ID: "github.com/alecaivazis/survey"},
+			License:    "Apache-2.0 OR MIT",
+			Homepage:   "https://github.com/AlecAivazis/survey",
+		},
+		{
+			ProjectKey: dependenciesloader.ProjectKey{ID: "github.com/alecthomas/chroma"},
+			License:    "non-standard",
+		},
+	}
+	projectKeyIDs := map[string]string{
+		"github.com/cli/cli":               "github.com/cli/cli",
+		"github.com/AlecAivazis/survey/v2": "github.com/alecaivazis/survey",
+		"github.com/alecthomas/chroma":     "github.com/alecthomas/chroma",
+	}
+	return dependencies, details, projectKeyIDs
+}
+
+func TestCycloneDX(t *testing.T) {
+	bom, err := CycloneDX(testGraph())
+	if err != nil {
+		t.Fatal("failed to create BOM:", err)
+	}
+
+	if bom.Metadata.Component == nil || bom.Metadata.Component.Purl != "pkg:golang/github.com/cli/cli@v1.14.0" {
+		t.Fatalf("root component should describe the SELF node, got: %+v", bom.Metadata.Component)
+	}
+
+	wantProperties := []Property{
+		{Name: "deps.dev:scorecard:date", Value: "2025-02-03T00:00:00Z"},
+		{Name: "deps.dev:scorecard:overallScore", Value: "8.4"},
+		{Name: "deps.dev:scorecard:check:Code-Review", Value: "9"},
+		{Name: "deps.dev:scorecard:check:Packaging", Value: "-1"},
+	}
+	if diff := cmp.Diff(wantProperties, bom.Metadata.Component.Properties); diff != "" {
+		t.Fatalf("unexpected properties (-want +got):\n%s", diff)
+	}
+
+	wantLicenses := map[string][]LicenseChoice{
+		"github.com/AlecAivazis/survey/v2": {{Expression: "Apache-2.0 OR MIT"}},
+		"github.com/alecthomas/chroma":     {{License: &License{Name: "non-standard"}}},
+		"github.com/kr/pty":                nil,
+	}
+	gotLicenses := map[string][]LicenseChoice{}
+	for _, component := range bom.Components {
+		gotLicenses[component.Name] = component.Licenses
+	}
+	if diff := cmp.Diff(wantLicenses, gotLicenses); diff != "" {
+		t.Fatalf("unexpected licenses (-want +got):\n%s", diff)
+	}
+
+	wantDependencies := []Dependency{
+		{Ref: "pkg:golang/github.com/cli/cli@v1.14.0", DependsOn: []string{
+			"pkg:golang/github.com/AlecAivazis/survey/v2@v2.2.14",
+			"pkg:golang/github.com/alecthomas/chroma@v0.8.2",
+		}},
+		{Ref: "pkg:golang/github.com/AlecAivazis/survey/v2@v2.2.14", DependsOn: []string{
+			"pkg:golang/github.com/alecthomas/chroma@v0.8.2",
+			"pkg:golang/github.com/kr/pty@v1.1.4%2Bincompatible",
+		}},
+		{Ref: "pkg:golang/github.com/alecthomas/chroma@v0.8.2", DependsOn: []string{}},
+		{Ref: "pkg:golang/github.com/kr/pty@v1.1.4%2Bincompatible", DependsOn: []string{}},
+	}
+	if diff := cmp.Diff(wantDependencies, bom.Dependencies); diff != "" {
+		t.Fatalf("unexpected dependencies (-want +got):\n%s", diff)
+	}
+}
+
+func TestCycloneDXJSONSchema(t *testing.T) {
+	bom, err := CycloneDX(testGraph())
+	if err != nil {
+		t.Fatal("failed to create BOM:", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteCycloneDX(&buf, bom, FormatJSON); err != nil {
+		t.Fatal("failed to write BOM:", err)
+	}
+
+	schema := compileSchema(t, "http://cyclonedx.org/schema/", "bom-1.5.schema.json", "spdx.schema.json", "jsf-0.82.schema.json")
+	var document any
+	if err := json.Unmarshal(buf.Bytes(), &document); err != nil {
+		t.Fatal("failed to parse BOM:", err)
+	}
+	if err := schema.Validate(document); err != nil {
+		t.Fatalf("BOM does not match the CycloneDX schema: %#v", err)
+	}
+}
+
+func TestCycloneDXXML(t *testing.T) {
+	bom, err := CycloneDX(testGraph())
+	if err != nil {
+		t.Fatal("failed to create BOM:", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteCycloneDX(&buf, bom, FormatXML); err != nil {
+		t.Fatal("failed to write BOM:", err)
+	}
+
+	var got xmlBom
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal("failed to parse BOM:", err)
+	}
+	if got.XMLName.Space != cycloneDXNamespace {
+		t.Fatalf("unexpected namespace: %q", got.XMLName.Space)
+	}
+	got.XMLName = xml.Name{}
+	if diff := cmp.Diff(toXML(bom), got); diff != "" {
+		t.Fatalf("unexpected BOM (-want +got):\n%s", diff)
+	}
+}
+
+func TestCycloneDXXMLSchema(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint is needed to validate BOMs against the CycloneDX XML schema")
+	}
+	bom, err := CycloneDX(testGraph())
+	if err != nil {
+		t.Fatal("failed to create BOM:", err)
+	}
+	file := path.Join(t.TempDir(), "bom.xml")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal("failed to create BOM file:", err)
+	}
+	if err := WriteCycloneDX(f, bom, FormatXML); err != nil {
+		t.Fatal("failed to write BOM:", err)
+	}
+	f.Close()
+
+	// bom-1.5.xsd imports spdx.xsd from the same directory
+	cwd, _ := os.Getwd()
+	output, err := exec.Command(xmllint, "--noout", "--nonet", "--schema", path.Join(cwd, "test_data", "bom-1.5.xsd"), file).CombinedOutput()
+	if err != nil {
+		t.Fatalf("BOM does not match the CycloneDX XML schema: %v\n%s", err, output)
+	}
+}
+
+func TestSPDX(t *testing.T) {
+	document, err := SPDX(testGraph())
+	if err != nil {
+		t.Fatal("failed to create SPDX document:", err)
+	}
+
+	wantLicenses := map[string]string{
+		"github.com/cli/cli":               "MIT",
+		"github.com/AlecAivazis/survey/v2": "Apache-2.0 OR MIT",
+		"github.com/alecthomas/chroma":     "NOASSERTION",
+		"github.com/kr/pty":                "NOASSERTION",
+	}
+	gotLicenses := map[string]string{}
+	for _, pkg := range document.Packages {
+		gotLicenses[pkg.Name] = pkg.LicenseDeclared
+	}
+	if diff := cmp.Diff(wantLicenses, gotLicenses); diff != "" {
+		t.Fatalf("unexpected licenses (-want +got):\n%s", diff)
+	}
+
+	if got := document.Packages[1].Homepage; got != "https://github.com/AlecAivazis/survey" {
+		t.Fatalf("want the homepage of the project of survey, got %q", got)
+	}
+	if got := document.Packages[3].ExternalRefs[0].ReferenceLocator; got != "pkg:golang/github.com/kr/pty@v1.1.4%2Bincompatible" {
+		t.Fatalf("unexpected purl: %s", got)
+	}
+
+	wantRelationships := []Relationship{
+		{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-Package-github.com-cli-cli"},
+		{"SPDXRef-Package-github.com-cli-cli", "DEPENDS_ON", "SPDXRef-Package-github.com-AlecAivazis-survey-v2"},
+		{"SPDXRef-Package-github.com-cli-cli", "DEPENDS_ON", "SPDXRef-Package-github.com-alecthomas-chroma"},
+		{"SPDXRef-Package-github.com-AlecAivazis-survey-v2", "DEPENDS_ON", "SPDXRef-Package-github.com-kr-pty"},
+		{"SPDXRef-Package-github.com-AlecAivazis-survey-v2", "DEPENDS_ON", "SPDXRef-Package-github.com-alecthomas-chroma"},
+	}
+	if diff := cmp.Diff(wantRelationships, document.Relationships); diff != "" {
+		t.Fatalf("unexpected relationships (-want +got):\n%s", diff)
+	}
+}
+
+func TestSPDXJSONSchema(t *testing.T) {
+	document, err := SPDX(testGraph())
+	if err != nil {
+		t.Fatal("failed to create SPDX document:", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteSPDX(&buf, document, FormatJSON); err != nil {
+		t.Fatal("failed to write SPDX document:", err)
+	}
+
+	schema := compileSchema(t, "http://spdx.org/rdf/terms/", "spdx-schema-2.3.json")
+	var got any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal("failed to parse SPDX document:", err)
+	}
+	if err := schema.Validate(got); err != nil {
+		t.Fatalf("document does not match the SPDX schema: %#v", err)
+	}
+}
+
+func TestSPDXTagValue(t *testing.T) {
+	document, err := SPDX(testGraph())
+	if err != nil {
+		t.Fatal("failed to create SPDX document:", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteSPDX(&buf, document, FormatTagValue); err != nil {
+		t.Fatal("failed to write SPDX document:", err)
+	}
+
+	for _, want := range []string{
+		"SPDXVersion: SPDX-2.3\n",
+		"DocumentName: github.com/cli/cli\n",
+		"PackageName: github.com/AlecAivazis/survey/v2\nSPDXID: SPDXRef-Package-github.com-AlecAivazis-survey-v2\nPackageVersion: v2.2.14\n",
+		"PackageLicenseDeclared: Apache-2.0 OR MIT\n",
+		"PackageHomePage: https://github.com/AlecAivazis/survey\n",
+		"ExternalRef: PACKAGE-MANAGER purl pkg:golang/github.com/cli/cli@v1.14.0\n",
+		"Relationship: SPDXRef-Package-github.com-cli-cli DEPENDS_ON SPDXRef-Package-github.com-alecthomas-chroma\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("tag-value document does not contain %q", want)
+		}
+	}
+}
+
+func TestDetailsByName(t *testing.T) {
+	dependencies, details, _ := testGraph()
+	tests := []struct {
+		name          string
+		projectKeyIDs map[string]string
+		want          map[string]string
+	}{
+		{
+			name:          "linked projects",
+			projectKeyIDs: map[string]string{"github.com/AlecAivazis/survey/v2": "github.com/alecaivazis/survey"},
+			want: map[string]string{
+				"github.com/cli/cli":               "github.com/cli/cli",
+				"github.com/AlecAivazis/survey/v2": "github.com/alecaivazis/survey",
+				"github.com/alecthomas/chroma":     "github.com/alecthomas/chroma",
+			},
+		},
+		{
+			name: "projects named like the packages",
+			want: map[string]string{
+				"github.com/cli/cli":           "github.com/cli/cli",
+				"github.com/alecthomas/chroma": "github.com/alecthomas/chroma",
+			},
+		},
+		{
+			name:          "project without details",
+			projectKeyIDs: map[string]string{"github.com/cli/cli": "github.com/cli/go-gh"},
+			want:          map[string]string{"github.com/alecthomas/chroma": "github.com/alecthomas/chroma"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := map[string]string{}
+			for name, detail := range detailsByName(dependencies, details, test.projectKeyIDs) {
+				got[name] = detail.ProjectKey.ID
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Fatalf("unexpected details (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// compileSchema compiles the first of the schema files in test_data, the other files are resources it refers to.
+// Files are added under the base URL, matching their $id.
+func compileSchema(t *testing.T, base string, files ...string) *jsonschema.Schema {
+	compiler := jsonschema.NewCompiler()
+	cwd, _ := os.Getwd()
+	for _, file := range files {
+		f, err := os.Open(path.Join(cwd, "test_data", file))
+		if err != nil {
+			t.Fatal("failed to read schema:", err)
+		}
+		defer f.Close()
+		if err := compiler.AddResource(base+file, f); err != nil {
+			t.Fatal("failed to add schema:", err)
+		}
+	}
+	schema, err := compiler.Compile(base + files[0])
+	if err != nil {
+		t.Fatal("failed to compile schema:", err)
+	}
+	return schema
+}
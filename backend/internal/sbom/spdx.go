@@ -0,0 +1,242 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	dependenciesloader "github.com/wojcikp/deps-dev-assignment/backend/internal/dependencies_loader"
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/purl"
+)
+
+const (
+	spdxVersion     = "SPDX-2.3"
+	spdxDataLicense = "CC0-1.0"
+	spdxDocumentID  = "SPDXRef-DOCUMENT"
+	noAssertion     = "NOASSERTION"
+)
+
+const (
+	RelationshipDescribes = "DESCRIBES"
+	RelationshipDependsOn = "DEPENDS_ON"
+)
+
+// Document is an SPDX 2.3 document, see https://spdx.github.io/spdx-spec/v2.3/.
+// Only the parts filled from the stored graph are modelled.
+type Document struct {
+	SPDXVersion       string         `json:"spdxVersion"`
+	DataLicense       string         `json:"dataLicense"`
+	SPDXID            string         `json:"SPDXID"`
+	Name              string         `json:"name"`
+	DocumentNamespace string         `json:"documentNamespace"`
+	CreationInfo      CreationInfo   `json:"creationInfo"`
+	Packages          []Package      `json:"packages"`
+	Relationships     []Relationship `json:"relationships"`
+}
+
+type CreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type Package struct {
+	Name                  string        `json:"name"`
+	SPDXID                string        `json:"SPDXID"`
+	VersionInfo           string        `json:"versionInfo,omitempty"`
+	PrimaryPackagePurpose string        `json:"primaryPackagePurpose,omitempty"`
+	DownloadLocation      string        `json:"downloadLocation"`
+	FilesAnalyzed         bool          `json:"filesAnalyzed"`
+	Homepage              string        `json:"homepage,omitempty"`
+	LicenseConcluded      string        `json:"licenseConcluded"`
+	LicenseDeclared       string        `json:"licenseDeclared"`
+	CopyrightText         string        `json:"copyrightText"`
+	Description           string        `json:"description,omitempty"`
+	ExternalRefs          []ExternalRef `json:"externalRefs,omitempty"`
+}
+
+type ExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type Relationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+var invalidIDCharacters = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// SPDX builds an SPDX document of the dependency graph. The document describes the SELF node,
+// or every node if the graph has no root, and DEPENDS_ON relationships follow the graph edges.
+// Licenses which are not valid SPDX expressions are declared as NOASSERTION. Details of the packages are
+// the details of their projects, given by projectKeyIDs.
+func SPDX(dependencies dependenciesloader.Dependencies, details []dependenciesloader.DependencyDetails, projectKeyIDs map[string]string) (Document, error) {
+	id, err := newUUID()
+	if err != nil {
+		return Document{}, err
+	}
+
+	name := toolName
+	for _, node := range dependencies.Nodes {
+		if node.Relation == "SELF" {
+			name = node.VersionKey.Name
+			break
+		}
+	}
+
+	document := Document{
+		SPDXVersion:       spdxVersion,
+		DataLicense:       spdxDataLicense,
+		SPDXID:            spdxDocumentID,
+		Name:              name,
+		DocumentNamespace: "https://spdx.org/spdxdocs/" + invalidIDCharacters.ReplaceAllString(name, "-") + "-" + id,
+		CreationInfo: CreationInfo{
+			Created:  time.Now().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + toolName},
+		},
+		Packages:      []Package{},
+		Relationships: []Relationship{},
+	}
+
+	byName := detailsByName(dependencies, details, projectKeyIDs)
+	ids := make([]string, len(dependencies.Nodes))
+	used := map[string]bool{}
+	hasRoot := false
+	for i, node := range dependencies.Nodes {
+		ids[i] = packageID(node.VersionKey.Name, used)
+		document.Packages = append(document.Packages, packageOf(node, ids[i], byName))
+		if node.Relation == "SELF" {
+			hasRoot = true
+			document.Relationships = append(document.Relationships, Relationship{spdxDocumentID, RelationshipDescribes, ids[i]})
+		}
+	}
+	if !hasRoot {
+		for _, id := range ids {
+			document.Relationships = append(document.Relationships, Relationship{spdxDocumentID, RelationshipDescribes, id})
+		}
+	}
+
+	edges := dependsOn(dependencies)
+	for i := range dependencies.Nodes {
+		for _, to := range edges[i] {
+			if to >= 0 && to < len(ids) {
+				document.Relationships = append(document.Relationships, Relationship{ids[i], RelationshipDependsOn, ids[to]})
+			}
+		}
+	}
+
+	return document, nil
+}
+
+// packageID derives an SPDX identifier from the package name, names differing only in characters
+// not allowed in identifiers get a numeric suffix.
+func packageID(name string, used map[string]bool) string {
+	base := "SPDXRef-Package-" + strings.Trim(invalidIDCharacters.ReplaceAllString(name, "-"), "-")
+	id := base
+	for i := 2; used[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	used[id] = true
+	return id
+}
+
+func packageOf(node dependenciesloader.Node, id string, details map[string]dependenciesloader.DependencyDetails) Package {
+	pkg := Package{
+		Name:                  node.VersionKey.Name,
+		SPDXID:                id,
+		VersionInfo:           node.VersionKey.Version,
+		PrimaryPackagePurpose: "LIBRARY",
+		DownloadLocation:      noAssertion,
+		LicenseConcluded:      noAssertion,
+		LicenseDeclared:       noAssertion,
+		CopyrightText:         noAssertion,
+		ExternalRefs: []ExternalRef{{
+			ReferenceCategory: "PACKAGE-MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  purl.FromVersionKey(node.VersionKey),
+		}},
+	}
+	if node.Relation == "SELF" {
+		pkg.PrimaryPackagePurpose = "APPLICATION"
+	}
+
+	if detail, ok := details[node.VersionKey.Name]; ok {
+		if expression, ok := spdxExpression(detail.License); ok {
+			pkg.LicenseDeclared = expression
+		}
+		pkg.Homepage = detail.Homepage
+		pkg.Description = detail.Description
+	}
+
+	return pkg
+}
+
+// WriteSPDX encodes the document in the SPDX JSON or tag-value format.
+func WriteSPDX(w io.Writer, document Document, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(document)
+	case FormatTagValue:
+		return writeTagValue(w, document)
+	default:
+		return fmt.Errorf("unsupported SPDX format: %q", format)
+	}
+}
+
+func writeTagValue(w io.Writer, document Document) error {
+	var b strings.Builder
+	tag := func(name, value string) {
+		if value == "" {
+			return
+		}
+		if strings.Contains(value, "\n") {
+			value = "<text>" + value + "</text>"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", name, value)
+	}
+
+	tag("SPDXVersion", document.SPDXVersion)
+	tag("DataLicense", document.DataLicense)
+	tag("SPDXID", document.SPDXID)
+	tag("DocumentName", document.Name)
+	tag("DocumentNamespace", document.DocumentNamespace)
+	for _, creator := range document.CreationInfo.Creators {
+		tag("Creator", creator)
+	}
+	tag("Created", document.CreationInfo.Created)
+
+	for _, pkg := range document.Packages {
+		fmt.Fprintf(&b, "\n##### Package: %s\n\n", pkg.Name)
+		tag("PackageName", pkg.Name)
+		tag("SPDXID", pkg.SPDXID)
+		tag("PackageVersion", pkg.VersionInfo)
+		tag("PrimaryPackagePurpose", pkg.PrimaryPackagePurpose)
+		tag("PackageDownloadLocation", pkg.DownloadLocation)
+		tag("FilesAnalyzed", fmt.Sprint(pkg.FilesAnalyzed))
+		tag("PackageHomePage", pkg.Homepage)
+		tag("PackageLicenseConcluded", pkg.LicenseConcluded)
+		tag("PackageLicenseDeclared", pkg.LicenseDeclared)
+		tag("PackageCopyrightText", pkg.CopyrightText)
+		tag("PackageDescription", pkg.Description)
+		for _, ref := range pkg.ExternalRefs {
+			tag("ExternalRef", ref.ReferenceCategory+" "+ref.ReferenceType+" "+ref.ReferenceLocator)
+		}
+	}
+
+	if len(document.Relationships) > 0 {
+		b.WriteString("\n##### Relationships\n\n")
+	}
+	for _, relationship := range document.Relationships {
+		tag("Relationship", relationship.SPDXElementID+" "+relationship.RelationshipType+" "+relationship.RelatedSPDXElement)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,306 @@
+package versions
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	ClassMajor      = "major"
+	ClassMinor      = "minor"
+	ClassPatch      = "patch"
+	ClassPrerelease = "prerelease"
+	ClassDowngrade  = "downgrade"
+	ClassNew        = "new"
+	ClassNone       = "none"
+	ClassUnknown    = "unknown"
+)
+
+// Classes lists every class a version change can be classified as.
+var Classes = []string{ClassMajor, ClassMinor, ClassPatch, ClassPrerelease, ClassDowngrade, ClassNew, ClassNone, ClassUnknown}
+
+// ParseClasses accepts update classes given either as repeated or comma separated values, e.g. of the
+// class parameter or flag. Unknown classes are an error.
+func ParseClasses(values []string) ([]string, error) {
+	classes := []string{}
+	for _, value := range values {
+		for _, class := range strings.Split(value, ",") {
+			if !slices.Contains(Classes, class) {
+				return nil, fmt.Errorf("invalid class: %s, expected one of: %s", class, strings.Join(Classes, ", "))
+			}
+			classes = append(classes, class)
+		}
+	}
+	return classes, nil
+}
+
+// Version is a parsed version normalized to semantic versioning, regardless of the system it comes from.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+	// Post is set for PyPI post-releases, e.g. ["2"] for 1.0.post2, which sort after their release.
+	// A development release of a post-release, e.g. 1.0.post2.dev1, has the dev segments appended.
+	Post  []string
+	Build string
+	// Pseudo is set for Go pseudo-versions, which point at a commit instead of a tag.
+	Pseudo bool
+}
+
+var (
+	semverRe = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+	pseudoRe = regexp.MustCompile(`(?:^|\.)\d{14}-[0-9a-f]{12}$`)
+	pypiRe   = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)(?:[-_.]?(a|alpha|b|beta|c|rc|pre|preview)[-_.]?(\d*))?(?:[-_.]?(post|rev|r)[-_.]?(\d*))?(?:[-_.]?(dev)[-_.]?(\d*))?(?:\+([a-z0-9.]+))?$`)
+	looseRe  = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)(?:[-.]?(.+))?$`)
+)
+
+// Parse parses version according to the versioning scheme of the deps.dev system it belongs to.
+func Parse(system, version string) (Version, error) {
+	switch strings.ToUpper(system) {
+	case "GO":
+		return parseGo(version)
+	case "NPM", "CARGO", "NUGET":
+		return parseSemver(version)
+	case "PYPI":
+		return parsePypi(version)
+	case "MAVEN":
+		return parseLoose(version)
+	default:
+		return Version{}, fmt.Errorf("unsupported system: %s", system)
+	}
+}
+
+func parseSemver(version string) (Version, error) {
+	m := semverRe.FindStringSubmatch(version)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid semantic version: %s", version)
+	}
+	v := Version{Build: m[5]}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	if m[4] != "" {
+		v.Prerelease = strings.Split(m[4], ".")
+	}
+	return v, nil
+}
+
+// parseGo accepts Go module versions, which always start with "v", may carry the
+// +incompatible build suffix and may be pseudo-versions such as v0.0.0-20191109021931-daa7c04131f5.
+func parseGo(version string) (Version, error) {
+	if !strings.HasPrefix(version, "v") {
+		return Version{}, fmt.Errorf("invalid go module version: %s", version)
+	}
+	v, err := parseSemver(version)
+	if err != nil {
+		return Version{}, err
+	}
+	if v.Build != "" && v.Build != "incompatible" {
+		return Version{}, fmt.Errorf("invalid go module version: %s", version)
+	}
+	v.Pseudo = pseudoRe.MatchString(strings.Join(v.Prerelease, "."))
+	return v, nil
+}
+
+func parsePypi(version string) (Version, error) {
+	m := pypiRe.FindStringSubmatch(strings.ToLower(version))
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid python package version: %s", version)
+	}
+	v, err := fromReleaseSegments(m[1], version)
+	if err != nil {
+		return Version{}, err
+	}
+	v.Build = m[8]
+	if m[2] != "" {
+		v.Prerelease = append(v.Prerelease, pypiPreLabel(m[2]), numberOrZero(m[3]))
+	}
+	if m[4] != "" {
+		v.Post = []string{numberOrZero(m[5])}
+	}
+	if m[6] != "" {
+		// A .devN release sorts before its final release, like a prerelease does.
+		if v.Post != nil {
+			v.Post = append(v.Post, "dev", numberOrZero(m[7]))
+		} else {
+			v.Prerelease = append(v.Prerelease, "dev", numberOrZero(m[7]))
+		}
+	}
+	return v, nil
+}
+
+func pypiPreLabel(label string) string {
+	switch label {
+	case "alpha":
+		return "a"
+	case "beta":
+		return "b"
+	case "c", "pre", "preview":
+		return "rc"
+	}
+	return label
+}
+
+// parseLoose handles schemes such as Maven's, where a version is a list of numbers
+// optionally followed by a qualifier like "RELEASE", "Final" or "beta-1".
+func parseLoose(version string) (Version, error) {
+	m := looseRe.FindStringSubmatch(version)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid version: %s", version)
+	}
+	v, err := fromReleaseSegments(m[1], version)
+	if err != nil {
+		return Version{}, err
+	}
+	switch qualifier := strings.ToLower(m[2]); qualifier {
+	case "", "final", "ga", "release":
+	default:
+		v.Prerelease = strings.FieldsFunc(qualifier, func(r rune) bool { return r == '.' || r == '-' })
+	}
+	return v, nil
+}
+
+func fromReleaseSegments(release, version string) (Version, error) {
+	segments := strings.Split(release, ".")
+	numbers := make([]int, 3)
+	for i, segment := range segments {
+		n, err := strconv.Atoi(segment)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version: %s", version)
+		}
+		if i < len(numbers) {
+			numbers[i] = n
+		}
+	}
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+func numberOrZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 depending on whether v is lower, equal or greater than other,
+// following semantic versioning precedence rules. Build metadata is ignored. A post-release
+// sorts after the version it was released for. Pseudo-versions follow the same rules, their
+// base and commit time are prerelease identifiers, so a pseudo-version sorts after the tag it
+// is based on and before the release following that tag.
+func (v Version) Compare(other Version) int {
+	if c := compareInts(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInts(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+	if c := comparePrerelease(v.Prerelease, other.Prerelease); c != 0 {
+		return c
+	}
+	return comparePost(v.Post, other.Post)
+}
+
+func comparePost(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return -1
+	case len(b) == 0:
+		return 1
+	}
+	an, _ := strconv.Atoi(a[0])
+	bn, _ := strconv.Atoi(b[0])
+	if c := compareInts(an, bn); c != 0 {
+		return c
+	}
+	// what is left are the dev segments, which sort before the post-release itself
+	return comparePrerelease(a[1:], b[1:])
+}
+
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		an, aErr := strconv.Atoi(a[i])
+		bn, bErr := strconv.Atoi(b[i])
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = compareInts(an, bn)
+		case aErr == nil:
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(a[i], b[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(a), len(b))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Classify tells what kind of change moving from one version to another is.
+// An empty from version means a newly introduced dependency.
+// Versions which cannot be parsed are classified as ClassUnknown.
+// An upgrade to a pseudo-version is ClassPrerelease, since an untagged commit isn't a release
+// whichever core version it is based on. A new post-release of the same version is ClassPatch.
+func Classify(system, from, to string) string {
+	if from == to {
+		return ClassNone
+	}
+	if from == "" {
+		return ClassNew
+	}
+	fromVersion, err := Parse(system, from)
+	if err != nil {
+		return ClassUnknown
+	}
+	toVersion, err := Parse(system, to)
+	if err != nil {
+		return ClassUnknown
+	}
+
+	switch c := toVersion.Compare(fromVersion); {
+	case c < 0:
+		return ClassDowngrade
+	case c == 0:
+		return ClassNone
+	case toVersion.Pseudo:
+		return ClassPrerelease
+	case toVersion.Major != fromVersion.Major:
+		return ClassMajor
+	case toVersion.Minor != fromVersion.Minor:
+		return ClassMinor
+	case toVersion.Patch != fromVersion.Patch:
+		return ClassPatch
+	case comparePrerelease(toVersion.Prerelease, fromVersion.Prerelease) == 0:
+		return ClassPatch
+	}
+	return ClassPrerelease
+}
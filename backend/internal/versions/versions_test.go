@@ -0,0 +1,123 @@
+package versions
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseGo(t *testing.T) {
+	tests := []struct {
+		version string
+		want    Version
+	}{
+		{"v1.14.0", Version{Major: 1, Minor: 14}},
+		{"v2.2.14", Version{Major: 2, Minor: 2, Patch: 14}},
+		{"v4.1.0+incompatible", Version{Major: 4, Minor: 1, Build: "incompatible"}},
+		{"v0.0.0-20191109021931-daa7c04131f5", Version{Prerelease: []string{"20191109021931-daa7c04131f5"}, Pseudo: true}},
+		{"v1.2.4-0.20191109021931-daa7c04131f5", Version{Major: 1, Minor: 2, Patch: 4, Prerelease: []string{"0", "20191109021931-daa7c04131f5"}, Pseudo: true}},
+		{"v1.0.0-rc.1", Version{Major: 1, Prerelease: []string{"rc", "1"}}},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse("GO", tt.version)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", tt.version, err)
+		}
+		if got.Compare(tt.want) != 0 || got.Build != tt.want.Build || got.Pseudo != tt.want.Pseudo {
+			t.Fatalf("unexpected version parsed from %s, want: %+v, got: %+v", tt.version, tt.want, got)
+		}
+	}
+
+	for _, invalid := range []string{"1.2.3", "v1.2", "v1.2.3+build"} {
+		if _, err := Parse("GO", invalid); err == nil {
+			t.Fatalf("expected an error for invalid go version %s", invalid)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		system, a, b string
+		want         int
+	}{
+		{"GO", "v1.0.0", "v1.0.0+incompatible", 0},
+		{"GO", "v1.0.0-alpha", "v1.0.0", -1},
+		{"GO", "v1.0.0-alpha.1", "v1.0.0-alpha.beta", -1},
+		{"GO", "v1.0.0-rc.2", "v1.0.0-rc.10", -1},
+		{"GO", "v0.0.0-20191109021931-daa7c04131f5", "v0.0.0-20200101000000-aaaaaaaaaaaa", -1},
+		{"NPM", "10.0.0", "9.9.9", 1},
+		{"PYPI", "1.0rc1", "1.0", -1},
+		{"PYPI", "2.31", "2.31.0", 0},
+		{"PYPI", "1.0.post1", "1.0", 1},
+		{"PYPI", "1.0.post1", "1.0.post2", -1},
+		{"PYPI", "1.0.post1", "1.0-post1", 0},
+		{"PYPI", "1.0.post1.dev0", "1.0.post1", -1},
+		{"PYPI", "1.0.post1.dev0", "1.0", 1},
+		{"PYPI", "1.0rc1.post1", "1.0rc2", -1},
+		{"PYPI", "1.0+local", "1.0", 0},
+		{"GO", "v1.2.3", "v1.2.4-0.20191109021931-daa7c04131f5", -1},
+		{"GO", "v1.2.4-0.20191109021931-daa7c04131f5", "v1.2.4", -1},
+		{"GO", "v1.2.4-0.20191109021931-daa7c04131f5", "v1.2.4-alpha", -1},
+		{"GO", "v1.2.4-alpha.0.20191109021931-daa7c04131f5", "v1.2.4-alpha", 1},
+		{"MAVEN", "5.3.1.RELEASE", "5.3.1", 0},
+		{"MAVEN", "1.0-beta-1", "1.0", -1},
+	}
+
+	for _, tt := range tests {
+		a, err := Parse(tt.system, tt.a)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", tt.a, err)
+		}
+		b, err := Parse(tt.system, tt.b)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", tt.b, err)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Fatalf("compare %s with %s, want: %d, got: %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+}
+
+func TestParseClasses(t *testing.T) {
+	got, err := ParseClasses([]string{"minor,patch", "new"})
+	if err != nil || !slices.Equal(got, []string{ClassMinor, ClassPatch, ClassNew}) {
+		t.Fatalf("unexpected classes: %v, %v", got, err)
+	}
+	for _, values := range [][]string{{"minr"}, {"patch,"}, {""}} {
+		if _, err := ParseClasses(values); err == nil {
+			t.Fatalf("want an error for the classes %q", values)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		system, from, to, want string
+	}{
+		{"GO", "v1.11.1", "v1.23.0", ClassMinor},
+		{"GO", "v1.11.1", "v1.11.2", ClassPatch},
+		{"GO", "v2.2.14", "v3.0.0", ClassMajor},
+		{"GO", "v1.23.0", "v1.11.1", ClassDowngrade},
+		{"GO", "v1.0.0-rc.1", "v1.0.0", ClassPrerelease},
+		{"GO", "v1.0.0", "v1.0.0", ClassNone},
+		{"GO", "v3.0.0", "v3.0.0+incompatible", ClassNone},
+		{"GO", "v0.0.0-20191109021931-daa7c04131f5", "v0.0.0-20200101000000-aaaaaaaaaaaa", ClassPrerelease},
+		{"GO", "master", "v1.0.0", ClassUnknown},
+		{"GO", "", "v1.0.0", ClassNew},
+		{"NPM", "4.17.20", "4.17.21", ClassPatch},
+		{"PYPI", "2.28.2", "2.31.0", ClassMinor},
+		{"PYPI", "1.0", "1.0.post1", ClassPatch},
+		{"PYPI", "1.0.post2", "1.0.post1", ClassDowngrade},
+		{"PYPI", "1.0rc1", "1.0rc1.post1", ClassPatch},
+		{"PYPI", "1.0rc1", "1.0.post1", ClassPrerelease},
+		{"GO", "v1.2.3", "v1.2.4-0.20191109021931-daa7c04131f5", ClassPrerelease},
+		{"GO", "v1.2.3", "v2.0.0-20191109021931-daa7c04131f5", ClassPrerelease},
+		{"GO", "v1.2.4-0.20191109021931-daa7c04131f5", "v1.3.0", ClassMinor},
+	}
+
+	for _, tt := range tests {
+		if got := Classify(tt.system, tt.from, tt.to); got != tt.want {
+			t.Fatalf("classify %s -> %s, want: %s, got: %s", tt.from, tt.to, tt.want, got)
+		}
+	}
+}
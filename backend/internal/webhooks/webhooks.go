@@ -0,0 +1,226 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+)
+
+const (
+	EventDependencyAdded     = "dependency.added"
+	EventVersionChanged      = "dependency.version_changed"
+	EventScoreBelowThreshold = "dependency.score_below_threshold"
+	EventLicenseChanged      = "dependency.license_changed"
+)
+
+const SignatureHeader = "X-Deps-Dev-Signature"
+
+const defaultMaxAttempts = 3
+
+type Webhook struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	// ScoreThreshold is the overallScore below which EventScoreBelowThreshold is sent.
+	ScoreThreshold float64 `json:"scoreThreshold"`
+	MaxAttempts    int     `json:"maxAttempts"`
+}
+
+type Config struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// Event is the JSON payload sent to webhooks.
+type Event struct {
+	Type                 string   `json:"event"`
+	Dependency           string   `json:"dependency"`
+	Purl                 string   `json:"purl,omitempty"`
+	Timestamp            string   `json:"timestamp"`
+	PreviousVersion      string   `json:"previousVersion,omitempty"`
+	Version              string   `json:"version,omitempty"`
+	PreviousOverallScore *float64 `json:"previousOverallScore,omitempty"`
+	OverallScore         *float64 `json:"overallScore,omitempty"`
+	Threshold            *float64 `json:"threshold,omitempty"`
+	PreviousLicense      string   `json:"previousLicense,omitempty"`
+	License              string   `json:"license,omitempty"`
+}
+
+type Dispatcher struct {
+	webhooks   []Webhook
+	db         *database.SQLiteDB
+	client     *http.Client
+	retryDelay time.Duration
+	wg         sync.WaitGroup
+}
+
+func NewDispatcher(config Config, db *database.SQLiteDB) *Dispatcher {
+	return &Dispatcher{
+		webhooks:   config.Webhooks,
+		db:         db,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		retryDelay: time.Second,
+	}
+}
+
+// LoadConfig reads webhooks configuration from a JSON file. An empty path means no webhooks.
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read webhooks config: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse webhooks config: %w", err)
+	}
+
+	for _, webhook := range config.Webhooks {
+		if webhook.URL == "" {
+			return config, fmt.Errorf("webhook url is required")
+		}
+		for _, event := range webhook.Events {
+			if !slices.Contains([]string{EventDependencyAdded, EventVersionChanged, EventScoreBelowThreshold, EventLicenseChanged}, event) {
+				return config, fmt.Errorf("unknown webhook event: %s", event)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// Notify delivers events to every subscribed webhook in the background.
+// Use Wait to block until all deliveries are finished.
+//
+// EventScoreBelowThreshold events carry the previous and the new score and are sent only to webhooks
+// whose threshold the new score has just fallen below, so they may be passed for every score change.
+func (d *Dispatcher) Notify(events ...Event) {
+	if d == nil {
+		return
+	}
+	for _, event := range events {
+		if event.Timestamp == "" {
+			event.Timestamp = time.Now().UTC().Format(time.RFC3339)
+		}
+		for _, webhook := range d.webhooks {
+			payload, ok := payloadFor(webhook, event)
+			if !ok {
+				continue
+			}
+			d.wg.Add(1)
+			go func() {
+				defer d.wg.Done()
+				d.deliver(webhook, payload)
+			}()
+		}
+	}
+}
+
+func (d *Dispatcher) Wait() {
+	if d == nil {
+		return
+	}
+	d.wg.Wait()
+}
+
+func payloadFor(webhook Webhook, event Event) (Event, bool) {
+	if !slices.Contains(webhook.Events, event.Type) {
+		return event, false
+	}
+	if event.Type != EventScoreBelowThreshold {
+		return event, true
+	}
+
+	if event.OverallScore == nil || *event.OverallScore >= webhook.ScoreThreshold {
+		return event, false
+	}
+	if event.PreviousOverallScore != nil && *event.PreviousOverallScore < webhook.ScoreThreshold {
+		return event, false
+	}
+	threshold := webhook.ScoreThreshold
+	event.Threshold = &threshold
+	return event, true
+}
+
+func (d *Dispatcher) deliver(webhook Webhook, event Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("failed to encode webhook payload: %v", err)
+		return
+	}
+
+	maxAttempts := webhook.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	delay := d.retryDelay
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		statusCode, err := d.send(webhook, payload)
+
+		delivery := database.WebhookDelivery{
+			URL:        webhook.URL,
+			Event:      event.Type,
+			Dependency: event.Dependency,
+			Payload:    string(payload),
+			Attempt:    attempt,
+			StatusCode: statusCode,
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		if logErr := d.db.LogWebhookDelivery(delivery); logErr != nil {
+			log.Printf("failed to log webhook delivery: %v", logErr)
+		}
+
+		if err == nil {
+			return
+		}
+		if attempt < maxAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	log.Printf("failed to deliver %s event to webhook %s after %d attempts", event.Type, webhook.URL, maxAttempts)
+}
+
+func (d *Dispatcher) send(webhook Webhook, payload []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("received non-2xx HTTP status: %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the value of SignatureHeader for the payload, which receivers can use
+// to verify that the request was sent by us.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
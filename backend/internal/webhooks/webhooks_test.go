@@ -0,0 +1,108 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/wojcikp/deps-dev-assignment/backend/internal/database"
+)
+
+func getTestDispatcher(t *testing.T, webhooks ...Webhook) (*Dispatcher, *database.SQLiteDB) {
+	db, err := database.NewSQLiteDB(path.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal("failed to create database:", err)
+	}
+	if err := db.CreateTables(); err != nil {
+		t.Fatal("failed to create tables:", err)
+	}
+	t.Cleanup(db.CloseDbConnection)
+
+	dispatcher := NewDispatcher(Config{Webhooks: webhooks}, db)
+	dispatcher.retryDelay = 0
+	return dispatcher, db
+}
+
+func TestNotifySignsAndRetries(t *testing.T) {
+	const secret = "test-secret"
+
+	var mu sync.Mutex
+	var received []Event
+	requests := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if got, want := r.Header.Get(SignatureHeader), Sign(secret, body); got != want {
+			t.Errorf("invalid signature, want: %s, got: %s", want, got)
+		}
+		var event Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("failed to parse payload: %v", err)
+		}
+		received = append(received, event)
+	}))
+	defer receiver.Close()
+
+	dispatcher, db := getTestDispatcher(t, Webhook{
+		URL:    receiver.URL,
+		Secret: secret,
+		Events: []string{EventVersionChanged},
+	})
+
+	dispatcher.Notify(
+		Event{Type: EventVersionChanged, Dependency: "github.com/briandowns/spinner", PreviousVersion: "v1.11.1", Version: "v1.23.0"},
+		Event{Type: EventLicenseChanged, Dependency: "github.com/briandowns/spinner"},
+	)
+	dispatcher.Wait()
+
+	if len(received) != 1 || received[0].Version != "v1.23.0" {
+		t.Fatalf("unexpected events received: %+v", received)
+	}
+
+	deliveries, err := db.GetWebhookDeliveries(10)
+	if err != nil {
+		t.Fatal("failed to get webhook deliveries:", err)
+	}
+	if len(deliveries) != 2 || deliveries[1].StatusCode != http.StatusServiceUnavailable || deliveries[0].Attempt != 2 {
+		t.Fatalf("unexpected deliveries logged: %+v", deliveries)
+	}
+}
+
+func TestScoreBelowThreshold(t *testing.T) {
+	webhook := Webhook{Events: []string{EventScoreBelowThreshold}, ScoreThreshold: 5}
+	score := func(s float64) *float64 { return &s }
+
+	tests := []struct {
+		previous, current *float64
+		want              bool
+	}{
+		{nil, score(4), true},
+		{score(6), score(4.5), true},
+		{score(4), score(3), false},
+		{score(6), score(5), false},
+		{nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		event := Event{Type: EventScoreBelowThreshold, PreviousOverallScore: tt.previous, OverallScore: tt.current}
+		payload, got := payloadFor(webhook, event)
+		if got != tt.want {
+			t.Fatalf("unexpected result for %+v, want: %t, got: %t", event, tt.want, got)
+		}
+		if got && *payload.Threshold != webhook.ScoreThreshold {
+			t.Fatalf("threshold missing in payload: %+v", payload)
+		}
+	}
+}
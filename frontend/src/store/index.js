@@ -3,16 +3,30 @@ import axios from 'axios'
 
 axios.defaults.baseURL = process.env.VUE_APP_API_URL
 
-export default createStore({
+// The API key is asked for when it's needed and kept in the session storage, so it's neither built
+// into the frontend nor kept after the tab is closed.
+const apiKeyStorageKey = 'deps-api-key'
+
+const store = createStore({
   state: {
     allDependencies: [],
-    updatedDependencies: []
+    updatedDependencies: [],
+    apiKey: sessionStorage.getItem(apiKeyStorageKey) || ''
   },
   getters: {
     getAllDependencies: state => { return state.allDependencies },
-    getUpdatedDependencies: state => { return state.updatedDependencies }
+    getUpdatedDependencies: state => { return state.updatedDependencies },
+    getApiKey: state => { return state.apiKey }
   },
   mutations: {
+    setApiKey (state, payload) {
+      state.apiKey = payload
+      if (payload) {
+        sessionStorage.setItem(apiKeyStorageKey, payload)
+      } else {
+        sessionStorage.removeItem(apiKeyStorageKey)
+      }
+    },
     setAllDependencies (state, payload) {
       state.allDependencies = payload
     },
@@ -22,7 +36,7 @@ export default createStore({
   },
   actions: {
     getAllDependenciesAction ({ commit, state }) {
-      return axios.get('/dependency/all')
+      return axios.get('/api/v1/projects')
         .then(response => response.data)
         .then(data => {
           commit('setAllDependencies', data)
@@ -31,15 +45,32 @@ export default createStore({
           console.error(err)
         })
     },
+    // updateDependenciesAction resolves to false if the API key was missing or rejected, the key is
+    // forgotten then so it's asked for again.
     updateDependenciesAction ({ commit, state }) {
-      return axios.get('/dependency/update')
+      return axios.post('/api/v1/refresh')
         .then(response => response.data)
         .then(data => {
           commit('setUpdatedDependencies', data)
+          return true
         })
         .catch(err => {
+          if (err.response && [401, 403].includes(err.response.status)) {
+            commit('setApiKey', '')
+            return false
+          }
           console.error(err)
+          return true
         })
     }
   }
 })
+
+axios.interceptors.request.use(config => {
+  if (store.state.apiKey) {
+    config.headers.Authorization = `Bearer ${store.state.apiKey}`
+  }
+  return config
+})
+
+export default store